@@ -1,6 +1,10 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"os"
 	"time"
@@ -8,7 +12,26 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
-var jwtkey = []byte(os.Getenv("JWT_SECRET"))
+// keys signs and verifies tokens. It defaults to the HS256 JWT_SECRET so the
+// package works without configuration; main replaces it via SetKeySet.
+var keys = defaultKeySet()
+
+func defaultKeySet() *KeySet {
+	ks := NewKeySet(AccessTokenTTL)
+	ks.Add(NewHMACKey(DefaultHMACKeyID, []byte(os.Getenv("JWT_SECRET"))))
+	ks.SetSigningKey(DefaultHMACKeyID)
+	return ks
+}
+
+// SetKeySet replaces the keys used by GenerateJWT and VerifyJWT.
+func SetKeySet(ks *KeySet) {
+	keys = ks
+}
+
+// Keys returns the key set in use, e.g. to publish its JWKS.
+func Keys() *KeySet {
+	return keys
+}
 
 type Role string
 
@@ -17,7 +40,24 @@ const (
 	User  Role = "user"
 )
 
-func GenerateJWT(id int64, role ...string) (string, error) {
+const (
+	// AccessTokenTTL is kept short because access tokens are only checked
+	// against the revocation list, never against the session itself.
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// Claims are the fields of a verified access token.
+type Claims struct {
+	UserID    int64
+	Role      Role
+	ID        string
+	ExpiresAt time.Time
+}
+
+// GenerateJWT signs an access token for the user. jti identifies the token
+// so it can be revoked before it expires.
+func GenerateJWT(id int64, jti string, role ...string) (string, error) {
 
 	roleStr := string(User)
 
@@ -25,37 +65,68 @@ func GenerateJWT(id int64, role ...string) (string, error) {
 		roleStr = role[0]
 	}
 
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": id,
 		"role":    roleStr,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(AccessTokenTTL).Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString(jwtkey)
+	return keys.Sign(claims)
 }
 
-func VerifyJWT(tokenString string) (int64, Role, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return jwtkey, nil
-	})
+func VerifyJWT(tokenString string) (*Claims, error) {
+	token, err := keys.Parse(tokenString, time.Now())
 	if err != nil {
-		return 0, "", err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 		userIDFloat, ok := claims["user_id"].(float64)
 		if !ok {
-			return 0, "", errors.New("invalid id claim")
+			return nil, errors.New("invalid id claim")
+		}
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			return nil, errors.New("missing jti claim")
 		}
 		roleStr, _ := claims["role"].(string)
-		id := int64(userIDFloat)
-		return id, Role(roleStr), nil
+		exp, _ := claims["exp"].(float64)
+		return &Claims{
+			UserID:    int64(userIDFloat),
+			Role:      Role(roleStr),
+			ID:        jti,
+			ExpiresAt: time.Unix(int64(exp), 0),
+		}, nil
 	}
 
-	return 0, "", errors.New("invalid token")
+	return nil, errors.New("invalid token")
+}
+
+// NewTokenID returns a random identifier for the jti claim.
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// NewRefreshToken returns an opaque random refresh token. Only its hash is
+// ever stored.
+func NewRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex SHA-256 of a refresh token, the form it is
+// stored and looked up in.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateJWT_RoundTrip(t *testing.T) {
+	token, err := GenerateJWT(42, "abc123", string(Admin))
+	assert.NoError(t, err)
+
+	claims, err := VerifyJWT(token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), claims.UserID)
+	assert.Equal(t, Admin, claims.Role)
+	assert.Equal(t, "abc123", claims.ID)
+	assert.WithinDuration(t, time.Now().Add(AccessTokenTTL), claims.ExpiresAt, 2*time.Second)
+}
+
+func TestVerifyJWT_RejectsMissingJTI(t *testing.T) {
+	token, err := GenerateJWT(42, "")
+	assert.NoError(t, err)
+
+	_, err = VerifyJWT(token)
+
+	assert.Error(t, err)
+}
+
+func TestHashToken_Deterministic(t *testing.T) {
+	token, err := NewRefreshToken()
+	assert.NoError(t, err)
+
+	assert.Equal(t, HashToken(token), HashToken(token))
+	assert.NotEqual(t, token, HashToken(token))
+	assert.Len(t, HashToken(token), 64)
+}
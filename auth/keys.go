@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// DefaultHMACKeyID is the kid of the HS256 key built from JWT_SECRET. Tokens
+// without a kid header, issued before key rotation existed, are verified
+// with it.
+const DefaultHMACKeyID = "hs256"
+
+// Key is one signing or verification key identified by its kid.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	// RetiredAt is set once the key no longer signs. It keeps verifying
+	// tokens issued before then until the key set's grace period has passed.
+	RetiredAt *time.Time
+}
+
+func NewHMACKey(kid string, secret []byte) *Key {
+	return &Key{ID: kid, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// ParsePrivateKeyPEM reads an RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8)
+// private key. RSA keys sign with RS256, Ed25519 keys with EdDSA.
+func ParsePrivateKeyPEM(kid string, pemBytes []byte) (*Key, error) {
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, signKey: rsaKey, verifyKey: &rsaKey.PublicKey}, nil
+	}
+	if edKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes); err == nil {
+		if priv, ok := edKey.(ed25519.PrivateKey); ok {
+			return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, signKey: priv, verifyKey: priv.Public()}, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q: not an RSA or Ed25519 private key", kid)
+}
+
+// usableAt reports whether the key may verify a token issued at issuedAt.
+func (k *Key) usableAt(issuedAt time.Time, now time.Time, grace time.Duration) bool {
+	if k.RetiredAt == nil {
+		return true
+	}
+	return !issuedAt.After(*k.RetiredAt) && now.Before(k.RetiredAt.Add(grace))
+}
+
+// KeySet holds the key that signs new tokens plus every key that still
+// verifies them. Keys that are not signing yet are published in the JWKS so
+// other services can cache them before a rotation.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	order   []string
+	grace   time.Duration
+}
+
+func NewKeySet(grace time.Duration) *KeySet {
+	return &KeySet{keys: make(map[string]*Key), grace: grace}
+}
+
+func (ks *KeySet) Add(key *Key) error {
+	if key.ID == "" {
+		return errors.New("key id is required")
+	}
+	if _, exists := ks.keys[key.ID]; exists {
+		return fmt.Errorf("duplicate key id %q", key.ID)
+	}
+	ks.keys[key.ID] = key
+	ks.order = append(ks.order, key.ID)
+	return nil
+}
+
+func (ks *KeySet) SetSigningKey(kid string) error {
+	key, ok := ks.keys[kid]
+	if !ok {
+		return fmt.Errorf("unknown signing key %q", kid)
+	}
+	if key.RetiredAt != nil {
+		return fmt.Errorf("signing key %q is retired", kid)
+	}
+	ks.signing = key
+	return nil
+}
+
+// Sign signs claims with the current signing key and stamps its kid.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return "", errors.New("no signing key configured")
+	}
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Parse verifies a token against the key named by its kid header. The
+// token's alg must match the key's, so an RSA public key can never be used
+// as an HMAC secret.
+func (ks *KeySet) Parse(tokenString string, now time.Time) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = DefaultHMACKeyID
+		}
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		var issuedAt time.Time
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if iat, ok := claims["iat"].(float64); ok {
+				issuedAt = time.Unix(int64(iat), 0)
+			}
+		}
+		if !key.usableAt(issuedAt, now, ks.grace) {
+			return nil, fmt.Errorf("key %q is retired", kid)
+		}
+		return key.verifyKey, nil
+	})
+}
+
+// JWK is the public half of a key in RFC 7517 form.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that can still verify tokens at now. HMAC
+// keys are secret and never published.
+func (ks *KeySet) JWKS(now time.Time) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, kid := range ks.order {
+		key := ks.keys[kid]
+		if key.RetiredAt != nil && !now.Before(key.RetiredAt.Add(ks.grace)) {
+			continue
+		}
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// LoadKeySetFromEnv builds the key set from:
+//
+//	JWT_SECRET             HS256 secret, kid "hs256" (optional)
+//	JWT_SECRET_RETIRED_AT  RFC 3339 time the secret stopped signing (optional)
+//	JWT_KEYS               comma-separated kid=path.pem[@retired_at] entries
+//	JWT_SIGNING_KEY        kid that signs new tokens; defaults to "hs256"
+//	JWT_KEY_GRACE_PERIOD   how long retired keys keep verifying; defaults to
+//	                       the access token lifetime
+func LoadKeySetFromEnv() (*KeySet, error) {
+	grace := AccessTokenTTL
+	if value := os.Getenv("JWT_KEY_GRACE_PERIOD"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_KEY_GRACE_PERIOD: %w", err)
+		}
+		grace = parsed
+	}
+	ks := NewKeySet(grace)
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		key := NewHMACKey(DefaultHMACKeyID, []byte(secret))
+		if value := os.Getenv("JWT_SECRET_RETIRED_AT"); value != "" {
+			retiredAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("JWT_SECRET_RETIRED_AT: %w", err)
+			}
+			key.RetiredAt = &retiredAt
+		}
+		ks.Add(key)
+	}
+
+	if value := os.Getenv("JWT_KEYS"); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			key, err := loadKeyEntry(strings.TrimSpace(entry))
+			if err != nil {
+				return nil, err
+			}
+			if err := ks.Add(key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	signing := os.Getenv("JWT_SIGNING_KEY")
+	if signing == "" {
+		signing = DefaultHMACKeyID
+	}
+	if err := ks.SetSigningKey(signing); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// loadKeyEntry parses one JWT_KEYS entry of the form kid=path[@retired_at].
+func loadKeyEntry(entry string) (*Key, error) {
+	kid, rest, ok := strings.Cut(entry, "=")
+	if !ok || kid == "" || rest == "" {
+		return nil, fmt.Errorf("JWT_KEYS: malformed entry %q", entry)
+	}
+
+	path := rest
+	var retiredAt *time.Time
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		parsed, err := time.Parse(time.RFC3339, rest[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("JWT_KEYS: key %q: %w", kid, err)
+		}
+		path, retiredAt = rest[:i], &parsed
+	}
+
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_KEYS: key %q: %w", kid, err)
+	}
+	key, err := ParsePrivateKeyPEM(kid, pemBytes)
+	if err != nil {
+		return nil, err
+	}
+	key.RetiredAt = retiredAt
+	return key, nil
+}
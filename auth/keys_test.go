@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pemKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func rsaKey(t *testing.T, kid string) *Key {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := ParsePrivateKeyPEM(kid, pemKey(t, priv))
+	require.NoError(t, err)
+	return key
+}
+
+func edKey(t *testing.T, kid string) *Key {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ParsePrivateKeyPEM(kid, pemKey(t, priv))
+	require.NoError(t, err)
+	return key
+}
+
+func claimsAt(issuedAt time.Time) jwt.MapClaims {
+	return jwt.MapClaims{"user_id": 1, "iat": issuedAt.Unix(), "exp": issuedAt.Add(time.Hour).Unix()}
+}
+
+func TestParsePrivateKeyPEM_Algorithms(t *testing.T) {
+	assert.Equal(t, "RS256", rsaKey(t, "rsa").Method.Alg())
+	assert.Equal(t, "EdDSA", edKey(t, "ed").Method.Alg())
+
+	_, err := ParsePrivateKeyPEM("bad", []byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestKeySet_VerifiesByKid(t *testing.T) {
+	ks := NewKeySet(time.Minute)
+	require.NoError(t, ks.Add(rsaKey(t, "old")))
+	require.NoError(t, ks.Add(edKey(t, "new")))
+	now := time.Now()
+
+	require.NoError(t, ks.SetSigningKey("old"))
+	oldToken, err := ks.Sign(claimsAt(now))
+	require.NoError(t, err)
+
+	require.NoError(t, ks.SetSigningKey("new"))
+	newToken, err := ks.Sign(claimsAt(now))
+	require.NoError(t, err)
+
+	parsed, err := ks.Parse(oldToken, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", parsed.Header["kid"])
+
+	parsed, err = ks.Parse(newToken, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+}
+
+func TestKeySet_RetiredKeyGracePeriod(t *testing.T) {
+	retiredAt := time.Now().Add(-time.Minute)
+	key := edKey(t, "old")
+	ks := NewKeySet(5 * time.Minute)
+	require.NoError(t, ks.Add(key))
+	require.NoError(t, ks.SetSigningKey("old"))
+
+	token, err := ks.Sign(claimsAt(retiredAt.Add(-time.Minute)))
+	require.NoError(t, err)
+	lateToken, err := ks.Sign(claimsAt(retiredAt.Add(time.Second)))
+	require.NoError(t, err)
+	key.RetiredAt = &retiredAt
+
+	_, err = ks.Parse(token, time.Now())
+	assert.NoError(t, err, "within grace period")
+
+	_, err = ks.Parse(lateToken, time.Now())
+	assert.Error(t, err, "issued after retirement")
+
+	_, err = ks.Parse(token, retiredAt.Add(6*time.Minute))
+	assert.Error(t, err, "after grace period")
+
+	assert.Error(t, ks.SetSigningKey("old"))
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	ks := NewKeySet(time.Minute)
+	require.NoError(t, ks.Add(rsaKey(t, "rsa")))
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsAt(time.Now()))
+	forged.Header["kid"] = "rsa"
+	token, err := forged.SignedString([]byte("guess"))
+	require.NoError(t, err)
+
+	_, err = ks.Parse(token, time.Now())
+	assert.Error(t, err)
+}
+
+func TestKeySet_JWKSPublishesOnlyPublicKeys(t *testing.T) {
+	retiredAt := time.Now().Add(-time.Hour)
+	expired := rsaKey(t, "expired")
+	expired.RetiredAt = &retiredAt
+
+	ks := NewKeySet(time.Minute)
+	require.NoError(t, ks.Add(NewHMACKey(DefaultHMACKeyID, []byte("secret"))))
+	require.NoError(t, ks.Add(rsaKey(t, "rsa")))
+	require.NoError(t, ks.Add(edKey(t, "ed")))
+	require.NoError(t, ks.Add(expired))
+
+	set := ks.JWKS(time.Now())
+
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "rsa", set.Keys[0].KeyID)
+	assert.Equal(t, "RSA", set.Keys[0].KeyType)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.Equal(t, "ed", set.Keys[1].KeyID)
+	assert.Equal(t, "OKP", set.Keys[1].KeyType)
+	assert.NotEmpty(t, set.Keys[1].X)
+}
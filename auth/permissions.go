@@ -0,0 +1,66 @@
+package auth
+
+type Permission string
+
+const (
+	PermManageOwnAccounts   Permission = "accounts:manage_own"
+	PermMoveOwnMoney        Permission = "money:move_own"
+	PermReadOwnTransactions Permission = "transactions:read_own"
+	PermReadAllTransactions Permission = "transactions:read_all"
+	PermRefundReceived      Permission = "transactions:refund_received"
+	PermReverseTransactions Permission = "transactions:reverse"
+	PermReadUsers           Permission = "users:read"
+	PermManageRoles         Permission = "users:manage_roles"
+	PermReadAuditLog        Permission = "audit:read"
+	PermManageLimits        Permission = "limits:manage"
+	PermManageFXRates       Permission = "fx:manage_rates"
+	PermManageFees          Permission = "fees:manage"
+	PermManageOverdrafts    Permission = "overdrafts:manage"
+	PermManageInterest      Permission = "interest:manage"
+	PermManageLoans         Permission = "loans:manage"
+	PermManageTermDeposits  Permission = "term_deposits:manage"
+	PermManageStatements    Permission = "statements:manage"
+)
+
+// customerPermissions are granted to every authenticated role.
+var customerPermissions = []Permission{
+	PermManageOwnAccounts,
+	PermMoveOwnMoney,
+	PermReadOwnTransactions,
+	PermRefundReceived,
+}
+
+// RolePermissions maps each role to the permissions it grants.
+var RolePermissions = map[Role][]Permission{
+	User: customerPermissions,
+	Admin: append([]Permission{
+		PermReadAllTransactions,
+		PermReverseTransactions,
+		PermReadUsers,
+		PermManageRoles,
+		PermReadAuditLog,
+		PermManageLimits,
+		PermManageFXRates,
+		PermManageFees,
+		PermManageOverdrafts,
+		PermManageInterest,
+		PermManageLoans,
+		PermManageTermDeposits,
+		PermManageStatements,
+	}, customerPermissions...),
+}
+
+func (r Role) Valid() bool {
+	_, ok := RolePermissions[r]
+	return ok
+}
+
+// Can reports whether the role grants the permission. Unknown roles grant nothing.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range RolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,6 @@
+ALTER TABLE transactions ALTER COLUMN amount DROP NOT NULL;
+ALTER TABLE transactions ALTER COLUMN amount TYPE DECIMAL(10,2) USING (amount / 100.0)::DECIMAL(10,2);
+
+ALTER TABLE users ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN balance TYPE FLOAT USING (balance / 100.0)::FLOAT;
+ALTER TABLE users ALTER COLUMN balance SET DEFAULT 0;
@@ -0,0 +1,7 @@
+-- Store money as integer minor units (cents) instead of FLOAT/DECIMAL.
+ALTER TABLE users ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 100)::BIGINT;
+ALTER TABLE users ALTER COLUMN balance SET DEFAULT 0;
+
+ALTER TABLE transactions ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE transactions ALTER COLUMN amount SET NOT NULL;
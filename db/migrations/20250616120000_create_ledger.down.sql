@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS ledger_entries_append_only ON ledger_entries;
+DROP TRIGGER IF EXISTS ledger_entries_balanced ON ledger_entries;
+DROP FUNCTION IF EXISTS reject_ledger_mutation();
+DROP FUNCTION IF EXISTS check_journal_balanced();
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS journals;
+DROP TABLE IF EXISTS ledger_accounts;
@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(64) UNIQUE NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('asset', 'liability', 'equity', 'revenue', 'expense')),
+    user_id INTEGER REFERENCES users(id) ON DELETE RESTRICT,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS journals (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT REFERENCES transactions(id),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Positive amounts are debits, negative amounts are credits.
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    journal_id BIGINT NOT NULL REFERENCES journals(id),
+    account_id BIGINT NOT NULL REFERENCES ledger_accounts(id),
+    amount BIGINT NOT NULL CHECK (amount <> 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_ledger_entries_journal ON ledger_entries(journal_id);
+CREATE INDEX idx_ledger_entries_account ON ledger_entries(account_id);
+
+-- Every journal must sum to zero. The check is deferred to commit so the
+-- legs of a journal can be inserted one at a time.
+CREATE OR REPLACE FUNCTION check_journal_balanced() RETURNS TRIGGER AS $$
+DECLARE
+    total BIGINT;
+BEGIN
+    SELECT COALESCE(SUM(amount), 0) INTO total FROM ledger_entries WHERE journal_id = NEW.journal_id;
+    IF total <> 0 THEN
+        RAISE EXCEPTION 'journal % is unbalanced by %', NEW.journal_id, total;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_balanced();
+
+-- Ledger entries are append-only; corrections are new journals.
+CREATE OR REPLACE FUNCTION reject_ledger_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger entries are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_mutation();
+
+INSERT INTO ledger_accounts (code, type) VALUES
+    ('cash', 'asset'),
+    ('fee_income', 'revenue'),
+    ('opening_balance_equity', 'equity');
+
+-- Carry existing balances into the ledger as opening journals.
+INSERT INTO ledger_accounts (code, type, user_id)
+SELECT 'user:' || id, 'liability', id FROM users;
+
+DO $$
+DECLARE
+    u RECORD;
+    j BIGINT;
+BEGIN
+    FOR u IN SELECT id, balance FROM users WHERE balance <> 0 LOOP
+        INSERT INTO journals (description) VALUES ('opening balance for user ' || u.id) RETURNING id INTO j;
+        INSERT INTO ledger_entries (journal_id, account_id, amount)
+            SELECT j, id, -u.balance FROM ledger_accounts WHERE code = 'user:' || u.id;
+        INSERT INTO ledger_entries (journal_id, account_id, amount)
+            SELECT j, id, u.balance FROM ledger_accounts WHERE code = 'opening_balance_equity';
+    END LOOP;
+END;
+$$;
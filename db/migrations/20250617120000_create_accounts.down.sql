@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN balance BIGINT NOT NULL DEFAULT 0;
+
+UPDATE users u SET balance = totals.balance
+FROM (SELECT user_id, SUM(balance) AS balance FROM accounts GROUP BY user_id) totals
+WHERE totals.user_id = u.id;
+
+ALTER TABLE transactions DROP COLUMN to_account_id;
+ALTER TABLE transactions DROP COLUMN from_account_id;
+
+UPDATE ledger_accounts SET code = 'user:' || user_id
+WHERE account_id IN (SELECT MIN(id) FROM accounts GROUP BY user_id);
+
+ALTER TABLE ledger_accounts DROP COLUMN account_id;
+
+DROP TABLE IF EXISTS accounts;
+DROP SEQUENCE IF EXISTS account_number_seq;
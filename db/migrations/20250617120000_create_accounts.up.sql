@@ -0,0 +1,40 @@
+CREATE SEQUENCE IF NOT EXISTS account_number_seq START 1000000001;
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE RESTRICT,
+    number VARCHAR(34) UNIQUE NOT NULL DEFAULT lpad(nextval('account_number_seq')::text, 12, '0'),
+    type VARCHAR(20) NOT NULL DEFAULT 'checking' CHECK (type IN ('checking', 'savings')),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    balance BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX idx_accounts_user ON accounts(user_id);
+
+-- Every existing user gets a checking account holding their current balance.
+INSERT INTO accounts (user_id, type, balance)
+SELECT id, 'checking', balance FROM users ORDER BY id;
+
+-- Per-user ledger accounts become per-account ledger accounts.
+ALTER TABLE ledger_accounts ADD COLUMN account_id BIGINT UNIQUE REFERENCES accounts(id);
+
+UPDATE ledger_accounts la
+SET account_id = a.id, code = 'account:' || a.id
+FROM accounts a
+WHERE la.code = 'user:' || a.user_id;
+
+ALTER TABLE transactions ADD COLUMN from_account_id BIGINT REFERENCES accounts(id);
+ALTER TABLE transactions ADD COLUMN to_account_id BIGINT REFERENCES accounts(id);
+
+UPDATE transactions t SET from_account_id = a.id
+FROM accounts a
+WHERE a.user_id = t.sender_id AND t.type IN ('withdraw', 'transfer');
+
+UPDATE transactions t SET to_account_id = a.id
+FROM accounts a
+WHERE a.user_id = t.receiver_id AND t.type IN ('deposit', 'transfer');
+
+ALTER TABLE users DROP COLUMN balance;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_created;
+DROP INDEX IF EXISTS idx_transactions_receiver_created;
+DROP INDEX IF EXISTS idx_transactions_sender_created;
+
+ALTER TABLE transactions ALTER COLUMN created_at DROP NOT NULL;
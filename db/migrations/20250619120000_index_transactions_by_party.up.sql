@@ -0,0 +1,7 @@
+-- Keyset pagination orders by (created_at, id), so created_at must be set.
+UPDATE transactions SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+ALTER TABLE transactions ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_created ON transactions(sender_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver_created ON transactions(receiver_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions(created_at, id);
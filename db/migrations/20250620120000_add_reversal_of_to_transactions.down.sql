@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_transactions_reversal_of;
+ALTER TABLE transactions DROP COLUMN reversal_of;
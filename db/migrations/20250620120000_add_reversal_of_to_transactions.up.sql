@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN reversal_of BIGINT REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_reversal_of ON transactions(reversal_of) WHERE reversal_of IS NOT NULL;
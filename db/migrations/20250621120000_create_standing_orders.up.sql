@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    from_account_id BIGINT NOT NULL REFERENCES accounts(id),
+    to_account_id BIGINT NOT NULL REFERENCES accounts(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('once', 'daily', 'weekly', 'monthly')),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    max_occurrences INTEGER CHECK (max_occurrences > 0),
+    occurrence INTEGER NOT NULL DEFAULT 0,
+    next_run_at TIMESTAMP,
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'completed', 'failed', 'cancelled')),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_standing_orders_user ON standing_orders(user_id);
+CREATE INDEX idx_standing_orders_due ON standing_orders(next_run_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS standing_order_executions (
+    id BIGSERIAL PRIMARY KEY,
+    standing_order_id BIGINT NOT NULL REFERENCES standing_orders(id),
+    occurrence INTEGER NOT NULL,
+    attempt INTEGER NOT NULL,
+    scheduled_for TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'retrying', 'failed')),
+    transaction_id BIGINT REFERENCES transactions(id),
+    error VARCHAR(255),
+    executed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (standing_order_id, occurrence, attempt)
+);
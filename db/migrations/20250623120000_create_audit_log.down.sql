@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+DROP FUNCTION IF EXISTS reject_audit_mutation();
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS limit_usage;
+DROP TABLE IF EXISTS transaction_limits;
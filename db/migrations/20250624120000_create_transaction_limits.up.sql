@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS transaction_limits (
+    id BIGSERIAL PRIMARY KEY,
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('global', 'role', 'user')),
+    role VARCHAR(20),
+    user_id INTEGER REFERENCES users(id),
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('deposit', 'withdraw', 'transfer')),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('per_transaction', 'daily_amount', 'monthly_amount', 'daily_count')),
+    currency VARCHAR(3) NOT NULL,
+    -- Minor units for amount limits, number of transactions for daily_count.
+    value BIGINT NOT NULL CHECK (value > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (
+        (scope = 'global' AND role IS NULL AND user_id IS NULL) OR
+        (scope = 'role' AND role IS NOT NULL AND user_id IS NULL) OR
+        (scope = 'user' AND user_id IS NOT NULL AND role IS NULL)
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS transaction_limits_target_idx ON transaction_limits
+    (scope, COALESCE(role, ''), COALESCE(user_id, 0), operation, kind, currency);
+
+-- Running totals per user, operation, currency and UTC day. The debit's
+-- transaction upserts its row before checking limits, so concurrent requests
+-- for the same user queue on that row instead of both passing the check.
+CREATE TABLE IF NOT EXISTS limit_usage (
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    operation VARCHAR(20) NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    day DATE NOT NULL,
+    amount BIGINT NOT NULL DEFAULT 0,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, operation, currency, day)
+);
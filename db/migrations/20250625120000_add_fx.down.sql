@@ -0,0 +1,21 @@
+CREATE OR REPLACE FUNCTION check_journal_balanced() RETURNS TRIGGER AS $$
+DECLARE
+    total BIGINT;
+BEGIN
+    SELECT COALESCE(SUM(amount), 0) INTO total FROM ledger_entries WHERE journal_id = NEW.journal_id;
+    IF total <> 0 THEN
+        RAISE EXCEPTION 'journal % is unbalanced by %', NEW.journal_id, total;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS fx_quote_id,
+    DROP COLUMN IF EXISTS fx_rate,
+    DROP COLUMN IF EXISTS to_currency,
+    DROP COLUMN IF EXISTS to_amount,
+    DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS fx_quotes;
+DROP TABLE IF EXISTS fx_rates;
@@ -0,0 +1,69 @@
+-- Mid-market rates. rate is the price of one major unit of base in quote;
+-- customers are charged spread_bps basis points off it. A rate applies from
+-- effective_at until a newer one for the same pair takes over.
+CREATE TABLE IF NOT EXISTS fx_rates (
+    id BIGSERIAL PRIMARY KEY,
+    base CHAR(3) NOT NULL,
+    quote CHAR(3) NOT NULL CHECK (quote <> base),
+    rate NUMERIC(24, 12) NOT NULL CHECK (rate > 0),
+    spread_bps INTEGER NOT NULL DEFAULT 0 CHECK (spread_bps >= 0 AND spread_bps < 10000),
+    source VARCHAR(16) NOT NULL,
+    effective_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (base, quote, effective_at)
+);
+
+-- A quote fixes a conversion for a short time; a transfer can redeem it once.
+CREATE TABLE IF NOT EXISTS fx_quotes (
+    id CHAR(32) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    from_amount BIGINT NOT NULL CHECK (from_amount > 0),
+    from_currency CHAR(3) NOT NULL,
+    to_amount BIGINT NOT NULL CHECK (to_amount > 0),
+    to_currency CHAR(3) NOT NULL,
+    rate NUMERIC(24, 12) NOT NULL,
+    mid_rate NUMERIC(24, 12) NOT NULL,
+    spread_bps INTEGER NOT NULL,
+    rate_effective_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS fx_quotes_unused_idx ON fx_quotes (expires_at) WHERE used_at IS NULL;
+
+-- amount and currency are what left the sender; a cross-currency transfer
+-- also records what the receiver got and the rate applied.
+ALTER TABLE transactions
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN to_amount BIGINT,
+    ADD COLUMN to_currency CHAR(3),
+    ADD COLUMN fx_rate NUMERIC(24, 12),
+    ADD COLUMN fx_quote_id CHAR(32) REFERENCES fx_quotes(id);
+
+UPDATE transactions t SET currency = a.currency
+FROM accounts a
+WHERE a.id = COALESCE(t.from_account_id, t.to_account_id);
+
+-- Money passing between currencies is parked on an FX position account per
+-- currency. The USD one joins the other seeded system accounts.
+INSERT INTO ledger_accounts (code, type) VALUES ('fx_position', 'equity');
+
+-- Journals must now balance within each currency, not just in total.
+CREATE OR REPLACE FUNCTION check_journal_balanced() RETURNS TRIGGER AS $$
+DECLARE
+    unbalanced RECORD;
+BEGIN
+    SELECT la.currency, SUM(e.amount) AS total INTO unbalanced
+    FROM ledger_entries e
+    JOIN ledger_accounts la ON la.id = e.account_id
+    WHERE e.journal_id = NEW.journal_id
+    GROUP BY la.currency
+    HAVING SUM(e.amount) <> 0
+    LIMIT 1;
+    IF FOUND THEN
+        RAISE EXCEPTION 'journal % is unbalanced by % %', NEW.journal_id, unbalanced.total, unbalanced.currency;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,36 @@
+-- Amounts are minor units of the rule's currency. tiers is a JSON array of
+-- {"up_to", "flat", "bps"} objects ordered by up_to, the last without one.
+CREATE TABLE IF NOT EXISTS fee_rules (
+    id BIGSERIAL PRIMARY KEY,
+    fee_type VARCHAR(20) NOT NULL CHECK (fee_type IN ('withdraw', 'transfer', 'fx')),
+    role VARCHAR(20),
+    currency VARCHAR(3) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('flat', 'percentage', 'tiered')),
+    flat BIGINT CHECK (flat > 0),
+    bps INTEGER CHECK (bps > 0 AND bps <= 10000),
+    tiers TEXT,
+    min_fee BIGINT CHECK (min_fee >= 0),
+    max_fee BIGINT CHECK (max_fee >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (
+        (kind = 'flat' AND flat IS NOT NULL) OR
+        (kind = 'percentage' AND bps IS NOT NULL) OR
+        (kind = 'tiered' AND tiers IS NOT NULL)
+    )
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS fee_rules_target_idx ON fee_rules
+    (fee_type, COALESCE(role, ''), currency);
+
+-- The fees charged on each transaction, one row per fee type.
+CREATE TABLE IF NOT EXISTS transaction_fees (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id),
+    rule_id BIGINT REFERENCES fee_rules(id) ON DELETE SET NULL,
+    fee_type VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS transaction_fees_transaction_idx ON transaction_fees (transaction_id);
@@ -0,0 +1,26 @@
+-- held is the part of the balance reserved by active holds; what an account
+-- can spend is balance - held. The ledger only changes when a hold is captured.
+ALTER TABLE accounts ADD COLUMN held BIGINT NOT NULL DEFAULT 0 CHECK (held >= 0);
+
+-- A hold reserves amount on account_id for a later transfer to to_account_id.
+-- It ends captured (possibly for less than amount), released or expired.
+CREATE TABLE IF NOT EXISTS holds (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    to_account_id BIGINT NOT NULL REFERENCES accounts(id) CHECK (to_account_id <> account_id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    captured_amount BIGINT CHECK (captured_amount > 0 AND captured_amount <= amount),
+    transaction_id BIGINT REFERENCES transactions(id),
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'captured', 'released', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX idx_holds_account ON holds(account_id);
+CREATE INDEX idx_holds_to_account ON holds(to_account_id);
+CREATE INDEX idx_holds_expiry ON holds(expires_at) WHERE status = 'active';
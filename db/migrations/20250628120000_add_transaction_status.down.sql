@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS transactions_status_history ON transactions;
+DROP TRIGGER IF EXISTS transactions_status_transition ON transactions;
+DROP FUNCTION IF EXISTS record_transaction_status();
+DROP FUNCTION IF EXISTS check_transaction_status();
+DROP TABLE IF EXISTS transaction_status_history;
+DELETE FROM transactions WHERE status IN ('pending', 'failed');
+ALTER TABLE transactions
+    DROP CONSTRAINT IF EXISTS transactions_failure_reason,
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS failure_reason,
+    DROP COLUMN IF EXISTS status;
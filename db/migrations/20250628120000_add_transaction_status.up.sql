@@ -0,0 +1,82 @@
+-- Transactions move through pending -> completed -> reversed, or
+-- pending -> failed. A failed transaction records why it was refused and
+-- never reaches the ledger.
+ALTER TABLE transactions
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'completed'
+        CHECK (status IN ('pending', 'completed', 'failed', 'reversed')),
+    ADD COLUMN failure_reason VARCHAR(32)
+        CHECK (failure_reason IN ('insufficient_funds', 'limit_exceeded', 'fraud_block', 'fx_rate_unavailable')),
+    ADD COLUMN status_changed_at TIMESTAMP,
+    ADD CONSTRAINT transactions_failure_reason CHECK ((status = 'failed') = (failure_reason IS NOT NULL));
+
+UPDATE transactions SET status_changed_at = created_at;
+
+-- Transfers already sent back in full are reversed.
+UPDATE transactions t
+SET status = 'reversed',
+    status_changed_at = r.last_at
+FROM (
+    SELECT reversal_of, SUM(amount) AS total, MAX(created_at) AS last_at
+    FROM transactions WHERE reversal_of IS NOT NULL GROUP BY reversal_of
+) r
+WHERE r.reversal_of = t.id AND r.total >= COALESCE(t.to_amount, t.amount);
+
+ALTER TABLE transactions
+    ALTER COLUMN status SET DEFAULT 'pending',
+    ALTER COLUMN status_changed_at SET NOT NULL;
+
+CREATE INDEX idx_transactions_status ON transactions(status) WHERE status <> 'completed';
+
+CREATE TABLE IF NOT EXISTS transaction_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id),
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    reason VARCHAR(32),
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_transaction_status_history_transaction ON transaction_status_history(transaction_id);
+
+INSERT INTO transaction_status_history (transaction_id, to_status, changed_at)
+SELECT id, 'completed', created_at FROM transactions;
+
+INSERT INTO transaction_status_history (transaction_id, from_status, to_status, changed_at)
+SELECT id, 'completed', 'reversed', status_changed_at FROM transactions WHERE status = 'reversed';
+
+-- New transactions start pending, and only the transitions below are allowed.
+-- Every status a transaction takes, including its first, is written to the
+-- history with the time it changed.
+CREATE OR REPLACE FUNCTION check_transaction_status() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        IF NEW.status <> 'pending' THEN
+            RAISE EXCEPTION 'transaction % must start pending, not %', NEW.id, NEW.status;
+        END IF;
+    ELSIF NEW.status IS DISTINCT FROM OLD.status THEN
+        IF NOT ((OLD.status = 'pending' AND NEW.status IN ('completed', 'failed'))
+             OR (OLD.status = 'completed' AND NEW.status = 'reversed')) THEN
+            RAISE EXCEPTION 'transaction % cannot move from % to %', NEW.id, OLD.status, NEW.status;
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_status_transition
+    BEFORE INSERT OR UPDATE OF status ON transactions
+    FOR EACH ROW EXECUTE FUNCTION check_transaction_status();
+
+CREATE OR REPLACE FUNCTION record_transaction_status() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.status IS DISTINCT FROM OLD.status THEN
+        INSERT INTO transaction_status_history (transaction_id, from_status, to_status, reason, changed_at)
+        VALUES (NEW.id, CASE WHEN TG_OP = 'UPDATE' THEN OLD.status END, NEW.status, NEW.failure_reason, NEW.status_changed_at);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transactions_status_history
+    AFTER INSERT OR UPDATE OF status ON transactions
+    FOR EACH ROW EXECUTE FUNCTION record_transaction_status();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS overdraft_interest;
+DROP TABLE IF EXISTS overdrafts;
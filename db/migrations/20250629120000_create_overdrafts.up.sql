@@ -0,0 +1,40 @@
+-- An overdraft lets a user's checking accounts in one currency spend below
+-- zero, together by at most limit_amount. interest_bps is the yearly rate
+-- charged each day on negative balances. last_drawn_at is touched by every
+-- debit that draws on the overdraft, so concurrent debits queue on the row.
+CREATE TABLE IF NOT EXISTS overdrafts (
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    currency CHAR(3) NOT NULL,
+    limit_amount BIGINT NOT NULL CHECK (limit_amount >= 0),
+    interest_bps INTEGER NOT NULL CHECK (interest_bps BETWEEN 0 AND 10000),
+    last_drawn_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, currency)
+);
+
+-- At most one interest charge per account and UTC day.
+CREATE TABLE IF NOT EXISTS overdraft_interest (
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    day DATE NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id),
+    PRIMARY KEY (account_id, day)
+);
+
+-- Messages for users, such as an account going into overdraft.
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    kind VARCHAR(40) NOT NULL,
+    message TEXT NOT NULL,
+    account_id BIGINT REFERENCES accounts(id),
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id);
+
+-- Overdraft interest is income. The USD account joins the other seeded
+-- system accounts.
+INSERT INTO ledger_accounts (code, type) VALUES ('interest_income', 'revenue');
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS interest_accrual_days;
+DROP TABLE IF EXISTS interest_accruals;
+DROP TABLE IF EXISTS interest_attachments;
+DROP TABLE IF EXISTS interest_products;
@@ -0,0 +1,65 @@
+-- An interest product pays rate_bps a year on the balance of the accounts it
+-- is attached to. day_count sets the share of the yearly rate one day earns,
+-- compounding how often accrued interest starts earning interest itself and
+-- payout how often it is paid into the account.
+CREATE TABLE IF NOT EXISTS interest_products (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    account_type VARCHAR(20) NOT NULL CHECK (account_type IN ('checking', 'savings')),
+    rate_bps INTEGER NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000),
+    day_count VARCHAR(10) NOT NULL CHECK (day_count IN ('act/365', 'act/360', 'act/act', '30/360')),
+    compounding VARCHAR(10) NOT NULL CHECK (compounding IN ('none', 'daily', 'monthly')),
+    payout VARCHAR(10) NOT NULL CHECK (payout IN ('monthly', 'quarterly', 'annually')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A product attached to one account, or to every account of a user in the
+-- product's currency and account type. An account's own attachment wins
+-- over its owner's. Accrual starts on the day of updated_at.
+CREATE TABLE IF NOT EXISTS interest_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES interest_products(id),
+    user_id INTEGER REFERENCES users(id),
+    account_id BIGINT REFERENCES accounts(id),
+    currency CHAR(3) NOT NULL,
+    account_type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((user_id IS NULL) <> (account_id IS NULL))
+);
+
+CREATE UNIQUE INDEX interest_attachments_account_idx ON interest_attachments(account_id)
+    WHERE account_id IS NOT NULL;
+CREATE UNIQUE INDEX interest_attachments_user_idx ON interest_attachments(user_id, currency, account_type)
+    WHERE user_id IS NOT NULL;
+
+-- Interest earned but not yet paid, per account, in minor units with
+-- fractions kept. compounded is the part of it that already earns interest.
+-- accrued_through is the last day accrued; the row is locked while a day is
+-- accrued, so each day is accrued once.
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    account_id BIGINT PRIMARY KEY REFERENCES accounts(id),
+    accrued NUMERIC(30, 12) NOT NULL DEFAULT 0,
+    compounded NUMERIC(30, 12) NOT NULL DEFAULT 0,
+    accrued_through DATE,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- What each day earned, on which balance and at which rate.
+-- transaction_id is the payout made at the end of that day, if any.
+CREATE TABLE IF NOT EXISTS interest_accrual_days (
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    day DATE NOT NULL,
+    product_id BIGINT NOT NULL REFERENCES interest_products(id),
+    base NUMERIC(30, 12) NOT NULL,
+    rate_bps INTEGER NOT NULL,
+    amount NUMERIC(30, 12) NOT NULL,
+    transaction_id BIGINT REFERENCES transactions(id),
+    PRIMARY KEY (account_id, day)
+);
+
+-- Interest paid to customers is an expense. The USD account joins the other
+-- seeded system accounts.
+INSERT INTO ledger_accounts (code, type) VALUES ('interest_expense', 'expense');
@@ -0,0 +1,53 @@
+-- A loan is applied for by a user, to be paid into and repaid from one of
+-- their accounts, in that account's currency. An admin approves it with a
+-- yearly rate and a late fee, which disburses it, or rejects it.
+-- outstanding is the principal not yet repaid.
+CREATE TABLE IF NOT EXISTS loans (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    currency CHAR(3) NOT NULL,
+    principal BIGINT NOT NULL CHECK (principal > 0),
+    outstanding BIGINT NOT NULL CHECK (outstanding >= 0 AND outstanding <= principal),
+    term_months INTEGER NOT NULL CHECK (term_months BETWEEN 1 AND 360),
+    method VARCHAR(20) NOT NULL CHECK (method IN ('annuity', 'equal_principal')),
+    rate_bps INTEGER CHECK (rate_bps BETWEEN 0 AND 10000),
+    late_fee BIGINT NOT NULL DEFAULT 0 CHECK (late_fee >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'rejected', 'active', 'repaid')),
+    decided_by INTEGER REFERENCES users(id),
+    decided_at TIMESTAMP,
+    disbursed_at TIMESTAMP,
+    disbursement_transaction_id BIGINT REFERENCES transactions(id),
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (status IN ('pending', 'rejected') OR rate_bps IS NOT NULL)
+);
+
+CREATE INDEX idx_loans_user ON loans(user_id);
+CREATE INDEX idx_loans_account ON loans(account_id) WHERE status IN ('pending', 'active');
+
+-- One row per monthly installment of an active loan. Installments not yet
+-- due are rewritten when part of the loan is repaid early. late_fee is added
+-- once an installment is overdue; last_attempt_on is the last day collection
+-- was tried, so a failed collection is retried once a day.
+CREATE TABLE IF NOT EXISTS loan_installments (
+    loan_id BIGINT NOT NULL REFERENCES loans(id),
+    seq INTEGER NOT NULL CHECK (seq > 0),
+    due_date DATE NOT NULL,
+    principal BIGINT NOT NULL CHECK (principal >= 0),
+    interest BIGINT NOT NULL CHECK (interest >= 0),
+    late_fee BIGINT NOT NULL DEFAULT 0 CHECK (late_fee >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'due' CHECK (status IN ('due', 'paid')),
+    last_attempt_on DATE,
+    transaction_id BIGINT REFERENCES transactions(id),
+    paid_at TIMESTAMP,
+    PRIMARY KEY (loan_id, seq)
+);
+
+CREATE INDEX idx_loan_installments_due ON loan_installments(due_date) WHERE status = 'due';
+
+-- Money lent out is an asset of the bank until it is repaid. The USD account
+-- joins the other seeded system accounts.
+INSERT INTO ledger_accounts (code, type) VALUES ('loans_receivable', 'asset');
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS term_deposits;
+DROP TABLE IF EXISTS term_deposit_products;
@@ -0,0 +1,49 @@
+-- A term deposit product locks money for term_months at a fixed yearly
+-- rate_bps. Breaking a deposit early forfeits its interest and costs
+-- penalty_bps of the principal. Only active products take new deposits or
+-- rollovers.
+CREATE TABLE IF NOT EXISTS term_deposit_products (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    term_months INTEGER NOT NULL CHECK (term_months BETWEEN 1 AND 120),
+    rate_bps INTEGER NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000),
+    penalty_bps INTEGER NOT NULL CHECK (penalty_bps BETWEEN 0 AND 10000),
+    min_amount BIGINT NOT NULL DEFAULT 0 CHECK (min_amount >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A term deposit holds principal, taken from account_id, in its own ledger
+-- account until matures_on. The product's terms are copied in when it
+-- opens. At maturity it is paid back to account_id with interest, or rolled
+-- over into a new deposit (rolled_from_id) if rollover is set. A deposit
+-- broken early is paid back less penalty.
+CREATE TABLE IF NOT EXISTS term_deposits (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    product_id BIGINT NOT NULL REFERENCES term_deposit_products(id),
+    currency CHAR(3) NOT NULL,
+    principal BIGINT NOT NULL CHECK (principal > 0),
+    rate_bps INTEGER NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000),
+    penalty_bps INTEGER NOT NULL CHECK (penalty_bps BETWEEN 0 AND 10000),
+    term_months INTEGER NOT NULL CHECK (term_months > 0),
+    starts_on DATE NOT NULL,
+    matures_on DATE NOT NULL CHECK (matures_on > starts_on),
+    rollover BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'matured', 'rolled_over', 'broken')),
+    interest BIGINT CHECK (interest >= 0),
+    penalty BIGINT CHECK (penalty >= 0),
+    open_transaction_id BIGINT REFERENCES transactions(id),
+    close_transaction_id BIGINT REFERENCES transactions(id),
+    rolled_from_id BIGINT REFERENCES term_deposits(id),
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_term_deposits_user ON term_deposits(user_id);
+CREATE INDEX idx_term_deposits_maturity ON term_deposits(matures_on) WHERE status = 'active';
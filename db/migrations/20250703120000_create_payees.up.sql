@@ -0,0 +1,13 @@
+-- A payee is a recipient a user has saved under a name of their own, so
+-- later transfers can be sent to it by payee ID. It points at the account
+-- the recipient was resolved to when it was saved.
+CREATE TABLE IF NOT EXISTS payees (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL CHECK (name <> ''),
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE INDEX idx_payees_user ON payees(user_id);
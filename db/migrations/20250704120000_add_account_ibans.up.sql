@@ -0,0 +1,4 @@
+-- Every account gets an IBAN built from its number under the bank's
+-- configured country and bank code. Existing accounts are given theirs by
+-- the application at start-up, since the configuration is not known here.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS iban VARCHAR(34) UNIQUE;
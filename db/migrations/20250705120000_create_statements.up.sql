@@ -0,0 +1,44 @@
+-- A statement is a snapshot of an account's movements over a period, taken
+-- by the monthly statement job once the period is over. Statements and their
+-- lines are append-only, so a statement once issued never changes.
+CREATE TABLE IF NOT EXISTS statements (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts(id),
+    -- The period covers period_start up to but not including period_end.
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL CHECK (period_end > period_start),
+    currency CHAR(3) NOT NULL,
+    opening_balance BIGINT NOT NULL,
+    total_in BIGINT NOT NULL CHECK (total_in >= 0),
+    total_out BIGINT NOT NULL CHECK (total_out >= 0),
+    closing_balance BIGINT NOT NULL CHECK (closing_balance = opening_balance + total_in - total_out),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, period_start)
+);
+
+CREATE TABLE IF NOT EXISTS statement_lines (
+    statement_id BIGINT NOT NULL REFERENCES statements(id),
+    line_no INTEGER NOT NULL,
+    journal_id BIGINT NOT NULL REFERENCES journals(id),
+    transaction_id BIGINT REFERENCES transactions(id),
+    type VARCHAR(32),
+    description VARCHAR(255) NOT NULL,
+    posted_at TIMESTAMP NOT NULL,
+    amount BIGINT NOT NULL,
+    balance BIGINT NOT NULL,
+    PRIMARY KEY (statement_id, line_no)
+);
+
+CREATE OR REPLACE FUNCTION reject_statement_mutation() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'statements are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER statements_append_only
+    BEFORE UPDATE OR DELETE ON statements
+    FOR EACH ROW EXECUTE FUNCTION reject_statement_mutation();
+
+CREATE TRIGGER statement_lines_append_only
+    BEFORE UPDATE OR DELETE ON statement_lines
+    FOR EACH ROW EXECUTE FUNCTION reject_statement_mutation();
@@ -0,0 +1,17 @@
+-- A statement PDF is the printable monthly statement of all of a customer's
+-- accounts, rendered by the statement PDF job once the month is over and
+-- kept as it was issued. Like statements, they are append-only.
+CREATE TABLE IF NOT EXISTS statement_pdfs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    -- The period covers period_start up to but not including period_end.
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL CHECK (period_end > period_start),
+    pdf BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, period_start)
+);
+
+CREATE TRIGGER statement_pdfs_append_only
+    BEFORE UPDATE OR DELETE ON statement_pdfs
+    FOR EACH ROW EXECUTE FUNCTION reject_statement_mutation();
@@ -0,0 +1,19 @@
+-- Audit entries of money actions are written here in the same transaction as
+-- the ledger posting, so an action that commits always keeps its entry. They
+-- are moved onto the hash chain in audit_log afterwards, oldest first.
+CREATE TABLE IF NOT EXISTS audit_queue (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    outcome VARCHAR(16) NOT NULL CHECK (outcome IN ('success', 'failure')),
+    actor_id INTEGER REFERENCES users(id),
+    target_type VARCHAR(32) NOT NULL DEFAULT '',
+    target_id BIGINT,
+    amount BIGINT,
+    currency VARCHAR(3),
+    error TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT '{}',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
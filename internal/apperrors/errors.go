@@ -37,11 +37,36 @@ var (
 		StatusCode: http.StatusUnauthorized,
 	}
 
+	ErrInvalidRefreshToken = &AppError{
+		Message:    "invalid or expired refresh token",
+		StatusCode: http.StatusUnauthorized,
+	}
+
+	ErrRefreshTokenReused = &AppError{
+		Message:    "refresh token was already used; the session has been revoked",
+		StatusCode: http.StatusUnauthorized,
+	}
+
 	ErrEmailPasswordRequired = &AppError{
 		Message:    "email and password are required",
 		StatusCode: http.StatusBadRequest,
 	}
 
+	ErrForbidden = &AppError{
+		Message:    "forbidden",
+		StatusCode: http.StatusForbidden,
+	}
+
+	ErrInvalidRole = &AppError{
+		Message:    "role must be admin or user",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrChangeOwnRole = &AppError{
+		Message:    "cannot change your own role",
+		StatusCode: http.StatusForbidden,
+	}
+
 	ErrNoUsersFound = &AppError{
 		Message:    "no users yet",
 		StatusCode: http.StatusNotFound,
@@ -55,13 +80,33 @@ var (
 		StatusCode: http.StatusBadRequest,
 	}
 
+	ErrAmountTooPrecise = &AppError{
+		Message:    "amount has more decimal places than the currency allows",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidAmountFormat = &AppError{
+		Message:    "amount must be a decimal number",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrUnsupportedCurrency = &AppError{
+		Message:    "unsupported currency",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrCurrencyMismatch = &AppError{
+		Message:    "currency does not match the account currency",
+		StatusCode: http.StatusBadRequest,
+	}
+
 	ErrInsufficientFunds = &AppError{
 		Message:    "insufficient funds",
 		StatusCode: http.StatusBadRequest,
 	}
 
 	ErrSelfTransfer = &AppError{
-		Message:    "cannot transfer to yourself",
+		Message:    "cannot transfer to the same account",
 		StatusCode: http.StatusBadRequest,
 	}
 
@@ -79,6 +124,440 @@ var (
 		Message:    "receiver not provided",
 		StatusCode: http.StatusBadRequest,
 	}
+
+	ErrTransactionNotFound = &AppError{
+		Message:    "transaction not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrNotReversible = &AppError{
+		Message:    "only transfers can be reversed or refunded",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrAlreadyReversed = &AppError{
+		Message:    "transaction has already been fully reversed",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrReversalExceedsOriginal = &AppError{
+		Message:    "amount exceeds what remains of the original transaction",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidStatusTransition = &AppError{
+		Message:    "transaction status does not allow this change",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrInvalidCursor = &AppError{
+		Message:    "invalid pagination cursor",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidIdempotencyKey = &AppError{
+		Message:    "Idempotency-Key must be between 1 and 255 characters",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrIdempotencyKeyReused = &AppError{
+		Message:    "Idempotency-Key was already used with a different request",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrIdempotencyInProgress = &AppError{
+		Message:    "a request with this Idempotency-Key is already being processed",
+		StatusCode: http.StatusConflict,
+	}
+)
+
+// Predefined errors for Account operations
+var (
+	ErrAccountNotFound = &AppError{
+		Message:    "account not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrAccountNotProvided = &AppError{
+		Message:    "account not provided",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrAccountClosed = &AppError{
+		Message:    "account is closed",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrAccountNotEmpty = &AppError{
+		Message:    "account balance must be zero to close it",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrAccountHasHolds = &AppError{
+		Message:    "account has active holds",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrAccountHasLoans = &AppError{
+		Message:    "account has a loan that is pending or being repaid",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrAccountHasTermDeposits = &AppError{
+		Message:    "account has active term deposits",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrInvalidAccountType = &AppError{
+		Message:    "account type must be checking or savings",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Hold operations
+var (
+	ErrHoldNotFound = &AppError{
+		Message:    "hold not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrHoldNotActive = &AppError{
+		Message:    "hold has already been captured, released or expired",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrHoldExpired = &AppError{
+		Message:    "hold has expired",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrCaptureExceedsHold = &AppError{
+		Message:    "amount exceeds the held amount",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidHoldExpiry = &AppError{
+		Message:    "expires_in must be a positive duration no longer than the maximum hold time",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Overdraft operations
+var (
+	ErrInvalidOverdraft = &AppError{
+		Message:    "overdraft limit must be a non-negative amount in a supported currency and interest_bps between 0 and 10000",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Notification operations
+var (
+	ErrNotificationNotFound = &AppError{
+		Message:    "notification not found",
+		StatusCode: http.StatusNotFound,
+	}
+)
+
+// Predefined errors for Interest operations
+var (
+	ErrInterestProductNotFound = &AppError{
+		Message:    "interest product not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidInterestProduct = &AppError{
+		Message:    "interest product needs a name, a supported currency and account type, rate_bps between 0 and 10000, and a valid day_count, compounding and payout",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInterestProductMismatch = &AppError{
+		Message:    "interest product currency and account type cannot change and must match the account",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidInterestAttachment = &AppError{
+		Message:    "interest product must be attached to exactly one of user_id or account_id",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInterestAttachmentNotFound = &AppError{
+		Message:    "interest attachment not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrNoInterest = &AppError{
+		Message:    "account earns no interest",
+		StatusCode: http.StatusNotFound,
+	}
+)
+
+// Predefined errors for Loan operations
+var (
+	ErrLoanNotFound = &AppError{
+		Message:    "loan not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidLoan = &AppError{
+		Message:    "loan needs a positive amount, a term of 1 to 360 months and a method of annuity or equal_principal",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidLoanTerms = &AppError{
+		Message:    "loan approval needs rate_bps between 0 and 10000 and a non-negative late fee in the loan's currency",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrLoanNotPending = &AppError{
+		Message:    "loan has already been decided",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrLoanNotActive = &AppError{
+		Message:    "loan is not being repaid",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrLoanInArrears = &AppError{
+		Message:    "loan has installments that are due; they are collected before an early repayment",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrInvalidLoanRepayment = &AppError{
+		Message:    "early repayment must be a positive amount in the loan's currency and at most what is outstanding",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Term Deposit operations
+var (
+	ErrTermDepositProductNotFound = &AppError{
+		Message:    "term deposit product not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidTermDepositProduct = &AppError{
+		Message:    "term deposit product needs a name, a supported currency, a term of 1 to 120 months, rate_bps and penalty_bps between 0 and 10000, and a non-negative min_amount in its currency",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrTermDepositProductMismatch = &AppError{
+		Message:    "term deposit product currency cannot change",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrTermDepositProductInactive = &AppError{
+		Message:    "term deposit product is not taking new deposits",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrTermDepositBelowMinimum = &AppError{
+		Message:    "amount is below the product's minimum deposit",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrTermDepositNotFound = &AppError{
+		Message:    "term deposit not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrTermDepositNotActive = &AppError{
+		Message:    "term deposit has already matured, rolled over or been broken",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrTermDepositMatured = &AppError{
+		Message:    "term deposit has matured and can no longer be broken",
+		StatusCode: http.StatusConflict,
+	}
+)
+
+// Predefined errors for Payee operations
+var (
+	ErrPayeeNotFound = &AppError{
+		Message:    "payee not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidPayee = &AppError{
+		Message:    "payee needs a name of up to 100 characters and a recipient by account number, IBAN, username or email",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrPayeeExists = &AppError{
+		Message:    "you already have a payee with this name",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrInvalidRecipient = &AppError{
+		Message:    "recipient must be given exactly one way: account ID, account number, IBAN, username, email or payee ID",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidIBAN = &AppError{
+		Message:    "IBAN is not valid; check its length and check digits",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Statement operations
+var (
+	ErrStatementNotFound = &AppError{
+		Message:    "statement not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidStatementPeriod = &AppError{
+		Message:    "statement period must start before it ends, start in the past and cover at most a year",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidStatementMonth = &AppError{
+		Message:    "statement month must be written YYYY-MM and not be in the future",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrStatementMonthNotOver = &AppError{
+		Message:    "statement PDFs can only be generated once the month is over",
+		StatusCode: http.StatusConflict,
+	}
+)
+
+// Predefined errors for Standing Order operations
+var (
+	ErrStandingOrderNotFound = &AppError{
+		Message:    "standing order not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrStandingOrderFinished = &AppError{
+		Message:    "standing order is no longer active",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrInvalidFrequency = &AppError{
+		Message:    "frequency must be once, daily, weekly or monthly",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidStartDate = &AppError{
+		Message:    "start date must be today or later",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidEndDate = &AppError{
+		Message:    "end date must not be before the start date",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidMaxOccurrences = &AppError{
+		Message:    "max occurrences must be at least 1",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidStandingOrderStatus = &AppError{
+		Message:    "status must be active or paused",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Limit operations
+var (
+	ErrLimitNotFound = &AppError{
+		Message:    "limit not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidLimitScope = &AppError{
+		Message:    "scope must be global, role or user",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidLimitOperation = &AppError{
+		Message:    "operation must be deposit, withdraw or transfer",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidLimitKind = &AppError{
+		Message:    "kind must be per_transaction, daily_amount, monthly_amount or daily_count",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidLimitValue = &AppError{
+		Message:    "amount limits need a positive amount and count limits a positive count",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for Fee operations
+var (
+	ErrFeeRuleNotFound = &AppError{
+		Message:    "fee rule not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrInvalidFeeType = &AppError{
+		Message:    "type must be withdraw, transfer or fx",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidFeeOperation = &AppError{
+		Message:    "operation must be withdraw or transfer",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidFeeKind = &AppError{
+		Message:    "kind must be flat, percentage or tiered",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrInvalidFeeRule = &AppError{
+		Message:    "fee amounts must be non-negative and in the rule's currency, percentages between 0 and 10000 basis points, and tiers ordered with only the last open-ended",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// Predefined errors for FX operations
+var (
+	ErrFXRateUnavailable = &AppError{
+		Message:    "no current exchange rate for this currency pair",
+		StatusCode: http.StatusUnprocessableEntity,
+	}
+
+	ErrInvalidFXRate = &AppError{
+		Message:    "rates need two different supported currencies, a positive decimal rate and a spread below 10000 basis points",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrSameCurrency = &AppError{
+		Message:    "from and to currencies must differ",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrFXAmountTooSmall = &AppError{
+		Message:    "amount is too small to convert",
+		StatusCode: http.StatusBadRequest,
+	}
+
+	ErrFXQuoteNotFound = &AppError{
+		Message:    "quote not found",
+		StatusCode: http.StatusNotFound,
+	}
+
+	ErrFXQuoteExpired = &AppError{
+		Message:    "quote has expired",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrFXQuoteUsed = &AppError{
+		Message:    "quote has already been used",
+		StatusCode: http.StatusConflict,
+	}
+
+	ErrFXQuoteMismatch = &AppError{
+		Message:    "quote does not match the transfer amount or accounts",
+		StatusCode: http.StatusBadRequest,
+	}
 )
 
 // Generic server errors
@@ -93,6 +572,11 @@ var (
 		StatusCode: http.StatusInternalServerError,
 	}
 
+	ErrLedgerMismatch = &AppError{
+		Message:    "ledger balance does not match account balance",
+		StatusCode: http.StatusInternalServerError,
+	}
+
 	ErrHashPassword = &AppError{
 		Message:    "failed to hash password",
 		StatusCode: http.StatusInternalServerError,
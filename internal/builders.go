@@ -1,22 +1,263 @@
 package internal
 
 import (
+	"MockBankGo/auth"
 	"MockBankGo/internal/handlers"
+	"MockBankGo/internal/iban"
+	"MockBankGo/internal/jobs"
 	"MockBankGo/internal/repositories"
 	"MockBankGo/internal/services"
+	"context"
+	"log"
+	"os"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// InitSessionService builds the service that issues and revokes tokens; it
+// is also the revocation checker for middleware.JWTAuth.
+func InitSessionService(db *sqlx.DB) *services.SessionService {
+	userRepo := repositories.NewUserRepository(db)
+	tokenRepo := repositories.NewTokenRepository(db)
+	return services.NewSessionService(userRepo, tokenRepo, db)
+}
+
 func InitUserHandler(db *sqlx.DB) *handlers.UserHandler {
 	userRepo := repositories.NewUserRepository(db)
-	userService := services.NewUserService(userRepo)
-	return handlers.NewUserHandler(db, userService)
+	accountService := newAccountService(db)
+	userService := services.NewUserService(userRepo, accountService)
+	return handlers.NewUserHandler(db, userService, accountService, newOverdraftService(db), InitSessionService(db), newAuditService(db))
 }
 
-func InitTransactionHandler(db *sqlx.DB) *handlers.TransactionHandler {
+func newAuditService(db *sqlx.DB) *services.AuditService {
+	return services.NewAuditService(repositories.NewAuditRepository(db), db)
+}
+
+func newTransactionService(db *sqlx.DB) *services.TransactionService {
+	accountRepo := repositories.NewAccountRepository(db)
+	transactionRepo := repositories.NewTransactionsRepo(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	return services.NewTransactionService(transactionRepo, ledgerRepo, accountRepo, idempotencyRepo, newLimitService(db), newFXService(db), newFeeService(db), newOverdraftService(db), newAuditService(db), db)
+}
+
+func newNotificationService(db *sqlx.DB) *services.NotificationService {
+	return services.NewNotificationService(repositories.NewNotificationRepository(db), db)
+}
+
+func InitNotificationHandler(db *sqlx.DB) *handlers.NotificationHandler {
+	return handlers.NewNotificationHandler(db, newNotificationService(db))
+}
+
+func newOverdraftService(db *sqlx.DB) *services.OverdraftService {
+	return services.NewOverdraftService(repositories.NewOverdraftRepository(db), newNotificationService(db), db)
+}
+
+func InitOverdraftHandler(db *sqlx.DB) *handlers.OverdraftHandler {
+	return handlers.NewOverdraftHandler(db, newOverdraftService(db))
+}
+
+func newInterestService(db *sqlx.DB) *services.InterestService {
+	interestRepo := repositories.NewInterestRepository(db)
+	overdraftRepo := repositories.NewOverdraftRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	return services.NewInterestService(interestRepo, overdraftRepo, ledgerRepo, accountRepo, newTransactionService(db), db)
+}
+
+func InitInterestHandler(db *sqlx.DB) *handlers.InterestHandler {
+	return handlers.NewInterestHandler(db, newInterestService(db))
+}
+
+func newLoanService(db *sqlx.DB) *services.LoanService {
+	loanRepo := repositories.NewLoanRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	return services.NewLoanService(loanRepo, accountRepo, newTransactionService(db), newNotificationService(db), newAuditService(db), db)
+}
+
+func InitLoanHandler(db *sqlx.DB) *handlers.LoanHandler {
+	return handlers.NewLoanHandler(db, newLoanService(db), newAuditService(db))
+}
+
+func newTermDepositService(db *sqlx.DB) *services.TermDepositService {
+	termDepositRepo := repositories.NewTermDepositRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	return services.NewTermDepositService(termDepositRepo, accountRepo, newTransactionService(db), newAuditService(db), db)
+}
+
+func InitTermDepositHandler(db *sqlx.DB) *handlers.TermDepositHandler {
+	return handlers.NewTermDepositHandler(db, newTermDepositService(db), newAuditService(db))
+}
+
+func newFeeService(db *sqlx.DB) *services.FeeService {
+	return services.NewFeeService(repositories.NewFeeRepository(db), db)
+}
+
+func InitFeeHandler(db *sqlx.DB) *handlers.FeeHandler {
+	return handlers.NewFeeHandler(db, newFeeService(db))
+}
+
+func newFXService(db *sqlx.DB) *services.FXService {
+	return services.NewFXService(repositories.NewFXRepository(db), db)
+}
+
+func InitFXHandler(db *sqlx.DB) *handlers.FXHandler {
+	return handlers.NewFXHandler(db, newFXService(db))
+}
+
+func newLimitService(db *sqlx.DB) *services.LimitService {
+	return services.NewLimitService(repositories.NewLimitRepository(db), db)
+}
+
+func InitLimitHandler(db *sqlx.DB) *handlers.LimitHandler {
+	return handlers.NewLimitHandler(db, newLimitService(db))
+}
+
+func newStandingOrderService(db *sqlx.DB) *services.StandingOrderService {
+	orderRepo := repositories.NewStandingOrderRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	return services.NewStandingOrderService(orderRepo, accountRepo, newTransactionService(db), newAuditService(db), db)
+}
+
+// holdTTL reads HOLD_TTL, the default and longest lifetime of a hold.
+func holdTTL() time.Duration {
+	value := os.Getenv("HOLD_TTL")
+	if value == "" {
+		return services.DefaultHoldTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Printf("HOLD_TTL %q is not a positive duration; using %s", value, services.DefaultHoldTTL)
+		return services.DefaultHoldTTL
+	}
+	return ttl
+}
+
+func newHoldService(db *sqlx.DB) *services.HoldService {
+	holdRepo := repositories.NewHoldRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	return services.NewHoldService(holdRepo, accountRepo, newTransactionService(db), holdTTL(), newAuditService(db), db)
+}
+
+func InitHoldHandler(db *sqlx.DB) *handlers.HoldHandler {
+	return handlers.NewHoldHandler(db, newHoldService(db), newAuditService(db))
+}
+
+func newPayeeService(db *sqlx.DB) *services.PayeeService {
+	payeeRepo := repositories.NewPayeeRepository(db)
 	userRepo := repositories.NewUserRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	return services.NewPayeeService(payeeRepo, userRepo, accountRepo, db)
+}
+
+func InitTransactionHandler(db *sqlx.DB) *handlers.TransactionHandler {
+	return handlers.NewTransactionHandler(db, newTransactionService(db), newPayeeService(db), newAuditService(db))
+}
+
+func InitPayeeHandler(db *sqlx.DB) *handlers.PayeeHandler {
+	return handlers.NewPayeeHandler(db, newPayeeService(db))
+}
+
+// ibanIssuer reads IBAN_COUNTRY_CODE and IBAN_BANK_CODE, which accounts'
+// IBANs are issued under. An IBAN never changes once issued, so a bad
+// setting stops the server instead of falling back to the defaults.
+func ibanIssuer() iban.Issuer {
+	country := os.Getenv("IBAN_COUNTRY_CODE")
+	if country == "" {
+		country = iban.DefaultCountry
+	}
+	bankCode := os.Getenv("IBAN_BANK_CODE")
+	if bankCode == "" {
+		bankCode = iban.DefaultBankCode
+	}
+	issuer, err := iban.NewIssuer(country, bankCode)
+	if err != nil {
+		log.Fatalf("IBAN_COUNTRY_CODE %q and IBAN_BANK_CODE %q: %v", country, bankCode, err)
+	}
+	return issuer
+}
+
+func newAccountService(db *sqlx.DB) *services.AccountService {
+	return services.NewAccountService(repositories.NewAccountRepository(db), ibanIssuer(), db)
+}
+
+func InitAccountHandler(db *sqlx.DB) *handlers.AccountHandler {
+	return handlers.NewAccountHandler(db, newAccountService(db))
+}
+
+// AssignIBANs gives accounts opened before IBANs were issued their IBAN.
+func AssignIBANs(db *sqlx.DB) error {
+	return newAccountService(db).AssignIBANs()
+}
+
+func newStatementService(db *sqlx.DB) *services.StatementService {
+	return services.NewStatementService(repositories.NewStatementRepository(db), repositories.NewAccountRepository(db), db)
+}
+
+func InitStatementHandler(db *sqlx.DB) *handlers.StatementHandler {
+	return handlers.NewStatementHandler(db, newStatementService(db))
+}
+
+// bankName reads BANK_NAME, which heads printable statements.
+func bankName() string {
+	if name := os.Getenv("BANK_NAME"); name != "" {
+		return name
+	}
+	return "MockBank"
+}
+
+func newExportService(db *sqlx.DB) *services.ExportService {
 	transactionRepo := repositories.NewTransactionsRepo(db)
-	transactionService := services.NewTransactionService(transactionRepo, userRepo, db)
-	return handlers.NewTransactionHandler(db, transactionService)
+	statementRepo := repositories.NewStatementRepository(db)
+	accountRepo := repositories.NewAccountRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	return services.NewExportService(transactionRepo, statementRepo, accountRepo, userRepo, newFeeService(db), ibanIssuer().BankCode, bankName(), db)
+}
+
+func InitExportHandler(db *sqlx.DB) *handlers.ExportHandler {
+	return handlers.NewExportHandler(db, newExportService(db))
+}
+
+func InitStandingOrderHandler(db *sqlx.DB) *handlers.StandingOrderHandler {
+	return handlers.NewStandingOrderHandler(db, newStandingOrderService(db))
+}
+
+func InitAuditHandler(db *sqlx.DB) *handlers.AuditHandler {
+	return handlers.NewAuditHandler(db, newAuditService(db))
+}
+
+func InitKeyHandler(keySet *auth.KeySet) *handlers.KeyHandler {
+	return handlers.NewKeyHandler(keySet)
+}
+
+// InitScheduler registers every background job.
+func InitScheduler(db *sqlx.DB) *jobs.Scheduler {
+	scheduler := jobs.NewScheduler()
+	scheduler.Every("standing-orders", time.Minute, newStandingOrderService(db).ExecuteDue)
+	scheduler.Every("expired-tokens", time.Hour, InitSessionService(db).DeleteExpired)
+	scheduler.Every("expired-fx-quotes", time.Hour, newFXService(db).DeleteExpiredQuotes)
+	scheduler.Every("expired-holds", time.Minute, newHoldService(db).ExpireHolds)
+	scheduler.Every("overdraft-interest", time.Hour, newInterestService(db).ChargeOverdraftInterest)
+	scheduler.Every("interest-accrual", time.Hour, newInterestService(db).AccrueInterest)
+	scheduler.Every("loan-installments", time.Hour, newLoanService(db).CollectInstallments)
+	scheduler.Every("term-deposit-maturity", time.Hour, newTermDepositService(db).MatureTermDeposits)
+	scheduler.Every("monthly-statements", time.Hour, newStatementService(db).TakeMonthlyStatements)
+	scheduler.Every("statement-pdfs", time.Hour, newExportService(db).TakeMonthlyStatementPDFs)
+
+	// Money actions append their queued audit entries as they finish; this
+	// catches any they could not.
+	auditService := newAuditService(db)
+	scheduler.Every("audit-queue", time.Minute, func(ctx context.Context, now time.Time) error {
+		return auditService.AppendQueued(ctx)
+	})
+
+	// FX_RATES_FILE is re-read so edits to it are picked up without a restart.
+	if path := os.Getenv("FX_RATES_FILE"); path != "" {
+		fxService := newFXService(db)
+		scheduler.Every("fx-rates-file", 5*time.Minute, func(ctx context.Context, now time.Time) error {
+			return fxService.LoadRatesFile(path)
+		})
+	}
+	return scheduler
 }
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"MockBankGo/internal/money"
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+// csvWriter writes one row per entry with the balance after it, under a
+// header row.
+type csvWriter struct {
+	w       *csv.Writer
+	balance money.Money
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(statement Statement) error {
+	c.balance = statement.Opening
+	return c.w.Write([]string{"id", "posted_at", "type", "description", "amount", "currency", "balance"})
+}
+
+func (c *csvWriter) Write(entry Entry) error {
+	c.balance = c.balance.Add(entry.Amount)
+	return c.w.Write([]string{
+		entry.ID,
+		entry.PostedAt.UTC().Format(time.RFC3339),
+		string(entry.Type),
+		entry.Description,
+		entry.Amount.String(),
+		string(entry.Amount.Currency),
+		c.balance.String(),
+	})
+}
+
+func (c *csvWriter) End(closing money.Money) error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,146 @@
+// Package export writes an account's transactions in the file formats
+// accounting tools import: CSV, OFX 2.x, QIF and SWIFT MT940.
+package export
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Format is a file format transactions can be exported in.
+type Format string
+
+const (
+	CSV   Format = "csv"
+	OFX   Format = "ofx"
+	QIF   Format = "qif"
+	MT940 Format = "mt940"
+)
+
+// DefaultFormat is used when a request names no format it understands.
+const DefaultFormat = CSV
+
+// mediaTypes maps the media types an Accept header may name to formats.
+// The first one listed for a format is the one it is served as.
+var mediaTypes = []struct {
+	mediaType string
+	format    Format
+}{
+	{"text/csv", CSV},
+	{"application/x-ofx", OFX},
+	{"application/ofx", OFX},
+	{"application/qif", QIF},
+	{"application/x-qif", QIF},
+	{"application/x-mt940", MT940},
+	{"text/x-mt940", MT940},
+}
+
+var extensions = map[Format]string{
+	CSV:   ".csv",
+	OFX:   ".ofx",
+	QIF:   ".qif",
+	MT940: ".sta",
+}
+
+func (f Format) Valid() bool {
+	_, ok := extensions[f]
+	return ok
+}
+
+// ContentType is the media type a file in the format is served as.
+func (f Format) ContentType() string {
+	for _, m := range mediaTypes {
+		if m.format == f {
+			return m.mediaType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// Extension is the file name extension of the format.
+func (f Format) Extension() string {
+	return extensions[f]
+}
+
+// ParseFormat reads a format name such as "ofx", in any case.
+func ParseFormat(name string) (Format, bool) {
+	format := Format(strings.ToLower(name))
+	return format, format.Valid()
+}
+
+// FormatFromAccept returns the first format an Accept header names.
+// Quality values are ignored.
+func FormatFromAccept(accept string) (Format, bool) {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		for _, m := range mediaTypes {
+			if m.mediaType == mediaType {
+				return m.format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Statement describes the account and period an export covers. The period
+// runs from From up to but not including To; Opening is the balance at
+// From.
+type Statement struct {
+	BankID        string
+	AccountNumber string
+	IBAN          string
+	AccountType   models.AccountType
+	Currency      money.Currency
+	From          time.Time
+	To            time.Time
+	Opening       money.Money
+	GeneratedAt   time.Time
+}
+
+// Entry is one transaction as it affected the account. Amount is positive
+// for money in and negative for money out, fees included. ID identifies
+// the transaction and stays the same in every export.
+type Entry struct {
+	ID          string
+	Type        models.TransactionType
+	PostedAt    time.Time
+	Amount      money.Money
+	Description string
+}
+
+// Writer writes an export: Begin once, Write for every entry, oldest
+// first, and End with the balance after the last one.
+type Writer interface {
+	Begin(statement Statement) error
+	Write(entry Entry) error
+	End(closing money.Money) error
+}
+
+// NewWriter returns a Writer for format writing to w.
+func NewWriter(format Format, w io.Writer) Writer {
+	switch format {
+	case OFX:
+		return &ofxWriter{w: w}
+	case QIF:
+		return &qifWriter{w: w}
+	case MT940:
+		return &mt940Writer{w: w}
+	}
+	return newCSVWriter(w)
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
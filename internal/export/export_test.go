@@ -0,0 +1,169 @@
+package export
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files from the current output:
+// go test ./internal/export -update
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func testStatement() (Statement, []Entry) {
+	statement := Statement{
+		BankID:        "12345678",
+		AccountNumber: "001000000001",
+		IBAN:          "DE69123456781000000001",
+		AccountType:   models.Checking,
+		Currency:      money.USD,
+		From:          time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		To:            time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+		Opening:       money.New(100000, money.USD),
+		GeneratedAt:   time.Date(2025, time.July, 2, 9, 30, 0, 0, time.UTC),
+	}
+	entries := []Entry{
+		{ID: "41", Type: models.Deposit, PostedAt: time.Date(2025, time.June, 3, 10, 15, 0, 0, time.UTC),
+			Amount: money.New(25000, money.USD), Description: "Deposit"},
+		{ID: "57", Type: models.Transfer, PostedAt: time.Date(2025, time.June, 14, 18, 2, 33, 0, time.UTC),
+			Amount: money.New(-12150, money.USD), Description: "Transfer to account 001000000007 & fees"},
+		{ID: "63", Type: models.LoanRepayment, PostedAt: time.Date(2025, time.June, 28, 0, 0, 5, 0, time.UTC),
+			Amount: money.New(-30000, money.USD), Description: "Loan repayment, including a late payment fee for the overdue installment"},
+		{ID: "70", Type: models.Interest, PostedAt: time.Date(2025, time.June, 30, 23, 59, 59, 0, time.UTC),
+			Amount: money.New(312, money.USD), Description: "Interest"},
+	}
+	return statement, entries
+}
+
+func render(t *testing.T, format Format) []byte {
+	statement, entries := testStatement()
+
+	var buf bytes.Buffer
+	w := NewWriter(format, &buf)
+	require.NoError(t, w.Begin(statement))
+	closing := statement.Opening
+	for _, entry := range entries {
+		require.NoError(t, w.Write(entry))
+		closing = closing.Add(entry.Amount)
+	}
+	require.NoError(t, w.End(closing))
+	return buf.Bytes()
+}
+
+func TestWriters_MatchGoldenFiles(t *testing.T) {
+	for _, format := range []Format{CSV, OFX, QIF, MT940} {
+		t.Run(string(format), func(t *testing.T) {
+			got := render(t, format)
+			golden := filepath.Join("testdata", "statement"+format.Extension()+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, got, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestMT940Amount(t *testing.T) {
+	mark, value := mt940Amount(money.New(-12150, money.USD))
+	assert.Equal(t, "D", mark)
+	assert.Equal(t, "121,50", value)
+
+	mark, value = mt940Amount(money.New(5, money.EUR))
+	assert.Equal(t, "C", mark)
+	assert.Equal(t, "0,05", value)
+
+	mark, value = mt940Amount(money.New(1500, money.JPY))
+	assert.Equal(t, "C", mark)
+	assert.Equal(t, "1500,", value)
+}
+
+func TestFormatFromAccept(t *testing.T) {
+	for accept, want := range map[string]Format{
+		"text/csv":                          CSV,
+		"application/x-ofx":                 OFX,
+		"application/json, application/qif": QIF,
+		"text/x-mt940;q=0.9, */*;q=0.1":     MT940,
+	} {
+		format, ok := FormatFromAccept(accept)
+		assert.True(t, ok, accept)
+		assert.Equal(t, want, format, accept)
+	}
+
+	for _, accept := range []string{"", "*/*", "application/json"} {
+		_, ok := FormatFromAccept(accept)
+		assert.False(t, ok, accept)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	format, ok := ParseFormat("MT940")
+	assert.True(t, ok)
+	assert.Equal(t, MT940, format)
+
+	_, ok = ParseFormat("xlsx")
+	assert.False(t, ok)
+}
+
+func renderPDF(t *testing.T, statement Statement, entries []Entry) []byte {
+	w := NewPDFWriter("MockBank", "Ada Lovelace", "Statement June 2025")
+	require.NoError(t, w.Begin(statement))
+	closing := statement.Opening
+	for _, entry := range entries {
+		require.NoError(t, w.Write(entry))
+		closing = closing.Add(entry.Amount)
+	}
+	require.NoError(t, w.End(closing))
+
+	var buf bytes.Buffer
+	_, err := w.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestPDFWriter_MatchesGoldenFile(t *testing.T) {
+	statement, entries := testStatement()
+	statement.AccountNumber = "********0001"
+	got := renderPDF(t, statement, entries)
+
+	golden := filepath.Join("testdata", "statement.pdf.golden")
+	if *update {
+		require.NoError(t, os.WriteFile(golden, got, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestPDFWriter_BreaksLongTablesAcrossPages(t *testing.T) {
+	statement, entries := testStatement()
+	for len(entries) < 120 {
+		entries = append(entries, entries[0])
+	}
+	out := string(renderPDF(t, statement, entries))
+
+	assert.Contains(t, out, "/Count 3")
+	assert.Contains(t, out, "(Account statement \\(continued\\))")
+	assert.Contains(t, out, "(Page 3 of 3)")
+	assert.Contains(t, out, "(Closing balance)")
+}
+
+func TestPDFWriter_WithoutAccounts(t *testing.T) {
+	w := NewPDFWriter("MockBank", "Ada Lovelace", "Statement June 2025")
+	var buf bytes.Buffer
+	_, err := w.WriteTo(&buf)
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), "/Count 1")
+	assert.Contains(t, buf.String(), "(Ada Lovelace had no accounts open in this period.)")
+	assert.Contains(t, buf.String(), "(Page 1 of 1)")
+}
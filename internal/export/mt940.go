@@ -0,0 +1,102 @@
+package export
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// mt940ReferenceLength and mt940NarrativeLength are the longest a
+	// reference and a line of :86: narrative may be.
+	mt940ReferenceLength = 16
+	mt940NarrativeLength = 65
+)
+
+// mt940Codes maps transaction types to SWIFT transaction type codes. Any
+// other type is MSC, miscellaneous.
+var mt940Codes = map[models.TransactionType]string{
+	models.Transfer:         "TRF",
+	models.Refund:           "TRF",
+	models.Reversal:         "TRF",
+	models.Interest:         "INT",
+	models.LoanDisbursement: "LDP",
+	models.LoanRepayment:    "LDP",
+}
+
+// mt940Amount writes an amount the SWIFT way: a C or D mark for its sign
+// and a decimal comma, which is kept even when there are no decimals.
+func mt940Amount(amount money.Money) (string, string) {
+	mark := "C"
+	if amount.IsNegative() {
+		mark = "D"
+		amount = amount.Neg()
+	}
+	value := strings.Replace(amount.String(), ".", ",", 1)
+	if !strings.Contains(value, ",") {
+		value += ","
+	}
+	return mark, value
+}
+
+// mt940Writer writes a SWIFT MT940 customer statement message: the
+// opening balance, a :61: statement line and :86: narrative per entry and
+// the closing balance. Lines end in CRLF.
+type mt940Writer struct {
+	w         io.Writer
+	err       error
+	statement Statement
+}
+
+func (m *mt940Writer) line(format string, args ...interface{}) {
+	if m.err != nil {
+		return
+	}
+	_, m.err = fmt.Fprintf(m.w, format+"\r\n", args...)
+}
+
+// balance writes a balance field: the mark, the date, the currency and
+// the amount.
+func (m *mt940Writer) balance(tag string, on time.Time, amount money.Money) {
+	mark, value := mt940Amount(amount)
+	m.line(":%s:%s%s%s%s", tag, mark, on.UTC().Format("060102"), m.statement.Currency, value)
+}
+
+func (m *mt940Writer) Begin(statement Statement) error {
+	m.statement = statement
+	account := statement.IBAN
+	if account == "" {
+		account = statement.BankID + "/" + statement.AccountNumber
+	}
+
+	m.line(":20:%s", truncate("STMT"+statement.From.UTC().Format("060102"), mt940ReferenceLength))
+	m.line(":25:%s", account)
+	m.line(":28C:1/1")
+	m.balance("60F", statement.From, statement.Opening)
+	return m.err
+}
+
+func (m *mt940Writer) Write(entry Entry) error {
+	code, ok := mt940Codes[entry.Type]
+	if !ok {
+		code = "MSC"
+	}
+	mark, value := mt940Amount(entry.Amount)
+	posted := entry.PostedAt.UTC()
+
+	m.line(":61:%s%s%s%sN%s%s", posted.Format("060102"), posted.Format("0102"), mark, value, code,
+		truncate(entry.ID, mt940ReferenceLength))
+	m.line(":86:%s", truncate(entry.Description, mt940NarrativeLength))
+	return m.err
+}
+
+// End writes the closing balance as of the last day the statement covers,
+// and the hyphen that ends the message.
+func (m *mt940Writer) End(closing money.Money) error {
+	m.balance("62F", m.statement.To.Add(-time.Nanosecond), closing)
+	m.line("-")
+	return m.err
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ofxDate is how OFX writes a time, always in UTC here.
+const ofxDate = "20060102150405"
+
+// ofxNameLength is the most an OFX NAME may hold; the whole description
+// goes in MEMO.
+const ofxNameLength = 32
+
+// ofxTypes maps transaction types to OFX transaction types. Any other
+// type is a CREDIT or a DEBIT by its sign.
+var ofxTypes = map[models.TransactionType]string{
+	models.Deposit:  "DEP",
+	models.Withdraw: "CASH",
+	models.Transfer: "XFER",
+	models.Interest: "INT",
+}
+
+// ofxWriter writes an OFX 2.2 bank statement response. OFX 2 is XML, so
+// unlike OFX 1 every element is closed.
+type ofxWriter struct {
+	w         io.Writer
+	err       error
+	statement Statement
+}
+
+func (o *ofxWriter) line(depth int, format string, args ...interface{}) {
+	if o.err != nil {
+		return
+	}
+	_, o.err = fmt.Fprintf(o.w, strings.Repeat("  ", depth)+format+"\n", args...)
+}
+
+// element writes <name>value</name> with value escaped.
+func (o *ofxWriter) element(depth int, name string, value string) {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	o.line(depth, "<%s>%s</%s>", name, escaped.String(), name)
+}
+
+func (o *ofxWriter) status(depth int) {
+	o.line(depth, "<STATUS>")
+	o.element(depth+1, "CODE", "0")
+	o.element(depth+1, "SEVERITY", "INFO")
+	o.line(depth, "</STATUS>")
+}
+
+func (o *ofxWriter) Begin(statement Statement) error {
+	o.statement = statement
+	o.line(0, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	o.line(0, `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`)
+	o.line(0, "<OFX>")
+	o.line(1, "<SIGNONMSGSRSV1>")
+	o.line(2, "<SONRS>")
+	o.status(3)
+	o.element(3, "DTSERVER", statement.GeneratedAt.UTC().Format(ofxDate))
+	o.element(3, "LANGUAGE", "ENG")
+	o.line(2, "</SONRS>")
+	o.line(1, "</SIGNONMSGSRSV1>")
+	o.line(1, "<BANKMSGSRSV1>")
+	o.line(2, "<STMTTRNRS>")
+	o.element(3, "TRNUID", "0")
+	o.status(3)
+	o.line(3, "<STMTRS>")
+	o.element(4, "CURDEF", string(statement.Currency))
+	o.line(4, "<BANKACCTFROM>")
+	o.element(5, "BANKID", statement.BankID)
+	o.element(5, "ACCTID", statement.AccountNumber)
+	o.element(5, "ACCTTYPE", strings.ToUpper(string(statement.AccountType)))
+	o.line(4, "</BANKACCTFROM>")
+	o.line(4, "<BANKTRANLIST>")
+	o.element(5, "DTSTART", statement.From.UTC().Format(ofxDate))
+	o.element(5, "DTEND", statement.To.UTC().Format(ofxDate))
+	return o.err
+}
+
+func (o *ofxWriter) Write(entry Entry) error {
+	trnType, ok := ofxTypes[entry.Type]
+	if !ok {
+		trnType = "CREDIT"
+		if entry.Amount.IsNegative() {
+			trnType = "DEBIT"
+		}
+	}
+
+	o.line(5, "<STMTTRN>")
+	o.element(6, "TRNTYPE", trnType)
+	o.element(6, "DTPOSTED", entry.PostedAt.UTC().Format(ofxDate))
+	o.element(6, "TRNAMT", entry.Amount.String())
+	o.element(6, "FITID", entry.ID)
+	o.element(6, "NAME", truncate(entry.Description, ofxNameLength))
+	o.element(6, "MEMO", entry.Description)
+	o.line(5, "</STMTTRN>")
+	return o.err
+}
+
+func (o *ofxWriter) End(closing money.Money) error {
+	o.line(4, "</BANKTRANLIST>")
+	o.line(4, "<LEDGERBAL>")
+	o.element(5, "BALAMT", closing.String())
+	o.element(5, "DTASOF", o.statement.To.UTC().Format(ofxDate))
+	o.line(4, "</LEDGERBAL>")
+	o.line(3, "</STMTRS>")
+	o.line(2, "</STMTTRNRS>")
+	o.line(1, "</BANKMSGSRSV1>")
+	o.line(0, "</OFX>")
+	return o.err
+}
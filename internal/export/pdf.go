@@ -0,0 +1,183 @@
+package export
+
+import (
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/pdf"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Layout of a printable statement page, in points from the bottom-left
+// corner of an A4 page.
+const (
+	pdfLeft        = 50
+	pdfRight       = 545
+	pdfTop         = 790
+	pdfBottom      = 80
+	pdfFooter      = 40
+	pdfRowHeight   = 14
+	pdfDescription = 120
+	pdfAmount      = 440
+	pdfTextSize    = 9
+)
+
+// pdfDate is how dates are printed.
+const pdfDate = "2006-01-02"
+
+// PDFWriter lays out a printable statement, one section per account, each
+// starting on a new page: the bank's header, the customer and account,
+// a table of entries with the balance after each, and the totals. Every
+// page is numbered once the document is complete, in WriteTo. The account
+// number should already be masked.
+type PDFWriter struct {
+	doc       *pdf.Document
+	bankName  string
+	customer  string
+	statement Statement
+	page      *pdf.Page
+	y         float64
+	balance   money.Money
+	totalIn   money.Money
+	totalOut  money.Money
+}
+
+func NewPDFWriter(bankName string, customer string, title string) *PDFWriter {
+	return &PDFWriter{doc: pdf.New(title), bankName: bankName, customer: customer}
+}
+
+// lastDay is the last day a statement covers, since To is exclusive.
+func lastDay(statement Statement) time.Time {
+	return statement.To.Add(-time.Nanosecond).UTC()
+}
+
+// fit shortens s with an ellipsis until it is at most width points wide.
+func fit(font pdf.Font, size float64, s string, width float64) string {
+	if pdf.Width(font, size, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.Width(font, size, string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// newPage starts a page with the bank's header and the table's column
+// headings.
+func (p *PDFWriter) newPage(continued bool) {
+	p.page = p.doc.AddPage()
+
+	title := "Account statement"
+	if continued {
+		title += " (continued)"
+	}
+	p.page.Text(pdfLeft, pdfTop, pdf.HelveticaBold, 18, p.bankName)
+	p.page.TextRight(pdfRight, pdfTop, pdf.HelveticaBold, 12, title)
+	p.page.Line(pdfLeft, pdfTop-10, pdfRight, pdfTop-10, 1)
+	p.y = pdfTop - 30
+
+	if !continued {
+		rows := [][2]string{
+			{"Customer", p.customer},
+			{"Account", p.statement.AccountNumber},
+			{"Currency", string(p.statement.Currency)},
+			{"Period", fmt.Sprintf("%s to %s", p.statement.From.UTC().Format(pdfDate), lastDay(p.statement).Format(pdfDate))},
+			{"Generated", p.statement.GeneratedAt.UTC().Format(pdfDate)},
+		}
+		for _, row := range rows {
+			p.page.Text(pdfLeft, p.y, pdf.HelveticaBold, pdfTextSize, row[0])
+			p.page.Text(pdfDescription, p.y, pdf.Helvetica, pdfTextSize, row[1])
+			p.y -= pdfRowHeight
+		}
+		p.y -= pdfRowHeight
+	}
+
+	p.page.Text(pdfLeft, p.y, pdf.HelveticaBold, pdfTextSize, "Date")
+	p.page.Text(pdfDescription, p.y, pdf.HelveticaBold, pdfTextSize, "Description")
+	p.page.TextRight(pdfAmount, p.y, pdf.HelveticaBold, pdfTextSize, "Amount")
+	p.page.TextRight(pdfRight, p.y, pdf.HelveticaBold, pdfTextSize, "Balance")
+	p.page.Line(pdfLeft, p.y-4, pdfRight, p.y-4, 0.5)
+	p.y -= pdfRowHeight + 2
+}
+
+// row writes one line of the table, starting a new page first when this
+// one is full.
+func (p *PDFWriter) row(date time.Time, description string, amount string, balance money.Money) {
+	if p.y < pdfBottom {
+		p.newPage(true)
+	}
+	width := pdfAmount - pdfDescription - pdf.Width(pdf.HelveticaBold, pdfTextSize, "-000,000,000.00")
+	p.page.Text(pdfLeft, p.y, pdf.Helvetica, pdfTextSize, date.UTC().Format(pdfDate))
+	p.page.Text(pdfDescription, p.y, pdf.Helvetica, pdfTextSize, fit(pdf.Helvetica, pdfTextSize, description, width))
+	if amount != "" {
+		p.page.TextRight(pdfAmount, p.y, pdf.Helvetica, pdfTextSize, amount)
+	}
+	p.page.TextRight(pdfRight, p.y, pdf.Helvetica, pdfTextSize, balance.String())
+	p.y -= pdfRowHeight
+}
+
+func (p *PDFWriter) Begin(statement Statement) error {
+	p.statement = statement
+	p.balance = statement.Opening
+	p.totalIn = money.New(0, statement.Currency)
+	p.totalOut = money.New(0, statement.Currency)
+
+	p.newPage(false)
+	p.row(statement.From, "Opening balance", "", p.balance)
+	return nil
+}
+
+func (p *PDFWriter) Write(entry Entry) error {
+	if entry.Amount.IsNegative() {
+		p.totalOut = p.totalOut.Sub(entry.Amount)
+	} else {
+		p.totalIn = p.totalIn.Add(entry.Amount)
+	}
+	p.balance = p.balance.Add(entry.Amount)
+	p.row(entry.PostedAt, entry.Description, entry.Amount.String(), p.balance)
+	return nil
+}
+
+// End writes the totals under the table, on a new page if they do not fit
+// on this one.
+func (p *PDFWriter) End(closing money.Money) error {
+	if p.y-3*pdfRowHeight < pdfBottom {
+		p.newPage(true)
+	}
+	p.page.Line(pdfLeft, p.y+pdfRowHeight-4, pdfRight, p.y+pdfRowHeight-4, 0.5)
+	p.y -= 4
+
+	totals := []struct {
+		label  string
+		amount money.Money
+		font   pdf.Font
+	}{
+		{"Total money in", p.totalIn, pdf.Helvetica},
+		{"Total money out", p.totalOut, pdf.Helvetica},
+		{"Closing balance", closing, pdf.HelveticaBold},
+	}
+	for _, total := range totals {
+		p.page.Text(pdfDescription, p.y, total.font, pdfTextSize, total.label)
+		p.page.TextRight(pdfRight, p.y, total.font, pdfTextSize, total.amount.String())
+		p.y -= pdfRowHeight
+	}
+	return nil
+}
+
+// WriteTo numbers the pages and writes the PDF. A customer with no
+// accounts in the period gets a page saying so.
+func (p *PDFWriter) WriteTo(w io.Writer) (int64, error) {
+	if len(p.doc.Pages()) == 0 {
+		p.page = p.doc.AddPage()
+		p.page.Text(pdfLeft, pdfTop, pdf.HelveticaBold, 18, p.bankName)
+		p.page.Text(pdfLeft, pdfTop-30, pdf.Helvetica, pdfTextSize, fmt.Sprintf("%s had no accounts open in this period.", p.customer))
+	}
+
+	pages := p.doc.Pages()
+	for i, page := range pages {
+		page.Text(pdfLeft, pdfFooter, pdf.Helvetica, 8, p.customer)
+		page.TextRight(pdfRight, pdfFooter, pdf.Helvetica, 8, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+	return p.doc.WriteTo(w)
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"MockBankGo/internal/money"
+	"fmt"
+	"io"
+)
+
+// qifDate is the US-style date most QIF importers expect.
+const qifDate = "01/02/2006"
+
+// qifWriter writes a QIF bank account: a D (date), T (amount), N (number)
+// and P (payee) line per entry, each entry ended by ^. QIF has no currency
+// or balances.
+type qifWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (q *qifWriter) printf(format string, args ...interface{}) {
+	if q.err != nil {
+		return
+	}
+	_, q.err = fmt.Fprintf(q.w, format, args...)
+}
+
+func (q *qifWriter) Begin(statement Statement) error {
+	q.printf("!Type:Bank\n")
+	return q.err
+}
+
+func (q *qifWriter) Write(entry Entry) error {
+	q.printf("D%s\nT%s\nN%s\nP%s\n^\n",
+		entry.PostedAt.UTC().Format(qifDate), entry.Amount.String(), entry.ID, entry.Description)
+	return q.err
+}
+
+func (q *qifWriter) End(closing money.Money) error {
+	return q.err
+}
@@ -0,0 +1,123 @@
+// Package fx converts money between currencies at exact decimal rates.
+package fx
+
+import (
+	"MockBankGo/internal/money"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Precision is the number of decimal places a rate is kept to. It matches
+// the NUMERIC(24, 12) columns rates are stored in, so a stored rate
+// reproduces the conversion it was used for.
+const Precision = 12
+
+// MaxSpreadBps is the exclusive upper bound on a spread in basis points.
+const MaxSpreadBps = 10000
+
+var (
+	ErrInvalidRate   = errors.New("rate must be a positive decimal")
+	ErrInvalidSpread = errors.New("spread must be between 0 and 9999 basis points")
+	ErrWrongCurrency = errors.New("amount is not in the rate's base currency")
+)
+
+// Rate is the price of one major unit of Base expressed in Quote.
+type Rate struct {
+	Base  money.Currency
+	Quote money.Currency
+	value *big.Rat
+}
+
+// ParseRate reads a plain decimal such as "1.0845". Fractions, exponents and
+// values that round to zero at Precision are rejected.
+func ParseRate(base money.Currency, quote money.Currency, s string) (Rate, error) {
+	whole, frac, hasDot := strings.Cut(strings.TrimSpace(s), ".")
+	if whole == "" || (hasDot && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Rate{}, ErrInvalidRate
+	}
+
+	value, ok := new(big.Rat).SetString(whole + "." + frac + "0")
+	if !ok {
+		return Rate{}, ErrInvalidRate
+	}
+	value = round(value)
+	if value.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{Base: base, Quote: quote, value: value}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// round rounds to Precision decimal places, half away from zero.
+func round(r *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(r.FloatString(Precision))
+	return rounded
+}
+
+// String formats the rate with trailing zeros removed, e.g. "0.9215".
+func (r Rate) String() string {
+	if r.value == nil {
+		return "0"
+	}
+	s := strings.TrimRight(r.value.FloatString(Precision), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Invert returns the rate for the opposite direction, rounded to Precision.
+func (r Rate) Invert() Rate {
+	return Rate{Base: r.Quote, Quote: r.Base, value: round(new(big.Rat).Inv(r.value))}
+}
+
+// WithSpread returns the rate a customer gets when the bank keeps spreadBps
+// basis points of every conversion: mid * (1 - spreadBps/10000).
+func (r Rate) WithSpread(spreadBps int) (Rate, error) {
+	if spreadBps < 0 || spreadBps >= MaxSpreadBps {
+		return Rate{}, ErrInvalidSpread
+	}
+	factor := big.NewRat(int64(MaxSpreadBps-spreadBps), MaxSpreadBps)
+	value := round(new(big.Rat).Mul(r.value, factor))
+	if value.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{Base: r.Base, Quote: r.Quote, value: value}, nil
+}
+
+// Convert turns an amount of Base into Quote, rounding down to the quote
+// currency's minor unit so the bank never pays out more than the rate allows.
+func (r Rate) Convert(amount money.Money) (money.Money, error) {
+	if amount.Currency != r.Base {
+		return money.Money{}, ErrWrongCurrency
+	}
+
+	num := new(big.Int).Mul(big.NewInt(amount.Amount), r.value.Num())
+	num.Mul(num, pow10(r.Quote.Exponent()))
+	den := new(big.Int).Mul(r.value.Denom(), pow10(r.Base.Exponent()))
+
+	// Div is Euclidean; with a positive divisor that is floor division.
+	minor := num.Div(num, den)
+	if !minor.IsInt64() {
+		return money.Money{}, money.ErrOverflow
+	}
+	return money.New(minor.Int64(), r.Quote), nil
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// Share returns part/whole of amount, rounded down. It splits a converted
+// amount proportionally, e.g. when only part of an exchange is refunded.
+func Share(amount money.Money, part int64, whole int64) money.Money {
+	share := new(big.Int).Mul(big.NewInt(amount.Amount), big.NewInt(part))
+	share.Div(share, big.NewInt(whole))
+	return money.New(share.Int64(), amount.Currency)
+}
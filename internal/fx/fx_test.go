@@ -0,0 +1,86 @@
+package fx
+
+import (
+	"MockBankGo/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate(money.USD, money.EUR, "0.921500")
+	require.NoError(t, err)
+	assert.Equal(t, "0.9215", rate.String())
+
+	rate, err = ParseRate(money.USD, money.JPY, "157")
+	require.NoError(t, err)
+	assert.Equal(t, "157", rate.String())
+
+	for _, bad := range []string{"", "0", "-1.2", "1e3", "1/3", ".5", "1.", "abc", "0.0000000000001"} {
+		_, err := ParseRate(money.USD, money.EUR, bad)
+		assert.ErrorIs(t, err, ErrInvalidRate, bad)
+	}
+}
+
+func TestConvert_RoundsDown(t *testing.T) {
+	rate, err := ParseRate(money.USD, money.EUR, "0.9215")
+	require.NoError(t, err)
+
+	converted, err := rate.Convert(money.New(1099, money.USD))
+	require.NoError(t, err)
+	// 10.99 * 0.9215 = 10.127285
+	assert.Equal(t, money.New(1012, money.EUR), converted)
+
+	_, err = rate.Convert(money.New(100, money.EUR))
+	assert.ErrorIs(t, err, ErrWrongCurrency)
+}
+
+func TestConvert_DifferentExponents(t *testing.T) {
+	rate, err := ParseRate(money.USD, money.JPY, "157.25")
+	require.NoError(t, err)
+
+	converted, err := rate.Convert(money.New(1000, money.USD))
+	require.NoError(t, err)
+	assert.Equal(t, money.New(1572, money.JPY), converted)
+
+	back := rate.Invert()
+	assert.Equal(t, money.JPY, back.Base)
+	assert.Equal(t, money.USD, back.Quote)
+	assert.Equal(t, "0.006359300477", back.String())
+
+	converted, err = back.Convert(money.New(1572, money.JPY))
+	require.NoError(t, err)
+	assert.Equal(t, money.New(999, money.USD), converted)
+}
+
+func TestWithSpread(t *testing.T) {
+	mid, err := ParseRate(money.EUR, money.GBP, "0.85")
+	require.NoError(t, err)
+
+	customer, err := mid.WithSpread(50)
+	require.NoError(t, err)
+	assert.Equal(t, "0.84575", customer.String())
+
+	same, err := mid.WithSpread(0)
+	require.NoError(t, err)
+	assert.Equal(t, mid.String(), same.String())
+
+	_, err = mid.WithSpread(MaxSpreadBps)
+	assert.ErrorIs(t, err, ErrInvalidSpread)
+	_, err = mid.WithSpread(-1)
+	assert.ErrorIs(t, err, ErrInvalidSpread)
+}
+
+func TestConvert_Overflow(t *testing.T) {
+	rate, err := ParseRate(money.USD, money.KZT, "500")
+	require.NoError(t, err)
+
+	_, err = rate.Convert(money.New(1<<62, money.USD))
+	assert.ErrorIs(t, err, money.ErrOverflow)
+}
+
+func TestShare(t *testing.T) {
+	assert.Equal(t, money.New(333, money.USD), Share(money.New(1000, money.USD), 1, 3))
+	assert.Equal(t, money.New(1000, money.USD), Share(money.New(1000, money.USD), 7, 7))
+}
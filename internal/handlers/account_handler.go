@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountHandler struct {
+	database       *sqlx.DB
+	accountService *services.AccountService
+}
+
+type OpenAccountInput struct {
+	Type     models.AccountType `json:"type"`
+	Currency money.Currency     `json:"currency"`
+}
+
+func NewAccountHandler(db *sqlx.DB, account_service *services.AccountService) *AccountHandler {
+	return &AccountHandler{database: db, accountService: account_service}
+}
+
+func (h *AccountHandler) OpenAccount(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input OpenAccountInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	account, err := h.accountService.OpenAccount(userID, input.Type, input.Currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
+}
+
+func (h *AccountHandler) ListAccounts(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+
+	accounts, err := h.accountService.ListAccounts(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(accounts)
+}
+
+func (h *AccountHandler) CloseAccount(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	accountID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrAccountNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	if err := h.accountService.CloseAccount(userID, accountID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte("Account closed"))
+}
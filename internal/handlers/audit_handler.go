@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AuditHandler struct {
+	database     *sqlx.DB
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(db *sqlx.DB, audit_service *services.AuditService) *AuditHandler {
+	return &AuditHandler{database: db, auditService: audit_service}
+}
+
+// newAuditEntry starts an audit entry for req with the caller, client
+// address, user agent and request ID filled in.
+func newAuditEntry(req *http.Request, action string) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		Action:    action,
+		IP:        middleware.ClientIP(req),
+		UserAgent: req.UserAgent(),
+		RequestID: middleware.GetRequestID(req.Context()),
+	}
+	if userID, ok := middleware.GetUserID(req.Context()); ok {
+		entry.ActorID = &userID
+	}
+	return entry
+}
+
+func auditDetails(details map[string]interface{}) json.RawMessage {
+	raw, _ := json.Marshal(details)
+	return raw
+}
+
+// recordAudit stores entry with the outcome of err. Replayed idempotent
+// requests are not new actions and are skipped. A failure to write the
+// audit log is logged but never changes the response.
+func recordAudit(audit *services.AuditService, entry *models.AuditEntry, err error) {
+	if _, ok := err.(*services.IdempotentReplay); ok {
+		return
+	}
+
+	entry.Outcome = models.AuditSuccess
+	if err != nil {
+		entry.Outcome = models.AuditFailure
+		entry.Error = "internal error"
+		if appErr, ok := err.(*apperrors.AppError); ok {
+			entry.Error = appErr.Message
+		} else if limitErr, ok := err.(*services.LimitExceeded); ok {
+			entry.Error = limitErr.Message
+		}
+	}
+
+	if auditErr := audit.Record(entry); auditErr != nil {
+		log.Printf("audit: failed to record %s for request %s: %v", entry.Action, entry.RequestID, auditErr)
+	}
+}
+
+// recordQueuedAudit finishes the audit of a money action whose entry was
+// handed to the service with services.WithAudit. An action that succeeded
+// queued its entry in the same transaction as the ledger posting; it is moved
+// onto the chain now, or by the audit-queue job if that fails. An action that
+// failed moved no money and is recorded here.
+func recordQueuedAudit(ctx context.Context, audit *services.AuditService, entry *models.AuditEntry, err error) {
+	if err != nil {
+		recordAudit(audit, entry, err)
+		return
+	}
+	if appendErr := audit.AppendQueued(ctx); appendErr != nil {
+		log.Printf("audit: failed to append queued entries after request %s: %v", entry.RequestID, appendErr)
+	}
+}
+
+func (h *AuditHandler) Search(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter, err := parseAuditFilter(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	page, err := h.auditService.Search(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *AuditHandler) Verify(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := h.auditService.VerifyChain()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseAuditFilter reads the GET /admin/audit query parameters: actor_id,
+// action, target_type, target_id, request_id, from, to, limit and cursor.
+func parseAuditFilter(req *http.Request) (models.AuditFilter, error) {
+	query := req.URL.Query()
+	filter := models.AuditFilter{
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		RequestID:  query.Get("request_id"),
+		Cursor:     query.Get("cursor"),
+	}
+
+	for name, target := range map[string]**int64{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return filter, invalidParam(name)
+			}
+			*target = &id
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			t, err := parseTimeParam(value, name == "to")
+			if err != nil {
+				return filter, invalidParam(name)
+			}
+			*target = &t
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return filter, invalidParam("limit")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
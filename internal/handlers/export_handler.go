@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"MockBankGo/internal/export"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type ExportHandler struct {
+	database      *sqlx.DB
+	exportService *services.ExportService
+}
+
+func NewExportHandler(db *sqlx.DB, export_service *services.ExportService) *ExportHandler {
+	return &ExportHandler{database: db, exportService: export_service}
+}
+
+// exportFormat picks the export format from the format parameter or, if
+// there is none, the Accept header. Anything else gets the default.
+func exportFormat(req *http.Request) (export.Format, error) {
+	if value := req.URL.Query().Get("format"); value != "" {
+		format, ok := export.ParseFormat(value)
+		if !ok {
+			return "", invalidParam("format")
+		}
+		return format, nil
+	}
+	if format, ok := export.FormatFromAccept(req.Header.Get("Accept")); ok {
+		return format, nil
+	}
+	return export.DefaultFormat, nil
+}
+
+// Export handles GET /transactions/export?account_id=&from=&to=&format=:
+// everything posted to the account over the period as a file for accounting
+// tools, in csv, ofx, qif or mt940. The period is read like a statement's.
+// The file is streamed, so an error once it has started can only be
+// logged.
+func (h *ExportHandler) Export(w http.ResponseWriter, req *http.Request) {
+	accountID, err := strconv.ParseInt(req.URL.Query().Get("account_id"), 10, 64)
+	if err != nil {
+		writeError(w, invalidParam("account_id"))
+		return
+	}
+
+	format, err := exportFormat(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	now := time.Now()
+	from, to, err := statementPeriod(req, now)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	account, statement, err := h.exportService.PrepareExport(req.Context(), userID, accountID, from, to, now)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("transactions-%s-%s%s", account.Number, statement.From.UTC().Format("20060102"), format.Extension())
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Vary", "Accept")
+
+	buffered := bufio.NewWriter(w)
+	err = h.exportService.WriteExport(req.Context(), account, statement, export.NewWriter(format, buffered))
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		log.Printf("transaction export: account %d: %v", account.ID, err)
+	}
+}
+
+// StatementPDF handles GET /statements/{period}.pdf: the user's printable
+// statement of all their accounts for a month, written YYYY-MM.
+func (h *ExportHandler) StatementPDF(w http.ResponseWriter, req *http.Request) {
+	period := mux.Vars(req)["period"]
+	userID, _ := middleware.GetUserID(req.Context())
+
+	pdf, err := h.exportService.StatementPDF(req.Context(), userID, period, time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", "statement-"+period+".pdf"))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	if _, err := w.Write(pdf); err != nil {
+		log.Printf("statement PDF: user %d: %v", userID, err)
+	}
+}
+
+// GenerateStatementPDFs handles POST /admin/statement-pdfs/{period}: renders
+// and stores the statement PDFs for a month that is over, for the users the
+// monthly job has not done yet, and reports how many were generated and how
+// many failed.
+func (h *ExportHandler) GenerateStatementPDFs(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	generated, failed, err := h.exportService.GenerateStatementPDFsForMonth(req.Context(), mux.Vars(req)["period"], time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]int{"generated": generated, "failed": failed})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type FeeHandler struct {
+	database   *sqlx.DB
+	feeService *services.FeeService
+}
+
+type FeeRuleInput struct {
+	Type     models.FeeType   `json:"type"`
+	Role     *auth.Role       `json:"role"`
+	Currency money.Currency   `json:"currency"`
+	Kind     models.FeeKind   `json:"kind"`
+	Flat     *money.Money     `json:"flat"`
+	Bps      *int             `json:"bps"`
+	Tiers    []models.FeeTier `json:"tiers"`
+	MinFee   *money.Money     `json:"min_fee"`
+	MaxFee   *money.Money     `json:"max_fee"`
+}
+
+func NewFeeHandler(db *sqlx.DB, fee_service *services.FeeService) *FeeHandler {
+	return &FeeHandler{database: db, feeService: fee_service}
+}
+
+// Preview handles GET /fees/preview?operation=transfer&amount=100.00&currency=USD&to_currency=EUR.
+// currency defaults to USD; to_currency only matters for transfers.
+func (h *FeeHandler) Preview(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := req.URL.Query()
+	operation := models.TransactionType(query.Get("operation"))
+
+	currency := money.DefaultCurrency
+	if value := query.Get("currency"); value != "" {
+		currency = money.Currency(strings.ToUpper(value))
+		if !currency.Valid() {
+			writeError(w, invalidParam("currency"))
+			return
+		}
+	}
+
+	amount, err := money.Parse(query.Get("amount"), currency)
+	if err != nil {
+		writeError(w, invalidParam("amount"))
+		return
+	}
+
+	toCurrency := money.Currency(strings.ToUpper(query.Get("to_currency")))
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	preview, err := h.feeService.Preview(userID, operation, amount, toCurrency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(preview)
+}
+
+func (h *FeeHandler) ListRules(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rules, err := h.feeService.ListRules()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rules)
+}
+
+func (h *FeeHandler) SetRule(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input FeeRuleInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		if appErr := moneyDecodeError(err); appErr != nil {
+			writeError(w, appErr)
+		} else {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+		}
+		return
+	}
+
+	rule := &models.FeeRule{
+		Type:     input.Type,
+		Role:     input.Role,
+		Currency: money.Currency(strings.ToUpper(string(input.Currency))),
+		Kind:     input.Kind,
+		Flat:     input.Flat,
+		Bps:      input.Bps,
+		Tiers:    input.Tiers,
+		MinFee:   input.MinFee,
+		MaxFee:   input.MaxFee,
+	}
+	if err := h.feeService.SetRule(rule); err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (h *FeeHandler) DeleteRule(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ruleID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrFeeRuleNotFound)
+		return
+	}
+
+	if err := h.feeService.DeleteRule(ruleID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write([]byte("Fee rule deleted"))
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type FXHandler struct {
+	database  *sqlx.DB
+	fxService *services.FXService
+}
+
+type FXRateInput struct {
+	Base        money.Currency `json:"base"`
+	Quote       money.Currency `json:"quote"`
+	Rate        string         `json:"rate"`
+	SpreadBps   int            `json:"spread_bps"`
+	EffectiveAt *time.Time     `json:"effective_at"`
+}
+
+func NewFXHandler(db *sqlx.DB, fx_service *services.FXService) *FXHandler {
+	return &FXHandler{database: db, fxService: fx_service}
+}
+
+// Quote handles GET /fx/quote?from=USD&to=EUR&amount=100.00. The quote's id
+// can be sent as quote_id with a transfer before it expires.
+func (h *FXHandler) Quote(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := req.URL.Query()
+	from := money.Currency(strings.ToUpper(query.Get("from")))
+	to := money.Currency(strings.ToUpper(query.Get("to")))
+	if !from.Valid() {
+		writeError(w, invalidParam("from"))
+		return
+	}
+	if !to.Valid() {
+		writeError(w, invalidParam("to"))
+		return
+	}
+
+	amount, err := money.Parse(query.Get("amount"), from)
+	if err != nil {
+		writeError(w, invalidParam("amount"))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	quote, err := h.fxService.CreateQuote(userID, amount, to, time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(quote)
+}
+
+func (h *FXHandler) ListRates(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rates, err := h.fxService.ListRates(time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rates)
+}
+
+// SetRates handles PUT /admin/fx/rates with an array of rates.
+func (h *FXHandler) SetRates(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input []FXRateInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	rates := make([]models.FXRate, len(input))
+	for i, in := range input {
+		rates[i] = models.FXRate{
+			Base:      money.Currency(strings.ToUpper(string(in.Base))),
+			Quote:     money.Currency(strings.ToUpper(string(in.Quote))),
+			Rate:      in.Rate,
+			SpreadBps: in.SpreadBps,
+		}
+		if in.EffectiveAt != nil {
+			rates[i].EffectiveAt = *in.EffectiveAt
+		}
+	}
+
+	stored, err := h.fxService.SetRates(rates, models.FXSourceAdmin, time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(stored)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type HoldHandler struct {
+	database     *sqlx.DB
+	holdService  *services.HoldService
+	auditService *services.AuditService
+}
+
+type HoldInput struct {
+	AccountID   int64       `json:"account_id"`
+	ToAccountID int64       `json:"to_account_id"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	ExpiresIn   string      `json:"expires_in"`
+}
+
+type CaptureInput struct {
+	Amount *money.Money `json:"amount"`
+}
+
+func NewHoldHandler(db *sqlx.DB, hold_service *services.HoldService, audit_service *services.AuditService) *HoldHandler {
+	return &HoldHandler{database: db, holdService: hold_service, auditService: audit_service}
+}
+
+// auditEntry starts the audit entry of a hold being placed, captured or
+// released. The service completes it with the outcome.
+func (h *HoldHandler) auditEntry(req *http.Request, action string, amount *money.Money, holdID *int64, details map[string]interface{}) *models.AuditEntry {
+	entry := newAuditEntry(req, action)
+	entry.TargetType = models.AuditTargetHold
+	entry.TargetID = holdID
+	entry.Amount = amount
+	entry.Details = auditDetails(details)
+	return entry
+}
+
+func (h *HoldHandler) Create(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input HoldInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	var expiresIn time.Duration
+	if input.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(input.ExpiresIn)
+		if err != nil {
+			writeError(w, apperrors.ErrInvalidHoldExpiry)
+			return
+		}
+		expiresIn = parsed
+	}
+
+	hold := &models.Hold{
+		AccountID:   input.AccountID,
+		ToAccountID: input.ToAccountID,
+		Amount:      input.Amount,
+		Description: input.Description,
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditHold, &input.Amount, nil, map[string]interface{}{
+		"account_id":    input.AccountID,
+		"to_account_id": input.ToAccountID,
+	})
+	err := h.holdService.PlaceHold(services.WithAudit(req.Context(), entry), userID, hold, expiresIn, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+func (h *HoldHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+
+	holds, err := h.holdService.ListHolds(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(holds)
+}
+
+func (h *HoldHandler) Get(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	holdID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrHoldNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	hold, err := h.holdService.GetHold(userID, holdID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(hold)
+}
+
+// Capture handles POST /holds/{id}/capture. The body is optional; without
+// an amount the whole hold is captured.
+func (h *HoldHandler) Capture(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	holdID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrHoldNotFound)
+		return
+	}
+
+	var input CaptureInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditHoldCapture, input.Amount, &holdID, map[string]interface{}{})
+	hold, transaction, err := h.holdService.CaptureHold(services.WithAudit(req.Context(), entry), userID, holdID, input.Amount, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		*models.Hold
+		Transaction *models.TransactionInfo `json:"transaction"`
+	}{hold, transaction})
+}
+
+func (h *HoldHandler) Release(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	holdID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrHoldNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditHoldRelease, nil, &holdID, map[string]interface{}{})
+	hold, err := h.holdService.ReleaseHold(services.WithAudit(req.Context(), entry), userID, holdID, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(hold)
+}
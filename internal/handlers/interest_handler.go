@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type InterestHandler struct {
+	database        *sqlx.DB
+	interestService *services.InterestService
+}
+
+type InterestProductInput struct {
+	Name        string                 `json:"name"`
+	Currency    money.Currency         `json:"currency"`
+	AccountType models.AccountType     `json:"account_type"`
+	RateBps     int                    `json:"rate_bps"`
+	DayCount    models.DayCount        `json:"day_count"`
+	Compounding models.Compounding     `json:"compounding"`
+	Payout      models.PayoutFrequency `json:"payout"`
+}
+
+type InterestAttachmentInput struct {
+	ProductID int64  `json:"product_id"`
+	UserID    *int64 `json:"user_id"`
+	AccountID *int64 `json:"account_id"`
+}
+
+func NewInterestHandler(db *sqlx.DB, interest_service *services.InterestService) *InterestHandler {
+	return &InterestHandler{database: db, interestService: interest_service}
+}
+
+// product builds a product from the request body. Missing conventions get
+// their defaults: act/365, no compounding and monthly payout.
+func (h *InterestHandler) product(w http.ResponseWriter, req *http.Request) (*models.InterestProduct, bool) {
+	var input InterestProductInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return nil, false
+	}
+
+	product := &models.InterestProduct{
+		Name:        input.Name,
+		Currency:    money.Currency(strings.ToUpper(string(input.Currency))),
+		AccountType: input.AccountType,
+		RateBps:     input.RateBps,
+		DayCount:    input.DayCount,
+		Compounding: input.Compounding,
+		Payout:      input.Payout,
+	}
+	if product.DayCount == "" {
+		product.DayCount = models.DayCountActual365
+	}
+	if product.Compounding == "" {
+		product.Compounding = models.CompoundingNone
+	}
+	if product.Payout == "" {
+		product.Payout = models.PayoutMonthly
+	}
+	return product, true
+}
+
+// ListProducts handles GET /admin/interest-products.
+func (h *InterestHandler) ListProducts(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	products, err := h.interestService.ListProducts()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(products)
+}
+
+// CreateProduct handles POST /admin/interest-products.
+func (h *InterestHandler) CreateProduct(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	product, ok := h.product(w, req)
+	if !ok {
+		return
+	}
+	if err := h.interestService.CreateProduct(product); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(product)
+}
+
+// UpdateProduct handles PUT /admin/interest-products/{id}.
+func (h *InterestHandler) UpdateProduct(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	productID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrInterestProductNotFound)
+		return
+	}
+
+	product, ok := h.product(w, req)
+	if !ok {
+		return
+	}
+	product.ID = productID
+	if err := h.interestService.UpdateProduct(product); err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(product)
+}
+
+// ListAttachments handles GET /admin/interest-attachments.
+func (h *InterestHandler) ListAttachments(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	attachments, err := h.interestService.ListAttachments()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// Attach handles PUT /admin/interest-attachments, which attaches a product to
+// a user or an account in place of whatever was attached there.
+func (h *InterestHandler) Attach(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input InterestAttachmentInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	attachment := &models.InterestAttachment{
+		ProductID: input.ProductID,
+		UserID:    input.UserID,
+		AccountID: input.AccountID,
+	}
+	if err := h.interestService.Attach(attachment); err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// Detach handles DELETE /admin/interest-attachments/{id}.
+func (h *InterestHandler) Detach(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	attachmentID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrInterestAttachmentNotFound)
+		return
+	}
+
+	if err := h.interestService.Detach(attachmentID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write([]byte("Interest attachment deleted"))
+}
+
+// AccountInterest handles GET /accounts/{id}/interest.
+func (h *InterestHandler) AccountInterest(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	accountID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrAccountNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	interest, err := h.interestService.AccountInterest(userID, accountID, time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(interest)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"MockBankGo/auth"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type KeyHandler struct {
+	keySet *auth.KeySet
+}
+
+func NewKeyHandler(key_set *auth.KeySet) *KeyHandler {
+	return &KeyHandler{keySet: key_set}
+}
+
+// JWKS publishes the public keys that verify bank tokens.
+func (h *KeyHandler) JWKS(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	json.NewEncoder(w).Encode(h.keySet.JWKS(time.Now()))
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type LimitHandler struct {
+	database     *sqlx.DB
+	limitService *services.LimitService
+}
+
+type LimitInput struct {
+	Scope     models.LimitScope      `json:"scope"`
+	Role      *auth.Role             `json:"role"`
+	UserID    *int64                 `json:"user_id"`
+	Operation models.TransactionType `json:"operation"`
+	Kind      models.LimitKind       `json:"kind"`
+	Amount    *money.Money           `json:"amount"`
+	Count     *int64                 `json:"count"`
+	Currency  money.Currency         `json:"currency"`
+}
+
+func NewLimitHandler(db *sqlx.DB, limit_service *services.LimitService) *LimitHandler {
+	return &LimitHandler{database: db, limitService: limit_service}
+}
+
+func (h *LimitHandler) ListLimits(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limits, err := h.limitService.ListLimits()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(limits)
+}
+
+func (h *LimitHandler) SetLimit(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input LimitInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		if appErr := moneyDecodeError(err); appErr != nil {
+			writeError(w, appErr)
+		} else {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+		}
+		return
+	}
+
+	limit := &models.TransactionLimit{
+		Scope:     input.Scope,
+		Role:      input.Role,
+		UserID:    input.UserID,
+		Operation: input.Operation,
+		Kind:      input.Kind,
+		Amount:    input.Amount,
+		Count:     input.Count,
+		Currency:  input.Currency,
+	}
+	if err := h.limitService.SetLimit(limit); err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(limit)
+}
+
+func (h *LimitHandler) DeleteLimit(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limitID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrLimitNotFound)
+		return
+	}
+
+	if err := h.limitService.DeleteLimit(limitID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write([]byte("Limit deleted"))
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type LoanHandler struct {
+	database     *sqlx.DB
+	loanService  *services.LoanService
+	auditService *services.AuditService
+}
+
+type LoanApplicationInput struct {
+	AccountID  int64             `json:"account_id"`
+	Amount     money.Money       `json:"amount"`
+	TermMonths int               `json:"term_months"`
+	Method     models.LoanMethod `json:"method"`
+}
+
+type LoanApprovalInput struct {
+	RateBps int          `json:"rate_bps"`
+	LateFee *money.Money `json:"late_fee"`
+}
+
+type LoanRepaymentInput struct {
+	Amount *money.Money `json:"amount"`
+}
+
+func NewLoanHandler(db *sqlx.DB, loan_service *services.LoanService, audit_service *services.AuditService) *LoanHandler {
+	return &LoanHandler{database: db, loanService: loan_service, auditService: audit_service}
+}
+
+// loanID reads the {id} route variable.
+func (h *LoanHandler) loanID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	loanID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrLoanNotFound)
+		return 0, false
+	}
+	return loanID, true
+}
+
+// auditEntry starts the audit entry of a loan being approved, rejected or
+// repaid early. The service completes it with the outcome.
+func (h *LoanHandler) auditEntry(req *http.Request, action string, amount *money.Money, loanID int64, details map[string]interface{}) *models.AuditEntry {
+	entry := newAuditEntry(req, action)
+	entry.TargetType = models.AuditTargetLoan
+	entry.TargetID = &loanID
+	entry.Amount = amount
+	entry.Details = auditDetails(details)
+	return entry
+}
+
+// Apply handles POST /loans.
+func (h *LoanHandler) Apply(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input LoanApplicationInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	loan := &models.Loan{
+		AccountID:  input.AccountID,
+		Principal:  input.Amount,
+		TermMonths: input.TermMonths,
+		Method:     input.Method,
+	}
+	if loan.Method == "" {
+		loan.Method = models.LoanAnnuity
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	if err := h.loanService.Apply(userID, loan); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(loan)
+}
+
+// List handles GET /loans.
+func (h *LoanHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+
+	loans, err := h.loanService.ListLoans(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(loans)
+}
+
+// Get handles GET /loans/{id}: the loan, its outstanding principal and its
+// repayment schedule.
+func (h *LoanHandler) Get(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loanID, ok := h.loanID(w, req)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	loan, err := h.loanService.GetLoan(userID, loanID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(loan)
+}
+
+// Repay handles POST /loans/{id}/repay. The body is optional; without an
+// amount the whole loan is repaid.
+func (h *LoanHandler) Repay(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loanID, ok := h.loanID(w, req)
+	if !ok {
+		return
+	}
+
+	var input LoanRepaymentInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditLoanRepay, input.Amount, loanID, map[string]interface{}{})
+	loan, transaction, err := h.loanService.RepayEarly(services.WithAudit(req.Context(), entry), userID, loanID, input.Amount, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		*models.LoanDetails
+		Transaction *models.TransactionInfo `json:"transaction"`
+	}{loan, transaction})
+}
+
+// ListAll handles GET /admin/loans?status=pending.
+func (h *LoanHandler) ListAll(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := models.LoanStatus(req.URL.Query().Get("status"))
+	if status != "" && !status.Valid() {
+		writeError(w, invalidParam("status"))
+		return
+	}
+
+	loans, err := h.loanService.ListAllLoans(status)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(loans)
+}
+
+// Approve handles POST /admin/loans/{id}/approve, which disburses the loan.
+func (h *LoanHandler) Approve(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loanID, ok := h.loanID(w, req)
+	if !ok {
+		return
+	}
+
+	var input LoanApprovalInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+	lateFee := money.Money{}
+	if input.LateFee != nil {
+		lateFee = *input.LateFee
+	}
+
+	adminID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditLoanApprove, nil, loanID, map[string]interface{}{"rate_bps": input.RateBps})
+	loan, err := h.loanService.Approve(services.WithAudit(req.Context(), entry), adminID, loanID, input.RateBps, lateFee, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(loan)
+}
+
+// Reject handles POST /admin/loans/{id}/reject.
+func (h *LoanHandler) Reject(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loanID, ok := h.loanID(w, req)
+	if !ok {
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditLoanReject, nil, loanID, map[string]interface{}{})
+	loan, err := h.loanService.Reject(services.WithAudit(req.Context(), entry), adminID, loanID, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(loan)
+}
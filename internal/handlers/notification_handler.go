@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationHandler struct {
+	database            *sqlx.DB
+	notificationService *services.NotificationService
+}
+
+func NewNotificationHandler(db *sqlx.DB, notification_service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{database: db, notificationService: notification_service}
+}
+
+// List handles GET /notifications?unread=true.
+func (h *NotificationHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	unreadOnly := false
+	if value := req.URL.Query().Get("unread"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, invalidParam("unread"))
+			return
+		}
+		unreadOnly = parsed
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	notifications, err := h.notificationService.ListNotifications(userID, unreadOnly)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(notifications)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	notificationID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrNotificationNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	notification, err := h.notificationService.MarkRead(userID, notificationID, time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(notification)
+}
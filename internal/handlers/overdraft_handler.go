@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type OverdraftHandler struct {
+	database         *sqlx.DB
+	overdraftService *services.OverdraftService
+}
+
+type OverdraftInput struct {
+	Limit       money.Money `json:"limit"`
+	InterestBps int         `json:"interest_bps"`
+}
+
+func NewOverdraftHandler(db *sqlx.DB, overdraft_service *services.OverdraftService) *OverdraftHandler {
+	return &OverdraftHandler{database: db, overdraftService: overdraft_service}
+}
+
+// List handles GET /admin/users/{id}/overdrafts.
+func (h *OverdraftHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrUserNotFound)
+		return
+	}
+
+	overdrafts, err := h.overdraftService.ListOverdrafts(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(overdrafts)
+}
+
+// Set handles PUT /admin/users/{id}/overdraft, which creates or replaces the
+// user's overdraft in the currency of the limit.
+func (h *OverdraftHandler) Set(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrUserNotFound)
+		return
+	}
+
+	var input OverdraftInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		if appErr := moneyDecodeError(err); appErr != nil {
+			writeError(w, appErr)
+		} else {
+			http.Error(w, "Invalid input", http.StatusBadRequest)
+		}
+		return
+	}
+
+	overdraft := &models.Overdraft{
+		UserID:      userID,
+		Limit:       input.Limit,
+		InterestBps: input.InterestBps,
+	}
+	if err := h.overdraftService.SetOverdraft(overdraft); err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(overdraft)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type PayeeHandler struct {
+	database     *sqlx.DB
+	payeeService *services.PayeeService
+}
+
+type PayeeInput struct {
+	Name            string         `json:"name"`
+	ToAccountNumber string         `json:"to_account_number"`
+	ToIBAN          string         `json:"to_iban"`
+	ToUsername      string         `json:"to_username"`
+	ToEmail         string         `json:"to_email"`
+	Currency        money.Currency `json:"currency"`
+}
+
+func NewPayeeHandler(db *sqlx.DB, payee_service *services.PayeeService) *PayeeHandler {
+	return &PayeeHandler{database: db, payeeService: payee_service}
+}
+
+// currencyParam reads an optional currency code. Without one the default
+// currency is used.
+func currencyParam(value string) (money.Currency, error) {
+	if value == "" {
+		return money.DefaultCurrency, nil
+	}
+	currency := money.Currency(strings.ToUpper(value))
+	if !currency.Valid() {
+		return "", invalidParam("currency")
+	}
+	return currency, nil
+}
+
+// List handles GET /payees.
+func (h *PayeeHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+
+	payees, err := h.payeeService.ListPayees(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(payees)
+}
+
+// Create handles POST /payees. A recipient given by username or email is
+// saved as the account that would receive money from them in currency.
+func (h *PayeeHandler) Create(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input PayeeInput
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	currency, err := currencyParam(string(input.Currency))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	query := models.RecipientQuery{
+		AccountNumber: input.ToAccountNumber,
+		IBAN:          input.ToIBAN,
+		Username:      input.ToUsername,
+		Email:         input.ToEmail,
+	}
+	payee, err := h.payeeService.CreatePayee(userID, input.Name, query, currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payee)
+}
+
+// Delete handles DELETE /payees/{id}.
+func (h *PayeeHandler) Delete(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	payeeID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrPayeeNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	if err := h.payeeService.DeletePayee(userID, payeeID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Write([]byte("Payee deleted"))
+}
+
+// Lookup handles GET /recipients/lookup, which shows who a transfer would
+// go to before it is sent: the account number and owner's name, masked.
+// The recipient is given by exactly one of account_number, iban, username,
+// email or payee_id; currency picks which account of a user receives it.
+func (h *PayeeHandler) Lookup(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params := req.URL.Query()
+	query := models.RecipientQuery{
+		AccountNumber: params.Get("account_number"),
+		IBAN:          params.Get("iban"),
+		Username:      params.Get("username"),
+		Email:         params.Get("email"),
+	}
+	if value := params.Get("payee_id"); value != "" {
+		payeeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(w, invalidParam("payee_id"))
+			return
+		}
+		query.PayeeID = &payeeID
+	}
+
+	currency, err := currencyParam(params.Get("currency"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	recipient, err := h.payeeService.Resolve(userID, query, currency)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(recipient)
+}
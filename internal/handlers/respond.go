@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// decodeBody reads the JSON request body into v. An empty body is accepted.
+// A malformed money amount answers with the matching AppError, anything else
+// with a plain 400.
+func decodeBody(w http.ResponseWriter, req *http.Request, v interface{}) bool {
+	err := json.NewDecoder(req.Body).Decode(v)
+	if err == nil || err == io.EOF {
+		return true
+	}
+
+	if appErr := moneyDecodeError(err); appErr != nil {
+		writeError(w, appErr)
+	} else {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+	}
+	return false
+}
+
+// writeError answers with err as a JSON error. An AppError keeps its status
+// and message, a breached limit also names the limit, and anything else is
+// reported as a 500 without details.
+func writeError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if limitErr, ok := err.(*services.LimitExceeded); ok {
+		w.WriteHeader(limitErr.StatusCode)
+		json.NewEncoder(w).Encode(struct {
+			Error     string                 `json:"error"`
+			Limit     models.LimitKind       `json:"limit"`
+			Scope     models.LimitScope      `json:"scope"`
+			Operation models.TransactionType `json:"operation"`
+			ResetsAt  *time.Time             `json:"resets_at,omitempty"`
+		}{limitErr.Message, limitErr.Limit.Kind, limitErr.Limit.Scope, limitErr.Limit.Operation, limitErr.ResetsAt})
+		return
+	}
+
+	if appErr, ok := err.(*apperrors.AppError); ok {
+		w.WriteHeader(appErr.StatusCode)
+		json.NewEncoder(w).Encode(map[string]string{"error": appErr.Message})
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Internal server error"})
+	}
+}
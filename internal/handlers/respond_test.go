@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBody(t *testing.T) {
+	type input struct {
+		Amount money.Money `json:"amount"`
+	}
+
+	cases := []struct {
+		name   string
+		body   string
+		ok     bool
+		status int
+		error  string
+	}{
+		{name: "valid", body: `{"amount":"12.50"}`, ok: true},
+		{name: "empty", body: ``, ok: true},
+		{name: "too precise", body: `{"amount":"12.505"}`, status: http.StatusBadRequest, error: apperrors.ErrAmountTooPrecise.Message},
+		{name: "malformed", body: `{"amount":`, status: http.StatusBadRequest, error: "Invalid input"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+
+			var v input
+			assert.Equal(t, tc.ok, decodeBody(w, req, &v))
+			if tc.ok {
+				return
+			}
+			assert.Equal(t, tc.status, w.Code)
+			assert.Contains(t, w.Body.String(), tc.error)
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	limitErr := &services.LimitExceeded{
+		AppError: &apperrors.AppError{Message: "daily limit exceeded", StatusCode: http.StatusUnprocessableEntity},
+		Limit:    models.TransactionLimit{Kind: models.LimitPerTransaction, Scope: models.LimitUser},
+	}
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{name: "app error", err: apperrors.ErrAmountTooPrecise, status: http.StatusBadRequest,
+			body: `{"error":"amount has more decimal places than the currency allows"}`},
+		{name: "limit exceeded", err: limitErr, status: http.StatusUnprocessableEntity,
+			body: `{"error":"daily limit exceeded","limit":"per_transaction","scope":"user","operation":""}`},
+		{name: "unexpected", err: errors.New("connection reset"), status: http.StatusInternalServerError,
+			body: `{"error":"Internal server error"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeError(w, tc.err)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.body, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type StandingOrderHandler struct {
+	database             *sqlx.DB
+	standingOrderService *services.StandingOrderService
+}
+
+type StandingOrderInput struct {
+	FromAccountID  int64            `json:"from_account_id"`
+	ToAccountID    int64            `json:"to_account_id"`
+	Amount         money.Money      `json:"amount"`
+	Frequency      models.Frequency `json:"frequency"`
+	StartDate      string           `json:"start_date"`
+	EndDate        *string          `json:"end_date"`
+	MaxOccurrences *int             `json:"max_occurrences"`
+	Description    string           `json:"description"`
+}
+
+type StandingOrderUpdateInput struct {
+	Amount         *money.Money                `json:"amount"`
+	Description    *string                     `json:"description"`
+	EndDate        *string                     `json:"end_date"`
+	MaxOccurrences *int                        `json:"max_occurrences"`
+	Status         *models.StandingOrderStatus `json:"status"`
+}
+
+func NewStandingOrderHandler(db *sqlx.DB, standing_order_service *services.StandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{database: db, standingOrderService: standing_order_service}
+}
+
+func parseDate(value string) (time.Time, error) {
+	return time.Parse("2006-01-02", value)
+}
+
+func (h *StandingOrderHandler) Create(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input StandingOrderInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	order := &models.StandingOrder{
+		FromAccountID:  input.FromAccountID,
+		ToAccountID:    input.ToAccountID,
+		Amount:         input.Amount,
+		Frequency:      input.Frequency,
+		MaxOccurrences: input.MaxOccurrences,
+		Description:    input.Description,
+	}
+
+	startDate, err := parseDate(input.StartDate)
+	if err != nil {
+		writeError(w, invalidParam("start_date"))
+		return
+	}
+	order.StartDate = startDate
+
+	if input.EndDate != nil {
+		endDate, err := parseDate(*input.EndDate)
+		if err != nil {
+			writeError(w, invalidParam("end_date"))
+			return
+		}
+		order.EndDate = &endDate
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	if err := h.standingOrderService.CreateStandingOrder(userID, order, time.Now()); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+
+	orders, err := h.standingOrderService.ListStandingOrders(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *StandingOrderHandler) Get(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orderID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrStandingOrderNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	order, executions, err := h.standingOrderService.GetStandingOrder(userID, orderID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		*models.StandingOrder
+		Executions []models.StandingOrderExecution `json:"executions"`
+	}{order, executions})
+}
+
+func (h *StandingOrderHandler) Update(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orderID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrStandingOrderNotFound)
+		return
+	}
+
+	var input StandingOrderUpdateInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	update := services.StandingOrderUpdate{
+		Amount:         input.Amount,
+		Description:    input.Description,
+		MaxOccurrences: input.MaxOccurrences,
+		Status:         input.Status,
+	}
+	if input.EndDate != nil {
+		endDate, err := parseDate(*input.EndDate)
+		if err != nil {
+			writeError(w, invalidParam("end_date"))
+			return
+		}
+		update.EndDate = &endDate
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	order, err := h.standingOrderService.UpdateStandingOrder(userID, orderID, update, time.Now())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) Cancel(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orderID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrStandingOrderNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	if err := h.standingOrderService.CancelStandingOrder(userID, orderID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte("Standing order cancelled"))
+}
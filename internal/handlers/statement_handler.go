@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type StatementHandler struct {
+	database         *sqlx.DB
+	statementService *services.StatementService
+}
+
+func NewStatementHandler(db *sqlx.DB, statement_service *services.StatementService) *StatementHandler {
+	return &StatementHandler{database: db, statementService: statement_service}
+}
+
+// statementPeriod reads the from and to query parameters, RFC 3339
+// timestamps or plain dates, the latter covering the whole day when used for
+// to. The period defaults to the start of the current month up to now.
+func statementPeriod(req *http.Request, now time.Time) (time.Time, time.Time, error) {
+	query := req.URL.Query()
+	y, m, _ := now.UTC().Date()
+	from := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if value := query.Get(name); value != "" {
+			t, err := parseTimeParam(value, name == "to")
+			if err != nil {
+				return from, to, invalidParam(name)
+			}
+			*target = t
+		}
+	}
+	return from, to, nil
+}
+
+// Statement handles GET /statements?account_id=&from=&to=: the account's
+// opening balance, each movement with the balance after it, the totals in
+// and out and the closing balance.
+func (h *StatementHandler) Statement(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	accountID, err := strconv.ParseInt(req.URL.Query().Get("account_id"), 10, 64)
+	if err != nil {
+		writeError(w, invalidParam("account_id"))
+		return
+	}
+
+	now := time.Now()
+	from, to, err := statementPeriod(req, now)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	statement, err := h.statementService.Statement(req.Context(), userID, accountID, from, to, now)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(statement)
+}
+
+// List handles GET /accounts/{id}/statements: the account's monthly
+// statements, without their lines.
+func (h *StatementHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	accountID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrAccountNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	statements, err := h.statementService.ListStatements(userID, accountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(statements)
+}
+
+// Get handles GET /statements/{id}: one monthly statement with its lines.
+func (h *StatementHandler) Get(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	statementID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrStatementNotFound)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	statement, err := h.statementService.GetStatement(userID, statementID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(statement)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/services"
+	"MockBankGo/middleware"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+)
+
+type TermDepositHandler struct {
+	database           *sqlx.DB
+	termDepositService *services.TermDepositService
+	auditService       *services.AuditService
+}
+
+type TermDepositProductInput struct {
+	Name       string         `json:"name"`
+	Currency   money.Currency `json:"currency"`
+	TermMonths int            `json:"term_months"`
+	RateBps    int            `json:"rate_bps"`
+	PenaltyBps int            `json:"penalty_bps"`
+	MinAmount  *money.Money   `json:"min_amount"`
+	Active     *bool          `json:"active"`
+}
+
+type TermDepositInput struct {
+	ProductID int64       `json:"product_id"`
+	AccountID int64       `json:"account_id"`
+	Amount    money.Money `json:"amount"`
+	Rollover  bool        `json:"rollover"`
+}
+
+type TermDepositRolloverInput struct {
+	Rollover *bool `json:"rollover"`
+}
+
+func NewTermDepositHandler(db *sqlx.DB, term_deposit_service *services.TermDepositService, audit_service *services.AuditService) *TermDepositHandler {
+	return &TermDepositHandler{database: db, termDepositService: term_deposit_service, auditService: audit_service}
+}
+
+// depositID reads the {id} route variable.
+func (h *TermDepositHandler) depositID(w http.ResponseWriter, req *http.Request) (int64, bool) {
+	depositID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrTermDepositNotFound)
+		return 0, false
+	}
+	return depositID, true
+}
+
+// auditEntry starts the audit entry of a term deposit being opened or
+// broken. The service completes it with the outcome.
+func (h *TermDepositHandler) auditEntry(req *http.Request, action string, amount *money.Money, depositID *int64, details map[string]interface{}) *models.AuditEntry {
+	entry := newAuditEntry(req, action)
+	entry.TargetType = models.AuditTargetTermDeposit
+	entry.TargetID = depositID
+	entry.Amount = amount
+	entry.Details = auditDetails(details)
+	return entry
+}
+
+// product builds a product from the request body. A product takes new
+// deposits unless active is false, and has no minimum unless min_amount is
+// given.
+func (h *TermDepositHandler) product(w http.ResponseWriter, req *http.Request) (*models.TermDepositProduct, bool) {
+	var input TermDepositProductInput
+	if !decodeBody(w, req, &input) {
+		return nil, false
+	}
+
+	product := &models.TermDepositProduct{
+		Name:       input.Name,
+		Currency:   money.Currency(strings.ToUpper(string(input.Currency))),
+		TermMonths: input.TermMonths,
+		RateBps:    input.RateBps,
+		PenaltyBps: input.PenaltyBps,
+		Active:     input.Active == nil || *input.Active,
+	}
+	if input.MinAmount != nil {
+		product.MinAmount = *input.MinAmount
+	}
+	return product, true
+}
+
+// ListProducts handles GET /admin/term-deposit-products.
+func (h *TermDepositHandler) ListProducts(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	products, err := h.termDepositService.ListProducts(false)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(products)
+}
+
+// ListOpenProducts handles GET /term-deposit-products: the products taking
+// new deposits.
+func (h *TermDepositHandler) ListOpenProducts(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	products, err := h.termDepositService.ListProducts(true)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(products)
+}
+
+// CreateProduct handles POST /admin/term-deposit-products.
+func (h *TermDepositHandler) CreateProduct(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	product, ok := h.product(w, req)
+	if !ok {
+		return
+	}
+	if err := h.termDepositService.CreateProduct(product); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(product)
+}
+
+// UpdateProduct handles PUT /admin/term-deposit-products/{id}.
+func (h *TermDepositHandler) UpdateProduct(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	productID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrTermDepositProductNotFound)
+		return
+	}
+
+	product, ok := h.product(w, req)
+	if !ok {
+		return
+	}
+	product.ID = productID
+	if err := h.termDepositService.UpdateProduct(product); err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(product)
+}
+
+// Open handles POST /term-deposits.
+func (h *TermDepositHandler) Open(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input TermDepositInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditTermDeposit, &input.Amount, nil, map[string]interface{}{
+		"product_id": input.ProductID,
+		"account_id": input.AccountID,
+	})
+	deposit, err := h.termDepositService.Open(services.WithAudit(req.Context(), entry), userID, input.ProductID, input.AccountID, input.Amount, input.Rollover, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(deposit)
+}
+
+// List handles GET /term-deposits.
+func (h *TermDepositHandler) List(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+
+	deposits, err := h.termDepositService.List(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(deposits)
+}
+
+// Get handles GET /term-deposits/{id}.
+func (h *TermDepositHandler) Get(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	depositID, ok := h.depositID(w, req)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	deposit, err := h.termDepositService.Get(userID, depositID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(deposit)
+}
+
+// SetRollover handles PATCH /term-deposits/{id}, which changes whether the
+// deposit rolls over when it matures.
+func (h *TermDepositHandler) SetRollover(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	depositID, ok := h.depositID(w, req)
+	if !ok {
+		return
+	}
+
+	var input TermDepositRolloverInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+	if input.Rollover == nil {
+		writeError(w, invalidParam("rollover"))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	deposit, err := h.termDepositService.SetRollover(userID, depositID, *input.Rollover)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(deposit)
+}
+
+// Break handles POST /term-deposits/{id}/break.
+func (h *TermDepositHandler) Break(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	depositID, ok := h.depositID(w, req)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(req.Context())
+
+	entry := h.auditEntry(req, models.AuditTermBreak, nil, &depositID, map[string]interface{}{})
+	deposit, transaction, err := h.termDepositService.Break(services.WithAudit(req.Context(), entry), userID, depositID, time.Now())
+	recordQueuedAudit(req.Context(), h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(struct {
+		*models.TermDeposit
+		Transaction *models.TransactionInfo `json:"transaction"`
+	}{deposit, transaction})
+}
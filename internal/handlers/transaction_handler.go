@@ -2,112 +2,403 @@ package handlers
 
 import (
 	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
 	"MockBankGo/internal/services"
 	"MockBankGo/middleware"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
 )
 
 type TransactionHandler struct {
 	database           *sqlx.DB
 	transactionService *services.TransactionService
+	payeeService       *services.PayeeService
+	auditService       *services.AuditService
 }
 
 type TransactionInput struct {
-	Amount     float64 `json:"amount"`
-	ReceiverID *int64  `json:"receiver_id"`
+	Amount        money.Money `json:"amount"`
+	AccountID     *int64      `json:"account_id"`
+	FromAccountID *int64      `json:"from_account_id"`
+	ToAccountID   *int64      `json:"to_account_id"`
+	QuoteID       string      `json:"quote_id,omitempty"`
+
+	// A transfer's recipient may be given instead of ToAccountID by any
+	// one of these.
+	ToAccountNumber string `json:"to_account_number,omitempty"`
+	ToIBAN          string `json:"to_iban,omitempty"`
+	ToUsername      string `json:"to_username,omitempty"`
+	ToEmail         string `json:"to_email,omitempty"`
+	PayeeID         *int64 `json:"payee_id,omitempty"`
+}
+
+// recipient is who a transfer is for, however the input names them.
+func (input *TransactionInput) recipient() models.RecipientQuery {
+	return models.RecipientQuery{
+		AccountID:     input.ToAccountID,
+		AccountNumber: input.ToAccountNumber,
+		IBAN:          input.ToIBAN,
+		Username:      input.ToUsername,
+		Email:         input.ToEmail,
+		PayeeID:       input.PayeeID,
+	}
 }
 
-func NewTransactionHandler(db *sqlx.DB, transaction_service *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{database: db, transactionService: transaction_service}
+func NewTransactionHandler(db *sqlx.DB, transaction_service *services.TransactionService, payee_service *services.PayeeService, audit_service *services.AuditService) *TransactionHandler {
+	return &TransactionHandler{database: db, transactionService: transaction_service, payeeService: payee_service, auditService: audit_service}
+}
+
+// auditEntry starts the audit entry of a money movement attempt. The service
+// completes it with the transaction it produces.
+func (h *TransactionHandler) auditEntry(req *http.Request, action string, amount money.Money, details map[string]interface{}) *models.AuditEntry {
+	entry := newAuditEntry(req, action)
+	entry.TargetType = models.AuditTargetTransaction
+	entry.Details = auditDetails(details)
+	if !amount.IsZero() {
+		entry.Amount = &amount
+	}
+	return entry
 }
 
 func (h *TransactionHandler) handleError(w http.ResponseWriter, err error) {
-	// handleError now sets its own Content-Type and returns JSON
-	w.Header().Set("Content-Type", "application/json")
+	// A replayed idempotent request gets exactly the response stored the first time.
+	if replay, ok := err.(*services.IdempotentReplay); ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(replay.StatusCode)
+		w.Write(replay.Body)
+		return
+	}
 
-	if appErr, ok := err.(*apperrors.AppError); ok {
-		w.WriteHeader(appErr.StatusCode)
-		json.NewEncoder(w).Encode(map[string]string{"error": appErr.Message})
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Internal server error"})
+	writeError(w, err)
+}
+
+// moneyDecodeError maps a JSON decoding failure caused by a money amount to
+// the AppError describing it, or returns nil for any other failure.
+func moneyDecodeError(err error) error {
+	switch {
+	case errors.Is(err, money.ErrTooPrecise):
+		return apperrors.ErrAmountTooPrecise
+	case errors.Is(err, money.ErrUnsupportedCurrency):
+		return apperrors.ErrUnsupportedCurrency
+	case errors.Is(err, money.ErrInvalidFormat), errors.Is(err, money.ErrOverflow):
+		return apperrors.ErrInvalidAmountFormat
 	}
+	return nil
+}
+
+// withIdempotency attaches the request's Idempotency-Key header, if any, to
+// the context. The key is bound to a hash of the route and the decoded input
+// so reusing it for a different request can be detected.
+func (h *TransactionHandler) withIdempotency(w http.ResponseWriter, req *http.Request, input *TransactionInput) (context.Context, bool) {
+	key := req.Header.Get("Idempotency-Key")
+	if key == "" {
+		return req.Context(), true
+	}
+	if len(key) > 255 {
+		h.handleError(w, apperrors.ErrInvalidIdempotencyKey)
+		return nil, false
+	}
+
+	canonical, err := json.Marshal(input)
+	if err != nil {
+		h.handleError(w, apperrors.ErrInternalServer)
+		return nil, false
+	}
+	sum := sha256.Sum256(append([]byte(req.Method+" "+req.URL.Path+"\n"), canonical...))
+
+	return services.WithIdempotencyKey(req.Context(), services.IdempotencyKey{
+		Key:         key,
+		RequestHash: hex.EncodeToString(sum[:]),
+	}), true
 }
 
 func (h *TransactionHandler) Withdraw(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var input TransactionInput
-	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	if !decodeBody(w, req, &input) {
 		return
 	}
 
-	userID, _ := middleware.GetUserID(req.Context())
+	if input.AccountID == nil {
+		h.handleError(w, apperrors.ErrAccountNotProvided)
+		return
+	}
+
+	ctx, ok := h.withIdempotency(w, req, &input)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
 
-	err := h.transactionService.WithdrawMoney(userID, input.Amount)
+	entry := h.auditEntry(req, models.AuditWithdraw, input.Amount, map[string]interface{}{"account_id": *input.AccountID})
+	transaction, err := h.transactionService.WithdrawMoney(services.WithAudit(ctx, entry), userID, *input.AccountID, input.Amount)
+	recordQueuedAudit(ctx, h.auditService, entry, err)
 	if err != nil {
 		h.handleError(w, err)
 		return
 	}
 
-	w.Write([]byte("Withdrawal successful!"))
+	json.NewEncoder(w).Encode(transaction)
 }
 
 func (h *TransactionHandler) Deposit(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var input TransactionInput
-	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	if !decodeBody(w, req, &input) {
 		return
 	}
 
-	userID, _ := middleware.GetUserID(req.Context())
+	if input.AccountID == nil {
+		h.handleError(w, apperrors.ErrAccountNotProvided)
+		return
+	}
+
+	ctx, ok := h.withIdempotency(w, req, &input)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(ctx)
 
-	err := h.transactionService.DepositMoney(userID, input.Amount)
+	entry := h.auditEntry(req, models.AuditDeposit, input.Amount, map[string]interface{}{"account_id": *input.AccountID})
+	transaction, err := h.transactionService.DepositMoney(services.WithAudit(ctx, entry), userID, *input.AccountID, input.Amount)
+	recordQueuedAudit(ctx, h.auditService, entry, err)
 	if err != nil {
 		h.handleError(w, err)
 		return
 	}
 
-	w.Write([]byte("Deposit successful!"))
+	json.NewEncoder(w).Encode(transaction)
 }
 
 func (h *TransactionHandler) Transfer(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	var input TransactionInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
 
-	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	if input.FromAccountID == nil {
+		h.handleError(w, apperrors.ErrAccountNotProvided)
 		return
 	}
 
-	if input.ReceiverID == nil {
-		h.handleError(w, apperrors.ErrReceiverNotProvided)
+	ctx, ok := h.withIdempotency(w, req, &input)
+	if !ok {
 		return
 	}
 
-	userID, _ := middleware.GetUserID(req.Context())
+	userID, _ := middleware.GetUserID(ctx)
 
-	if err := h.transactionService.TransferMoney(req.Context(), userID, *input.ReceiverID, input.Amount); err != nil {
+	details := map[string]interface{}{
+		"from_account_id": *input.FromAccountID,
+	}
+	if input.PayeeID != nil {
+		details["payee_id"] = *input.PayeeID
+	}
+
+	recipient, err := h.payeeService.Resolve(userID, input.recipient(), input.Amount.Currency)
+	if err != nil {
+		if err != apperrors.ErrReceiverNotProvided && err != apperrors.ErrInvalidRecipient {
+			recordAudit(h.auditService, h.auditEntry(req, models.AuditTransfer, input.Amount, details), err)
+		}
 		h.handleError(w, err)
 		return
 	}
+	details["to_account_id"] = recipient.AccountID
+	if input.QuoteID != "" {
+		details["quote_id"] = input.QuoteID
+	}
 
-	w.Write([]byte("Transfer successful!"))
+	entry := h.auditEntry(req, models.AuditTransfer, input.Amount, details)
+	transaction, err := h.transactionService.TransferMoney(services.WithAudit(ctx, entry), userID, *input.FromAccountID, recipient.AccountID, input.Amount, input.QuoteID)
+	recordQueuedAudit(ctx, h.auditService, entry, err)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) Reverse(w http.ResponseWriter, req *http.Request) {
+	h.compensate(w, req, models.AuditReversal, h.transactionService.ReverseTransaction)
+}
+
+func (h *TransactionHandler) Refund(w http.ResponseWriter, req *http.Request) {
+	h.compensate(w, req, models.AuditRefund, h.transactionService.RefundTransaction)
+}
+
+type compensateFunc func(ctx context.Context, actorID int64, transactionID int64, amount *money.Money) (*models.TransactionInfo, error)
+
+// compensate handles POST /transactions/{id}/reverse and /refund. The body is
+// optional; without an amount the whole remaining amount is sent back.
+func (h *TransactionHandler) compensate(w http.ResponseWriter, req *http.Request, action string, run compensateFunc) {
+	w.Header().Set("Content-Type", "application/json")
+
+	transactionID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		h.handleError(w, apperrors.ErrTransactionNotFound)
+		return
+	}
+
+	var input TransactionInput
+	if !decodeBody(w, req, &input) {
+		return
+	}
+
+	var amount *money.Money
+	if !input.Amount.IsZero() {
+		amount = &input.Amount
+	}
+
+	ctx, ok := h.withIdempotency(w, req, &input)
+	if !ok {
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(ctx)
+
+	entry := h.auditEntry(req, action, input.Amount, map[string]interface{}{"original_transaction_id": transactionID})
+	transaction, err := run(services.WithAudit(ctx, entry), actorID, transactionID, amount)
+	recordQueuedAudit(ctx, h.auditService, entry, err)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
 }
 
 func (h *TransactionHandler) GetTransactions(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	transactions, err := h.transactionService.GetTransactions()
+	filter, err := parseTransactionFilter(req)
 	if err != nil {
 		h.handleError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(transactions)
+
+	userID, _ := middleware.GetUserID(req.Context())
+	role, _ := middleware.GetUserRole(req.Context())
+
+	page, err := h.transactionService.GetTransactions(userID, role, filter)
+	if err != nil {
+		h.handleError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(page)
+}
+
+// parseTransactionFilter reads the GET /transactions query parameters:
+// user_id, counterparty_id, type, status, from, to, min_amount, max_amount,
+// order, limit and cursor.
+func parseTransactionFilter(req *http.Request) (models.TransactionFilter, error) {
+	query := req.URL.Query()
+	var filter models.TransactionFilter
+
+	for name, target := range map[string]**int64{"user_id": &filter.UserID, "counterparty_id": &filter.CounterpartyID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return filter, invalidParam(name)
+			}
+			*target = &id
+		}
+	}
+
+	if value := query.Get("type"); value != "" {
+		filter.Type = models.TransactionType(value)
+		if !filter.Type.Valid() {
+			return filter, invalidParam("type")
+		}
+	}
+
+	if value := query.Get("status"); value != "" {
+		filter.Status = models.TransactionStatus(value)
+		if !filter.Status.Valid() {
+			return filter, invalidParam("status")
+		}
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			t, err := parseTimeParam(value, name == "to")
+			if err != nil {
+				return filter, invalidParam(name)
+			}
+			*target = &t
+		}
+	}
+
+	if value := query.Get("currency"); value != "" {
+		filter.Currency = money.Currency(strings.ToUpper(value))
+		if !filter.Currency.Valid() {
+			return filter, invalidParam("currency")
+		}
+	}
+
+	// Amounts only compare within one currency; without a currency they are
+	// in the default one.
+	for name, target := range map[string]**money.Money{"min_amount": &filter.MinAmount, "max_amount": &filter.MaxAmount} {
+		if value := query.Get(name); value != "" {
+			if filter.Currency == "" {
+				filter.Currency = money.DefaultCurrency
+			}
+			amount, err := money.Parse(value, filter.Currency)
+			if err != nil {
+				return filter, invalidParam(name)
+			}
+			*target = &amount
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return filter, invalidParam("limit")
+		}
+		filter.Limit = limit
+	}
+
+	filter.Order = models.SortOrder(query.Get("order"))
+	filter.Cursor = query.Get("cursor")
+	return filter, nil
+}
+
+// parseTimeParam accepts RFC 3339 timestamps or plain dates. A plain date used
+// as an upper bound covers the whole day.
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func invalidParam(name string) error {
+	return apperrors.NewAppError(fmt.Sprintf("invalid %s parameter", name), http.StatusBadRequest)
 }
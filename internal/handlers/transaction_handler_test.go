@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransactionFilter(t *testing.T) {
+	id := func(v int64) *int64 { return &v }
+	at := func(s string) *time.Time {
+		parsed, _ := time.Parse(time.RFC3339, s)
+		return &parsed
+	}
+	amount := func(minor int64, currency money.Currency) *money.Money {
+		m := money.New(minor, currency)
+		return &m
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  models.TransactionFilter
+	}{
+		{"nothing", "", models.TransactionFilter{}},
+		{"ids", "user_id=7&counterparty_id=8", models.TransactionFilter{UserID: id(7), CounterpartyID: id(8)}},
+		{"type and status", "type=refund&status=completed", models.TransactionFilter{Type: models.Refund, Status: models.TransactionCompleted}},
+		{"plain dates", "from=2025-06-01&to=2025-06-30",
+			models.TransactionFilter{From: at("2025-06-01T00:00:00Z"), To: at("2025-07-01T00:00:00Z")}},
+		{"timestamps", "from=2025-06-01T08:00:00Z&to=2025-06-01T17:00:00Z",
+			models.TransactionFilter{From: at("2025-06-01T08:00:00Z"), To: at("2025-06-01T17:00:00Z")}},
+		{"amounts default to USD", "min_amount=10.00&max_amount=25.5",
+			models.TransactionFilter{Currency: money.USD, MinAmount: amount(1000, money.USD), MaxAmount: amount(2550, money.USD)}},
+		{"amounts in the given currency", "currency=eur&min_amount=10.50",
+			models.TransactionFilter{Currency: money.EUR, MinAmount: amount(1050, money.EUR)}},
+		{"amounts in a currency without minor units", "currency=JPY&max_amount=1500",
+			models.TransactionFilter{Currency: money.JPY, MaxAmount: amount(1500, money.JPY)}},
+		{"currency alone", "currency=GBP", models.TransactionFilter{Currency: money.GBP}},
+		{"paging", "order=asc&limit=20&cursor=abc", models.TransactionFilter{Order: models.SortAsc, Limit: 20, Cursor: "abc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := parseTransactionFilter(httptest.NewRequest("GET", "/transactions?"+tt.query, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, filter)
+		})
+	}
+}
+
+func TestParseTransactionFilter_RejectsInvalidParameters(t *testing.T) {
+	tests := []struct {
+		query string
+		param string
+	}{
+		{"user_id=me", "user_id"},
+		{"counterparty_id=1.5", "counterparty_id"},
+		{"type=gift", "type"},
+		{"status=lost", "status"},
+		{"from=yesterday", "from"},
+		{"to=2025-13-01", "to"},
+		{"currency=XYZ", "currency"},
+		{"min_amount=ten", "min_amount"},
+		{"max_amount=10.001", "max_amount"},
+		{"currency=JPY&min_amount=10.5", "min_amount"},
+		{"limit=0", "limit"},
+		{"limit=many", "limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := parseTransactionFilter(httptest.NewRequest("GET", "/transactions?"+tt.query, nil))
+			assert.Equal(t, invalidParam(tt.param), err)
+		})
+	}
+}
@@ -1,36 +1,30 @@
 package handlers
 
 import (
+	"MockBankGo/auth"
 	"MockBankGo/internal/apperrors"
 	"MockBankGo/internal/models"
 	"MockBankGo/internal/services"
 	"MockBankGo/middleware"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/gorilla/mux"
 	"github.com/jmoiron/sqlx"
 )
 
 type UserHandler struct {
-	database    *sqlx.DB
-	userService *services.UserService
+	database         *sqlx.DB
+	userService      *services.UserService
+	accountService   *services.AccountService
+	overdraftService *services.OverdraftService
+	sessionService   *services.SessionService
+	auditService     *services.AuditService
 }
 
-func NewUserHandler(db *sqlx.DB, user_service *services.UserService) *UserHandler {
-	return &UserHandler{database: db, userService: user_service}
-}
-
-func (h *UserHandler) handleError(w http.ResponseWriter, err error) {
-	// handleError now sets its own Content-Type and returns JSON
-	w.Header().Set("Content-Type", "application/json")
-
-	if appErr, ok := err.(*apperrors.AppError); ok {
-		w.WriteHeader(appErr.StatusCode)
-		json.NewEncoder(w).Encode(map[string]string{"error": appErr.Message})
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Internal server error"})
-	}
+func NewUserHandler(db *sqlx.DB, user_service *services.UserService, account_service *services.AccountService, overdraft_service *services.OverdraftService, session_service *services.SessionService, audit_service *services.AuditService) *UserHandler {
+	return &UserHandler{database: db, userService: user_service, accountService: account_service, overdraftService: overdraft_service, sessionService: session_service, auditService: audit_service}
 }
 
 func (h *UserHandler) Signup(w http.ResponseWriter, req *http.Request) {
@@ -43,8 +37,17 @@ func (h *UserHandler) Signup(w http.ResponseWriter, req *http.Request) {
 	}
 
 	err := h.userService.CreateUser(&NewUser)
+
+	entry := newAuditEntry(req, models.AuditSignup)
+	entry.TargetType = models.AuditTargetUser
+	entry.Details = auditDetails(map[string]interface{}{"username": NewUser.Username, "email": NewUser.Email})
+	if err == nil {
+		entry.ActorID, entry.TargetID = &NewUser.ID, &NewUser.ID
+	}
+	recordAudit(h.auditService, entry, err)
+
 	if err != nil {
-		h.handleError(w, err)
+		writeError(w, err)
 		return
 	}
 
@@ -61,15 +64,78 @@ func (h *UserHandler) Login(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	token, err := h.userService.LoginUser(logUser.Email, logUser.Password)
+	entry := newAuditEntry(req, models.AuditLogin)
+	entry.Details = auditDetails(map[string]interface{}{"email": logUser.Email})
+
+	user, err := h.userService.LoginUser(logUser.Email, logUser.Password)
 	if err != nil {
-		h.handleError(w, err)
+		recordAudit(h.auditService, entry, err)
+		writeError(w, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"token": token,
-	})
+	entry.ActorID = &user.ID
+	tokens, err := h.sessionService.StartSession(user)
+	recordAudit(h.auditService, entry, err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func (h *UserHandler) Refresh(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(input.RefreshToken)
+	if err == apperrors.ErrRefreshTokenReused {
+		recordAudit(h.auditService, newAuditEntry(req, models.AuditRefreshReuse), err)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func (h *UserHandler) Logout(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+	token, _ := middleware.GetToken(req.Context())
+
+	err := h.sessionService.Logout(userID, token)
+	recordAudit(h.auditService, newAuditEntry(req, models.AuditLogout), err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte("Logged out"))
+}
+
+func (h *UserHandler) LogoutAll(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, _ := middleware.GetUserID(req.Context())
+	token, _ := middleware.GetToken(req.Context())
+
+	err := h.sessionService.LogoutAll(userID, token)
+	recordAudit(h.auditService, newAuditEntry(req, models.AuditLogoutAll), err)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Write([]byte("Logged out of all sessions"))
 }
 
 func (h *UserHandler) GetUsers(w http.ResponseWriter, req *http.Request) {
@@ -77,7 +143,7 @@ func (h *UserHandler) GetUsers(w http.ResponseWriter, req *http.Request) {
 
 	users, err := h.userService.GetAllUsers()
 	if err != nil {
-		h.handleError(w, err)
+		writeError(w, err)
 		return
 	}
 	json.NewEncoder(w).Encode(users)
@@ -89,21 +155,72 @@ func (h *UserHandler) Profile(w http.ResponseWriter, req *http.Request) {
 
 	userProfile, err := h.userService.GetUserProfile(userID)
 	if err != nil {
-		h.handleError(w, err)
+		writeError(w, err)
+		return
+	}
+
+	accounts, err := h.accountService.ListAccounts(userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	overdrafts, err := h.overdraftService.ListOverdrafts(userID)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	response := struct {
-		Username string  `json:"username"`
-		Name     string  `json:"name"`
-		Email    string  `json:"email"`
-		Balance  float64 `json:"balance"`
+		Username   string             `json:"username"`
+		Name       string             `json:"name"`
+		Email      string             `json:"email"`
+		Accounts   []models.Account   `json:"accounts"`
+		Overdrafts []models.Overdraft `json:"overdrafts"`
 	}{
-		Username: userProfile.Username,
-		Name:     userProfile.Name,
-		Email:    userProfile.Email,
-		Balance:  userProfile.Balance,
+		Username:   userProfile.Username,
+		Name:       userProfile.Name,
+		Email:      userProfile.Email,
+		Accounts:   accounts,
+		Overdrafts: overdrafts,
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	targetID, err := strconv.ParseInt(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		writeError(w, apperrors.ErrUserNotFound)
+		return
+	}
+
+	var input struct {
+		Role auth.Role `json:"role"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(req.Context())
+
+	err = h.userService.ChangeRole(actorID, targetID, input.Role)
+
+	entry := newAuditEntry(req, models.AuditRoleChange)
+	entry.TargetType, entry.TargetID = models.AuditTargetUser, &targetID
+	entry.Details = auditDetails(map[string]interface{}{"role": input.Role})
+	recordAudit(h.auditService, entry, err)
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":   targetID,
+		"role": input.Role,
+	})
+}
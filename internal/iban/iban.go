@@ -0,0 +1,181 @@
+// Package iban generates and validates International Bank Account Numbers
+// (ISO 13616): a country code, two mod-97 check digits (ISO 7064) and the
+// country's basic bank account number (BBAN).
+package iban
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	minLength = 15
+	maxLength = 34
+)
+
+// DefaultCountry and DefaultBankCode are what accounts' IBANs are issued
+// under unless configured otherwise.
+const (
+	DefaultCountry  = "DE"
+	DefaultBankCode = "12345678"
+)
+
+// lengths holds the IBAN length of countries whose format is checked. An
+// IBAN from any other country only has to be 15 to 34 characters long.
+var lengths = map[string]int{
+	"AT": 20,
+	"BE": 16,
+	"CH": 21,
+	"DE": 22,
+	"DK": 18,
+	"ES": 24,
+	"FI": 18,
+	"FR": 27,
+	"GB": 22,
+	"IE": 22,
+	"IT": 27,
+	"KZ": 20,
+	"LU": 20,
+	"NL": 18,
+	"NO": 15,
+	"PL": 28,
+	"PT": 25,
+	"SE": 24,
+}
+
+var (
+	ErrInvalidFormat      = errors.New("IBAN must be a country code, two check digits and up to 30 letters and digits")
+	ErrInvalidLength      = errors.New("IBAN has the wrong length for its country")
+	ErrInvalidChecksum    = errors.New("IBAN check digits do not match")
+	ErrInvalidCountry     = errors.New("country code must be two letters")
+	ErrInvalidBankCode    = errors.New("bank code must be letters and digits that leave room for an account number")
+	ErrAccountNumberRange = errors.New("account number does not fit in the IBAN")
+)
+
+func isUpperLetters(s string) bool {
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize removes spaces from an IBAN and upper-cases it, so one written
+// in groups of four can be compared and stored.
+func Normalize(s string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// mod97 returns s, upper-case letters and digits, taken as a number with
+// each letter replaced by two digits (A is 10, Z is 35), modulo 97.
+func mod97(s string) int {
+	remainder := 0
+	for _, c := range s {
+		if c >= 'A' && c <= 'Z' {
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(c-'0')) % 97
+		}
+	}
+	return remainder
+}
+
+// Parse normalizes s and checks that it is a well-formed IBAN with correct
+// check digits.
+func Parse(s string) (string, error) {
+	iban := Normalize(s)
+	if len(iban) < minLength || len(iban) > maxLength || !isAlphanumeric(iban) {
+		return "", ErrInvalidFormat
+	}
+	if !isUpperLetters(iban[:2]) || !isDigits(iban[2:4]) {
+		return "", ErrInvalidFormat
+	}
+	if length, ok := lengths[iban[:2]]; ok && len(iban) != length {
+		return "", ErrInvalidLength
+	}
+	if mod97(iban[4:]+iban[:4]) != 1 {
+		return "", ErrInvalidChecksum
+	}
+	return iban, nil
+}
+
+// Generate returns the IBAN of bban in country, with its check digits.
+func Generate(country string, bban string) (string, error) {
+	if len(country) != 2 || !isUpperLetters(country) {
+		return "", ErrInvalidCountry
+	}
+	if !isAlphanumeric(bban) {
+		return "", ErrInvalidFormat
+	}
+	check := 98 - mod97(bban+country+"00")
+	iban := country + string(rune('0'+check/10)) + string(rune('0'+check%10)) + bban
+	return Parse(iban)
+}
+
+// Format writes an IBAN in groups of four, the way it is printed.
+func Format(iban string) string {
+	var groups []string
+	for len(iban) > 4 {
+		groups = append(groups, iban[:4])
+		iban = iban[4:]
+	}
+	return strings.Join(append(groups, iban), " ")
+}
+
+// Issuer gives accounts IBANs in one country under one bank code.
+type Issuer struct {
+	Country  string
+	BankCode string
+}
+
+// NewIssuer checks that IBANs can be issued in country under bankCode.
+func NewIssuer(country string, bankCode string) (Issuer, error) {
+	issuer := Issuer{Country: strings.ToUpper(country), BankCode: strings.ToUpper(bankCode)}
+	if len(issuer.Country) != 2 || !isUpperLetters(issuer.Country) {
+		return Issuer{}, ErrInvalidCountry
+	}
+	if issuer.BankCode == "" || !isAlphanumeric(issuer.BankCode) {
+		return Issuer{}, ErrInvalidBankCode
+	}
+	if _, err := issuer.IBAN("1"); err != nil {
+		return Issuer{}, ErrInvalidBankCode
+	}
+	return issuer, nil
+}
+
+// IBAN returns the IBAN of an account number: the bank code followed by the
+// account number, zero-padded to fill the country's length where it is
+// known.
+func (i Issuer) IBAN(accountNumber string) (string, error) {
+	if accountNumber == "" || !isDigits(accountNumber) {
+		return "", ErrAccountNumberRange
+	}
+	if length, ok := lengths[i.Country]; ok {
+		width := length - 4 - len(i.BankCode)
+		accountNumber = strings.TrimLeft(accountNumber, "0")
+		if width < 1 || len(accountNumber) > width {
+			return "", ErrAccountNumberRange
+		}
+		accountNumber = strings.Repeat("0", width-len(accountNumber)) + accountNumber
+	}
+	return Generate(i.Country, i.BankCode+accountNumber)
+}
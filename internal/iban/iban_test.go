@@ -0,0 +1,59 @@
+package iban
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	iban, err := Parse("gb82 west 1234 5698 7654 32")
+	require.NoError(t, err)
+	assert.Equal(t, "GB82WEST12345698765432", iban)
+
+	iban, err = Parse("DE89370400440532013000")
+	require.NoError(t, err)
+	assert.Equal(t, "DE89370400440532013000", iban)
+
+	_, err = Parse("DE88370400440532013000")
+	assert.ErrorIs(t, err, ErrInvalidChecksum)
+	_, err = Parse("DE8937040044053201300")
+	assert.ErrorIs(t, err, ErrInvalidLength)
+	for _, bad := range []string{"", "DE89", "1289370400440532013000", "DEXX370400440532013000", "DE89-3704-0044-0532-0130-00"} {
+		_, err := Parse(bad)
+		assert.ErrorIs(t, err, ErrInvalidFormat, bad)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	iban, err := Generate("GB", "WEST12345698765432")
+	require.NoError(t, err)
+	assert.Equal(t, "GB82WEST12345698765432", iban)
+
+	_, err = Generate("G1", "WEST12345698765432")
+	assert.ErrorIs(t, err, ErrInvalidCountry)
+}
+
+func TestIssuer_PadsAccountNumber(t *testing.T) {
+	issuer, err := NewIssuer("de", "37040044")
+	require.NoError(t, err)
+
+	iban, err := issuer.IBAN("000532013000")
+	require.NoError(t, err)
+	assert.Equal(t, "DE89370400440532013000", iban)
+
+	_, err = issuer.IBAN("12345678901")
+	assert.ErrorIs(t, err, ErrAccountNumberRange)
+}
+
+func TestNewIssuer_RejectsBankCodeWithoutRoom(t *testing.T) {
+	_, err := NewIssuer("DE", "370400440532013000")
+	assert.ErrorIs(t, err, ErrInvalidBankCode)
+	_, err = NewIssuer("DEU", "37040044")
+	assert.ErrorIs(t, err, ErrInvalidCountry)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "DE89 3704 0044 0532 0130 00", Format("DE89370400440532013000"))
+}
@@ -0,0 +1,76 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Func is one run of a background job. now is the time the run was triggered.
+type Func func(ctx context.Context, now time.Time) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+}
+
+// Scheduler runs registered jobs in-process, each on its own ticker. Jobs
+// must be safe to run on several instances at once; the scheduler itself
+// does no cross-process coordination.
+type Scheduler struct {
+	jobs []job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers fn to run once at start-up and then every interval.
+func (s *Scheduler) Every(name string, interval time.Duration, fn Func) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, run: fn})
+}
+
+// Start launches all jobs and returns immediately. They stop when ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Wait blocks until every job loop has exited.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	s.runOnce(ctx, j, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runOnce(ctx, j, now)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, j job, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("job %s panicked: %v", j.name, r)
+		}
+	}()
+
+	if err := j.run(ctx, now); err != nil {
+		log.Printf("job %s failed: %v", j.name, err)
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+type AccountType string
+
+const (
+	Checking AccountType = "checking"
+	Savings  AccountType = "savings"
+)
+
+type AccountStatus string
+
+const (
+	AccountOpen   AccountStatus = "open"
+	AccountClosed AccountStatus = "closed"
+)
+
+// Account is a customer account. Balance is the ledger balance; Held is the
+// part of it reserved by active holds and Available what can still be spent.
+// IBAN is only missing on an account opened before IBANs were issued, until
+// the next start-up.
+type Account struct {
+	ID        int64          `db:"id" json:"id"`
+	UserID    int64          `db:"user_id" json:"user_id"`
+	Number    string         `db:"number" json:"number"`
+	IBAN      *string        `db:"iban" json:"iban,omitempty"`
+	Type      AccountType    `db:"type" json:"type"`
+	Currency  money.Currency `db:"currency" json:"currency"`
+	Balance   money.Money    `db:"balance" json:"balance"`
+	Held      money.Money    `db:"held" json:"held"`
+	Available money.Money    `db:"-" json:"available"`
+	Status    AccountStatus  `db:"status" json:"status"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	ClosedAt  *time.Time     `db:"closed_at" json:"closed_at,omitempty"`
+}
+
+func (t AccountType) Valid() bool {
+	return t == Checking || t == Savings
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"encoding/json"
+	"time"
+)
+
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// Audited actions.
+const (
+	AuditSignup       = "user.signup"
+	AuditRoleChange   = "user.role_change"
+	AuditLogin        = "auth.login"
+	AuditLogout       = "auth.logout"
+	AuditLogoutAll    = "auth.logout_all"
+	AuditRefreshReuse = "auth.refresh_reuse"
+	AuditDeposit      = "money.deposit"
+	AuditWithdraw     = "money.withdraw"
+	AuditTransfer     = "money.transfer"
+	AuditReversal     = "money.reversal"
+	AuditRefund       = "money.refund"
+	AuditHold         = "money.hold"
+	AuditHoldCapture  = "money.hold_capture"
+	AuditHoldRelease  = "money.hold_release"
+	AuditLoanApprove  = "money.loan_approve"
+	AuditLoanReject   = "money.loan_reject"
+	AuditLoanRepay    = "money.loan_repay"
+	AuditTermDeposit  = "money.term_deposit"
+	AuditTermBreak    = "money.term_deposit_break"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser          = "user"
+	AuditTargetTransaction   = "transaction"
+	AuditTargetStandingOrder = "standing_order"
+	AuditTargetHold          = "hold"
+	AuditTargetLoan          = "loan"
+	AuditTargetTermDeposit   = "term_deposit"
+)
+
+// AuditEntry is one row of the append-only audit trail. Hash covers every
+// other field plus PrevHash, the hash of the entry before it, so editing or
+// deleting any row breaks the chain from that point on.
+type AuditEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	Action     string          `db:"action" json:"action"`
+	Outcome    AuditOutcome    `db:"outcome" json:"outcome"`
+	ActorID    *int64          `db:"actor_id" json:"actor_id,omitempty"`
+	TargetType string          `db:"target_type" json:"target_type,omitempty"`
+	TargetID   *int64          `db:"target_id" json:"target_id,omitempty"`
+	Amount     *money.Money    `db:"amount" json:"amount,omitempty"`
+	Currency   *money.Currency `db:"currency" json:"-"`
+	Error      string          `db:"error" json:"error,omitempty"`
+	Details    json.RawMessage `db:"details" json:"details"`
+	IP         string          `db:"ip" json:"ip,omitempty"`
+	UserAgent  string          `db:"user_agent" json:"user_agent,omitempty"`
+	RequestID  string          `db:"request_id" json:"request_id,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	PrevHash   string          `db:"prev_hash" json:"prev_hash"`
+	Hash       string          `db:"hash" json:"hash"`
+}
+
+type AuditFilter struct {
+	ActorID    *int64
+	Action     string
+	TargetType string
+	TargetID   *int64
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Cursor     string
+	BeforeID   int64
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// AuditVerification is the result of re-computing the hash chain.
+type AuditVerification struct {
+	Valid    bool  `json:"valid"`
+	Entries  int64 `json:"entries"`
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
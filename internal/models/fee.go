@@ -0,0 +1,126 @@
+package models
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/money"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// FeeType is what a fee is charged for. Withdrawals and transfers are
+// charged by their transaction type; a transfer between currencies is also
+// charged the FX fee.
+type FeeType string
+
+const (
+	FeeWithdraw FeeType = "withdraw"
+	FeeTransfer FeeType = "transfer"
+	FeeFX       FeeType = "fx"
+
+	// FeeLatePayment is charged on an overdue loan installment. It comes
+	// from the loan, not from a fee rule.
+	FeeLatePayment FeeType = "late_payment"
+
+	// FeeEarlyWithdrawal is the penalty for breaking a term deposit early.
+	// It comes from the deposit's product, not from a fee rule.
+	FeeEarlyWithdrawal FeeType = "early_withdrawal"
+)
+
+func (t FeeType) Valid() bool {
+	switch t {
+	case FeeWithdraw, FeeTransfer, FeeFX:
+		return true
+	}
+	return false
+}
+
+type FeeKind string
+
+const (
+	FeeFlat       FeeKind = "flat"
+	FeePercentage FeeKind = "percentage"
+	FeeTiered     FeeKind = "tiered"
+)
+
+func (k FeeKind) Valid() bool {
+	switch k {
+	case FeeFlat, FeePercentage, FeeTiered:
+		return true
+	}
+	return false
+}
+
+// FeeTier charges Flat plus Bps basis points on amounts up to and including
+// UpTo. The last tier has no UpTo and covers everything above.
+type FeeTier struct {
+	UpTo *money.Money `json:"up_to,omitempty"`
+	Flat *money.Money `json:"flat,omitempty"`
+	Bps  int          `json:"bps,omitempty"`
+}
+
+// FeeTiers is stored as JSON text.
+type FeeTiers []FeeTier
+
+func (t FeeTiers) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+func (t *FeeTiers) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	}
+	return fmt.Errorf("cannot scan %T into FeeTiers", src)
+}
+
+// FeeRule prices one fee type in one currency, for one role or, with no
+// role, for everyone. A role rule overrides the rule for everyone. The fee
+// is then raised to MinFee or lowered to MaxFee when those are set.
+type FeeRule struct {
+	ID        int64          `db:"id" json:"id"`
+	Type      FeeType        `db:"fee_type" json:"type"`
+	Role      *auth.Role     `db:"role" json:"role,omitempty"`
+	Currency  money.Currency `db:"currency" json:"currency"`
+	Kind      FeeKind        `db:"kind" json:"kind"`
+	Flat      *money.Money   `db:"flat" json:"flat,omitempty"`
+	Bps       *int           `db:"bps" json:"bps,omitempty"`
+	Tiers     FeeTiers       `db:"tiers" json:"tiers,omitempty"`
+	MinFee    *money.Money   `db:"min_fee" json:"min_fee,omitempty"`
+	MaxFee    *money.Money   `db:"max_fee" json:"max_fee,omitempty"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// TransactionFee is one fee charged on a transaction. It is paid from the
+// debited account, in that account's currency.
+type TransactionFee struct {
+	ID            int64          `db:"id" json:"-"`
+	TransactionID int64          `db:"transaction_id" json:"-"`
+	RuleID        *int64         `db:"rule_id" json:"rule_id,omitempty"`
+	Type          FeeType        `db:"fee_type" json:"type"`
+	Amount        money.Money    `db:"amount" json:"amount"`
+	Currency      money.Currency `db:"currency" json:"-"`
+}
+
+// FeePreview is what an operation would cost before it is made.
+type FeePreview struct {
+	Operation  TransactionType  `json:"operation"`
+	Amount     money.Money      `json:"amount"`
+	Fees       []TransactionFee `json:"fees"`
+	TotalFee   money.Money      `json:"total_fee"`
+	TotalDebit money.Money      `json:"total_debit"`
+}
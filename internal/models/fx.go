@@ -0,0 +1,43 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// Where a rate came from.
+const (
+	FXSourceFile  = "file"
+	FXSourceAdmin = "admin"
+)
+
+// FXRate is a mid-market rate: one major unit of Base costs Rate units of
+// Quote. Rate is kept as a decimal string so it never passes through a float.
+type FXRate struct {
+	ID          int64          `db:"id" json:"id"`
+	Base        money.Currency `db:"base" json:"base"`
+	Quote       money.Currency `db:"quote" json:"quote"`
+	Rate        string         `db:"rate" json:"rate"`
+	SpreadBps   int            `db:"spread_bps" json:"spread_bps"`
+	Source      string         `db:"source" json:"source"`
+	EffectiveAt time.Time      `db:"effective_at" json:"effective_at"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+// FXQuote fixes the result of a conversion until ExpiresAt. A transfer that
+// presents the quote's ID gets exactly ToAmount, once.
+type FXQuote struct {
+	ID              string         `db:"id" json:"id"`
+	UserID          int64          `db:"user_id" json:"-"`
+	FromAmount      money.Money    `db:"from_amount" json:"from_amount"`
+	FromCurrency    money.Currency `db:"from_currency" json:"-"`
+	ToAmount        money.Money    `db:"to_amount" json:"to_amount"`
+	ToCurrency      money.Currency `db:"to_currency" json:"-"`
+	Rate            string         `db:"rate" json:"rate"`
+	MidRate         string         `db:"mid_rate" json:"mid_rate"`
+	SpreadBps       int            `db:"spread_bps" json:"spread_bps"`
+	RateEffectiveAt time.Time      `db:"rate_effective_at" json:"rate_effective_at"`
+	ExpiresAt       time.Time      `db:"expires_at" json:"expires_at"`
+	UsedAt          *time.Time     `db:"used_at" json:"used_at,omitempty"`
+	CreatedAt       time.Time      `db:"created_at" json:"created_at"`
+}
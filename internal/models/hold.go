@@ -0,0 +1,35 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+	HoldExpired  HoldStatus = "expired"
+)
+
+// Hold reserves Amount on an account without moving it, lowering the
+// account's available balance until the hold ends. The owner of ToAccountID
+// may capture it, for all or part of Amount, as a transfer, or release it;
+// otherwise it expires at ExpiresAt.
+type Hold struct {
+	ID             int64          `db:"id" json:"id"`
+	UserID         int64          `db:"user_id" json:"user_id"`
+	AccountID      int64          `db:"account_id" json:"account_id"`
+	ToAccountID    int64          `db:"to_account_id" json:"to_account_id"`
+	Amount         money.Money    `db:"amount" json:"amount"`
+	Currency       money.Currency `db:"currency" json:"-"`
+	CapturedAmount *money.Money   `db:"captured_amount" json:"captured_amount,omitempty"`
+	TransactionID  *int64         `db:"transaction_id" json:"transaction_id,omitempty"`
+	Description    string         `db:"description" json:"description"`
+	Status         HoldStatus     `db:"status" json:"status"`
+	ExpiresAt      time.Time      `db:"expires_at" json:"expires_at"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	ClosedAt       *time.Time     `db:"closed_at" json:"closed_at,omitempty"`
+}
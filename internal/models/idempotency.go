@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the response to a money-moving request so a
+// retry with the same Idempotency-Key replays it instead of running again.
+type IdempotencyRecord struct {
+	UserID       int64     `db:"user_id"`
+	Key          string    `db:"key"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   int       `db:"status_code"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// DayCount is the convention that decides what share of a yearly rate one
+// day earns.
+type DayCount string
+
+const (
+	DayCountActual365    DayCount = "act/365"
+	DayCountActual360    DayCount = "act/360"
+	DayCountActualActual DayCount = "act/act"
+	DayCount30360        DayCount = "30/360"
+)
+
+func (d DayCount) Valid() bool {
+	switch d {
+	case DayCountActual365, DayCountActual360, DayCountActualActual, DayCount30360:
+		return true
+	}
+	return false
+}
+
+// Compounding is how often accrued interest starts earning interest itself.
+type Compounding string
+
+const (
+	CompoundingNone    Compounding = "none"
+	CompoundingDaily   Compounding = "daily"
+	CompoundingMonthly Compounding = "monthly"
+)
+
+func (c Compounding) Valid() bool {
+	switch c {
+	case CompoundingNone, CompoundingDaily, CompoundingMonthly:
+		return true
+	}
+	return false
+}
+
+// PayoutFrequency is how often accrued interest is paid into the account.
+type PayoutFrequency string
+
+const (
+	PayoutMonthly   PayoutFrequency = "monthly"
+	PayoutQuarterly PayoutFrequency = "quarterly"
+	PayoutAnnually  PayoutFrequency = "annually"
+)
+
+func (p PayoutFrequency) Valid() bool {
+	switch p {
+	case PayoutMonthly, PayoutQuarterly, PayoutAnnually:
+		return true
+	}
+	return false
+}
+
+// InterestProduct pays RateBps a year on the balance of accounts of one
+// currency and type.
+type InterestProduct struct {
+	ID          int64           `db:"id" json:"id"`
+	Name        string          `db:"name" json:"name"`
+	Currency    money.Currency  `db:"currency" json:"currency"`
+	AccountType AccountType     `db:"account_type" json:"account_type"`
+	RateBps     int             `db:"rate_bps" json:"rate_bps"`
+	DayCount    DayCount        `db:"day_count" json:"day_count"`
+	Compounding Compounding     `db:"compounding" json:"compounding"`
+	Payout      PayoutFrequency `db:"payout" json:"payout"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// InterestAttachment attaches a product to one account, or to every account
+// of a user in the product's currency and account type.
+type InterestAttachment struct {
+	ID          int64          `db:"id" json:"id"`
+	ProductID   int64          `db:"product_id" json:"product_id"`
+	UserID      *int64         `db:"user_id" json:"user_id,omitempty"`
+	AccountID   *int64         `db:"account_id" json:"account_id,omitempty"`
+	Currency    money.Currency `db:"currency" json:"currency"`
+	AccountType AccountType    `db:"account_type" json:"account_type"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// InterestEarner is an open account with the product it earns interest
+// under and when that product was attached.
+type InterestEarner struct {
+	AccountID  int64     `db:"account_id"`
+	ProductID  int64     `db:"product_id"`
+	AttachedAt time.Time `db:"attached_at"`
+}
+
+// InterestAccrual is interest an account has earned but not been paid, in
+// minor units kept as an exact decimal. Compounded is the part of it that
+// earns interest itself.
+type InterestAccrual struct {
+	AccountID      int64      `db:"account_id"`
+	Accrued        string     `db:"accrued"`
+	Compounded     string     `db:"compounded"`
+	AccruedThrough *time.Time `db:"accrued_through"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// InterestAccrualDay is what one day earned. Base and Amount are minor units
+// kept as exact decimals. TransactionID is the payout made at the end of the
+// day, if any.
+type InterestAccrualDay struct {
+	AccountID     int64     `db:"account_id" json:"-"`
+	Day           time.Time `db:"day" json:"day"`
+	ProductID     int64     `db:"product_id" json:"product_id"`
+	Base          string    `db:"base" json:"base"`
+	RateBps       int       `db:"rate_bps" json:"rate_bps"`
+	Amount        string    `db:"amount" json:"amount"`
+	TransactionID *int64    `db:"transaction_id" json:"transaction_id,omitempty"`
+}
+
+// AccountInterest is what an account earns and has earned so far. Accrued is
+// rounded down to the minor unit; AccruedExact keeps the fractions.
+type AccountInterest struct {
+	AccountID      int64           `json:"account_id"`
+	Product        InterestProduct `json:"product"`
+	Accrued        money.Money     `json:"accrued"`
+	AccruedExact   string          `json:"accrued_exact"`
+	AccruedThrough *time.Time      `json:"accrued_through,omitempty"`
+	NextPayout     time.Time       `json:"next_payout"`
+}
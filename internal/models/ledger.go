@@ -0,0 +1,76 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+type LedgerAccountType string
+
+const (
+	LedgerAsset     LedgerAccountType = "asset"
+	LedgerLiability LedgerAccountType = "liability"
+	LedgerEquity    LedgerAccountType = "equity"
+	LedgerRevenue   LedgerAccountType = "revenue"
+	LedgerExpense   LedgerAccountType = "expense"
+)
+
+// Well-known system ledger accounts. Migrations seed the USD ones; the
+// accounts for other currencies are created on first use.
+const (
+	LedgerCashCode            = "cash"
+	LedgerFeeIncomeCode       = "fee_income"
+	LedgerOpeningEquityCode   = "opening_balance_equity"
+	LedgerFXPositionCode      = "fx_position"
+	LedgerInterestIncomeCode  = "interest_income"
+	LedgerInterestExpenseCode = "interest_expense"
+	LedgerLoansReceivableCode = "loans_receivable"
+)
+
+// SystemLedgerTypes gives the account type of every system ledger account.
+var SystemLedgerTypes = map[string]LedgerAccountType{
+	LedgerCashCode:            LedgerAsset,
+	LedgerFeeIncomeCode:       LedgerRevenue,
+	LedgerOpeningEquityCode:   LedgerEquity,
+	LedgerFXPositionCode:      LedgerEquity,
+	LedgerInterestIncomeCode:  LedgerRevenue,
+	LedgerInterestExpenseCode: LedgerExpense,
+	LedgerLoansReceivableCode: LedgerAsset,
+}
+
+// SystemLedgerCode returns the code of a system account in a currency, e.g.
+// "cash:EUR". USD accounts keep the bare codes they were seeded with.
+func SystemLedgerCode(code string, currency money.Currency) string {
+	if currency == money.DefaultCurrency {
+		return code
+	}
+	return code + ":" + string(currency)
+}
+
+type LedgerAccount struct {
+	ID        int64             `db:"id" json:"id"`
+	Code      string            `db:"code" json:"code"`
+	Type      LedgerAccountType `db:"type" json:"type"`
+	UserID    *int64            `db:"user_id" json:"user_id,omitempty"`
+	AccountID *int64            `db:"account_id" json:"account_id,omitempty"`
+	Currency  money.Currency    `db:"currency" json:"currency"`
+	CreatedAt time.Time         `db:"created_at" json:"created_at"`
+}
+
+// LedgerEntry is one leg of a journal. Positive amounts are debits,
+// negative amounts are credits; the legs of a journal always sum to zero.
+type LedgerEntry struct {
+	ID        int64       `db:"id" json:"id"`
+	JournalID int64       `db:"journal_id" json:"journal_id"`
+	AccountID int64       `db:"account_id" json:"account_id"`
+	Amount    money.Money `db:"amount" json:"amount"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+}
+
+func Debit(accountID int64, amount money.Money) LedgerEntry {
+	return LedgerEntry{AccountID: accountID, Amount: amount}
+}
+
+func Credit(accountID int64, amount money.Money) LedgerEntry {
+	return LedgerEntry{AccountID: accountID, Amount: amount.Neg()}
+}
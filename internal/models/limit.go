@@ -0,0 +1,85 @@
+package models
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/money"
+	"time"
+)
+
+type LimitScope string
+
+const (
+	LimitGlobal LimitScope = "global"
+	LimitRole   LimitScope = "role"
+	LimitUser   LimitScope = "user"
+)
+
+func (s LimitScope) Valid() bool {
+	switch s {
+	case LimitGlobal, LimitRole, LimitUser:
+		return true
+	}
+	return false
+}
+
+// Specificity orders scopes so a user limit overrides a role limit, which
+// overrides a global one.
+func (s LimitScope) Specificity() int {
+	switch s {
+	case LimitUser:
+		return 2
+	case LimitRole:
+		return 1
+	}
+	return 0
+}
+
+type LimitKind string
+
+const (
+	LimitPerTransaction LimitKind = "per_transaction"
+	LimitDailyAmount    LimitKind = "daily_amount"
+	LimitMonthlyAmount  LimitKind = "monthly_amount"
+	LimitDailyCount     LimitKind = "daily_count"
+)
+
+func (k LimitKind) Valid() bool {
+	switch k {
+	case LimitPerTransaction, LimitDailyAmount, LimitMonthlyAmount, LimitDailyCount:
+		return true
+	}
+	return false
+}
+
+// IsCount reports whether the limit's value is a number of transactions
+// rather than an amount of money.
+func (k LimitKind) IsCount() bool {
+	return k == LimitDailyCount
+}
+
+// LimitOperations are the transaction types a limit can apply to.
+var LimitOperations = []TransactionType{Deposit, Withdraw, Transfer}
+
+// TransactionLimit caps one kind of activity for an operation in one
+// currency. Value is in minor units, or a count for count limits; Amount or
+// Count presents it.
+type TransactionLimit struct {
+	ID        int64           `db:"id" json:"id"`
+	Scope     LimitScope      `db:"scope" json:"scope"`
+	Role      *auth.Role      `db:"role" json:"role,omitempty"`
+	UserID    *int64          `db:"user_id" json:"user_id,omitempty"`
+	Operation TransactionType `db:"operation" json:"operation"`
+	Kind      LimitKind       `db:"kind" json:"kind"`
+	Currency  money.Currency  `db:"currency" json:"currency"`
+	Value     int64           `db:"value" json:"-"`
+	Amount    *money.Money    `db:"-" json:"amount,omitempty"`
+	Count     *int64          `db:"-" json:"count,omitempty"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// LimitUsage is what a user has used of an operation in one currency on one day.
+type LimitUsage struct {
+	Amount int64 `db:"amount"`
+	Count  int64 `db:"count"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// LoanMethod is how a loan's principal is spread over its installments.
+// An annuity repays the same amount every month; equal principal repays the
+// same principal every month plus interest on what is left, so installments
+// shrink.
+type LoanMethod string
+
+const (
+	LoanAnnuity        LoanMethod = "annuity"
+	LoanEqualPrincipal LoanMethod = "equal_principal"
+)
+
+func (m LoanMethod) Valid() bool {
+	return m == LoanAnnuity || m == LoanEqualPrincipal
+}
+
+// LoanStatus is where a loan is in its life: applied for, turned down,
+// disbursed and being repaid, or fully repaid.
+type LoanStatus string
+
+const (
+	LoanPending  LoanStatus = "pending"
+	LoanRejected LoanStatus = "rejected"
+	LoanActive   LoanStatus = "active"
+	LoanRepaid   LoanStatus = "repaid"
+)
+
+func (s LoanStatus) Valid() bool {
+	switch s {
+	case LoanPending, LoanRejected, LoanActive, LoanRepaid:
+		return true
+	}
+	return false
+}
+
+// Loan is money lent to a user, paid into and repaid from AccountID.
+// RateBps is the yearly rate, set when the loan is approved. Outstanding is
+// the principal not yet repaid.
+type Loan struct {
+	ID                        int64          `db:"id" json:"id"`
+	UserID                    int64          `db:"user_id" json:"user_id"`
+	AccountID                 int64          `db:"account_id" json:"account_id"`
+	Currency                  money.Currency `db:"currency" json:"currency"`
+	Principal                 money.Money    `db:"principal" json:"principal"`
+	Outstanding               money.Money    `db:"outstanding" json:"outstanding"`
+	TermMonths                int            `db:"term_months" json:"term_months"`
+	Method                    LoanMethod     `db:"method" json:"method"`
+	RateBps                   *int           `db:"rate_bps" json:"rate_bps,omitempty"`
+	LateFee                   money.Money    `db:"late_fee" json:"late_fee"`
+	Status                    LoanStatus     `db:"status" json:"status"`
+	DecidedBy                 *int64         `db:"decided_by" json:"decided_by,omitempty"`
+	DecidedAt                 *time.Time     `db:"decided_at" json:"decided_at,omitempty"`
+	DisbursedAt               *time.Time     `db:"disbursed_at" json:"disbursed_at,omitempty"`
+	DisbursementTransactionID *int64         `db:"disbursement_transaction_id" json:"disbursement_transaction_id,omitempty"`
+	ClosedAt                  *time.Time     `db:"closed_at" json:"closed_at,omitempty"`
+	CreatedAt                 time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt                 time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+type InstallmentStatus string
+
+const (
+	InstallmentDue  InstallmentStatus = "due"
+	InstallmentPaid InstallmentStatus = "paid"
+)
+
+// LoanInstallment is one monthly repayment. It collects Principal and
+// Interest, plus LateFee once it is overdue.
+type LoanInstallment struct {
+	LoanID        int64             `db:"loan_id" json:"-"`
+	Seq           int               `db:"seq" json:"seq"`
+	DueDate       time.Time         `db:"due_date" json:"due_date"`
+	Principal     money.Money       `db:"principal" json:"principal"`
+	Interest      money.Money       `db:"interest" json:"interest"`
+	LateFee       money.Money       `db:"late_fee" json:"late_fee"`
+	Status        InstallmentStatus `db:"status" json:"status"`
+	LastAttemptOn *time.Time        `db:"last_attempt_on" json:"last_attempt_on,omitempty"`
+	TransactionID *int64            `db:"transaction_id" json:"transaction_id,omitempty"`
+	PaidAt        *time.Time        `db:"paid_at" json:"paid_at,omitempty"`
+	Currency      money.Currency    `db:"currency" json:"-"`
+}
+
+// Total is what the installment collects.
+func (i LoanInstallment) Total() money.Money {
+	return i.Principal.Add(i.Interest).Add(i.LateFee)
+}
+
+// LoanDetails is a loan with its repayment schedule.
+type LoanDetails struct {
+	*Loan
+	Schedule []LoanInstallment `json:"schedule"`
+}
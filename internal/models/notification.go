@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type NotificationKind string
+
+const (
+	NotificationOverdraftEntered NotificationKind = "overdraft_entered"
+	NotificationLoanOverdue      NotificationKind = "loan_overdue"
+)
+
+type Notification struct {
+	ID        int64            `db:"id" json:"id"`
+	UserID    int64            `db:"user_id" json:"-"`
+	Kind      NotificationKind `db:"kind" json:"kind"`
+	Message   string           `db:"message" json:"message"`
+	AccountID *int64           `db:"account_id" json:"account_id,omitempty"`
+	ReadAt    *time.Time       `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt time.Time        `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// Overdraft lets a user's checking accounts in one currency go below zero,
+// together by at most Limit. InterestBps is the yearly rate charged each day
+// on negative balances. Used is how far the available balances of those
+// accounts are below zero; interest can push it past Limit, so Remaining
+// never goes below zero.
+type Overdraft struct {
+	UserID      int64          `db:"user_id" json:"user_id"`
+	Currency    money.Currency `db:"currency" json:"currency"`
+	Limit       money.Money    `db:"limit_amount" json:"limit"`
+	InterestBps int            `db:"interest_bps" json:"interest_bps"`
+	Used        money.Money    `db:"used" json:"used"`
+	Remaining   money.Money    `db:"-" json:"remaining"`
+	LastDrawnAt *time.Time     `db:"last_drawn_at" json:"last_drawn_at,omitempty"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// Payee is a recipient a user has saved under a name of their choosing.
+// AccountNumber and DisplayName are those of the account it resolved to,
+// masked like a Recipient's before they are shown.
+type Payee struct {
+	ID            int64          `db:"id" json:"id"`
+	UserID        int64          `db:"user_id" json:"-"`
+	Name          string         `db:"name" json:"name"`
+	AccountID     int64          `db:"account_id" json:"-"`
+	AccountNumber string         `db:"account_number" json:"account_number"`
+	DisplayName   string         `db:"display_name" json:"display_name"`
+	Currency      money.Currency `db:"currency" json:"currency"`
+	CreatedAt     time.Time      `db:"created_at" json:"created_at"`
+}
+
+// RecipientQuery names who money is for in one of the ways a client may
+// know them. Exactly one field is set.
+type RecipientQuery struct {
+	AccountID     *int64
+	AccountNumber string
+	IBAN          string
+	Username      string
+	Email         string
+	PayeeID       *int64
+}
+
+// Recipient is the account a RecipientQuery resolved to, with no more of
+// its owner than the sender needs to confirm they have the right person.
+type Recipient struct {
+	AccountID     int64          `json:"-"`
+	AccountNumber string         `json:"account_number"`
+	DisplayName   string         `json:"display_name"`
+	Currency      money.Currency `json:"currency"`
+}
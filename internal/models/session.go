@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RefreshToken is one link in a login session's chain of refresh tokens.
+// AccessTokenID is the jti of the access token issued alongside it, so
+// revoking the session can revoke that access token too.
+type RefreshToken struct {
+	ID              int64      `db:"id"`
+	UserID          int64      `db:"user_id"`
+	FamilyID        string     `db:"family_id"`
+	TokenHash       string     `db:"token_hash"`
+	AccessTokenID   string     `db:"access_token_id"`
+	AccessExpiresAt time.Time  `db:"access_expires_at"`
+	ExpiresAt       time.Time  `db:"expires_at"`
+	UsedAt          *time.Time `db:"used_at"`
+	RevokedAt       *time.Time `db:"revoked_at"`
+	CreatedAt       time.Time  `db:"created_at"`
+}
+
+// TokenPair is returned by login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
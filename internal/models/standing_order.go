@@ -0,0 +1,76 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Once    Frequency = "once"
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+)
+
+func (f Frequency) Valid() bool {
+	switch f {
+	case Once, Daily, Weekly, Monthly:
+		return true
+	}
+	return false
+}
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "active"
+	StandingOrderPaused    StandingOrderStatus = "paused"
+	StandingOrderCompleted StandingOrderStatus = "completed"
+	StandingOrderFailed    StandingOrderStatus = "failed"
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+)
+
+// StandingOrder is a scheduled transfer, either one-off on a future date or
+// recurring until an end date or a number of occurrences.
+type StandingOrder struct {
+	ID             int64               `db:"id" json:"id"`
+	UserID         int64               `db:"user_id" json:"user_id"`
+	FromAccountID  int64               `db:"from_account_id" json:"from_account_id"`
+	ToAccountID    int64               `db:"to_account_id" json:"to_account_id"`
+	Amount         money.Money         `db:"amount" json:"amount"`
+	Currency       money.Currency      `db:"currency" json:"-"`
+	Frequency      Frequency           `db:"frequency" json:"frequency"`
+	StartDate      time.Time           `db:"start_date" json:"start_date"`
+	EndDate        *time.Time          `db:"end_date" json:"end_date,omitempty"`
+	MaxOccurrences *int                `db:"max_occurrences" json:"max_occurrences,omitempty"`
+	Occurrence     int                 `db:"occurrence" json:"occurrence"` // index of the next occurrence
+	NextRunAt      *time.Time          `db:"next_run_at" json:"next_run_at,omitempty"`
+	RetryCount     int                 `db:"retry_count" json:"retry_count"`
+	Status         StandingOrderStatus `db:"status" json:"status"`
+	Description    string              `db:"description" json:"description"`
+	CreatedAt      time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time           `db:"updated_at" json:"updated_at"`
+}
+
+type ExecutionStatus string
+
+const (
+	ExecutionSucceeded ExecutionStatus = "succeeded"
+	ExecutionRetrying  ExecutionStatus = "retrying"
+	ExecutionFailed    ExecutionStatus = "failed"
+)
+
+// StandingOrderExecution records one attempt to run an occurrence.
+type StandingOrderExecution struct {
+	ID              int64           `db:"id" json:"id"`
+	StandingOrderID int64           `db:"standing_order_id" json:"standing_order_id"`
+	Occurrence      int             `db:"occurrence" json:"occurrence"`
+	Attempt         int             `db:"attempt" json:"attempt"`
+	ScheduledFor    time.Time       `db:"scheduled_for" json:"scheduled_for"`
+	Status          ExecutionStatus `db:"status" json:"status"`
+	TransactionID   *int64          `db:"transaction_id" json:"transaction_id,omitempty"`
+	Error           *string         `db:"error" json:"error,omitempty"`
+	ExecutedAt      time.Time       `db:"executed_at" json:"executed_at"`
+}
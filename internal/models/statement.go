@@ -0,0 +1,56 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// StatementSummary is the header of a statement: which account and period
+// it covers and the balances and totals for that period. The period runs
+// from From up to but not including To. ID is only set on statements the
+// monthly statement job has stored.
+type StatementSummary struct {
+	ID             *int64         `db:"id" json:"id,omitempty"`
+	AccountID      int64          `db:"account_id" json:"account_id"`
+	AccountNumber  string         `db:"account_number" json:"account_number"`
+	IBAN           *string        `db:"iban" json:"iban,omitempty"`
+	Currency       money.Currency `db:"currency" json:"currency"`
+	From           time.Time      `db:"period_start" json:"from"`
+	To             time.Time      `db:"period_end" json:"to"`
+	OpeningBalance money.Money    `db:"opening_balance" json:"opening_balance"`
+	TotalIn        money.Money    `db:"total_in" json:"total_in"`
+	TotalOut       money.Money    `db:"total_out" json:"total_out"`
+	ClosingBalance money.Money    `db:"closing_balance" json:"closing_balance"`
+	GeneratedAt    time.Time      `db:"created_at" json:"generated_at"`
+}
+
+// Statement is a StatementSummary with the movements behind it, oldest
+// first.
+type Statement struct {
+	StatementSummary
+	Lines []StatementLine `json:"lines"`
+}
+
+// StatementLine is one journal's net effect on the account. Amount is
+// positive for money in and negative for money out; Balance is the account
+// balance once it was posted.
+type StatementLine struct {
+	JournalID     int64            `db:"journal_id" json:"journal_id"`
+	TransactionID *int64           `db:"transaction_id" json:"transaction_id,omitempty"`
+	Type          *TransactionType `db:"type" json:"type,omitempty"`
+	Description   string           `db:"description" json:"description"`
+	PostedAt      time.Time        `db:"posted_at" json:"posted_at"`
+	Amount        money.Money      `db:"amount" json:"amount"`
+	Balance       money.Money      `db:"balance" json:"balance"`
+}
+
+// StatementPDF is a customer's printable statement of all their accounts
+// for a month, as the statement PDF job rendered it.
+type StatementPDF struct {
+	ID          int64     `db:"id" json:"id"`
+	UserID      int64     `db:"user_id" json:"user_id"`
+	From        time.Time `db:"period_start" json:"from"`
+	To          time.Time `db:"period_end" json:"to"`
+	PDF         []byte    `db:"pdf" json:"-"`
+	GeneratedAt time.Time `db:"created_at" json:"generated_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
+
+// TermDepositProduct locks money for TermMonths at a fixed yearly RateBps.
+// Breaking a deposit early forfeits its interest and costs PenaltyBps of
+// the principal.
+type TermDepositProduct struct {
+	ID         int64          `db:"id" json:"id"`
+	Name       string         `db:"name" json:"name"`
+	Currency   money.Currency `db:"currency" json:"currency"`
+	TermMonths int            `db:"term_months" json:"term_months"`
+	RateBps    int            `db:"rate_bps" json:"rate_bps"`
+	PenaltyBps int            `db:"penalty_bps" json:"penalty_bps"`
+	MinAmount  money.Money    `db:"min_amount" json:"min_amount"`
+	Active     bool           `db:"active" json:"active"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// TermDepositStatus is where a term deposit is in its life.
+type TermDepositStatus string
+
+const (
+	TermDepositActive     TermDepositStatus = "active"
+	TermDepositMatured    TermDepositStatus = "matured"
+	TermDepositRolledOver TermDepositStatus = "rolled_over"
+	TermDepositBroken     TermDepositStatus = "broken"
+)
+
+// TermDeposit is money locked from AccountID until MaturesOn on the terms
+// of a product at the time it opened. Interest is set when it matures and
+// Penalty when it is broken.
+type TermDeposit struct {
+	ID                 int64             `db:"id" json:"id"`
+	UserID             int64             `db:"user_id" json:"user_id"`
+	AccountID          int64             `db:"account_id" json:"account_id"`
+	ProductID          int64             `db:"product_id" json:"product_id"`
+	Currency           money.Currency    `db:"currency" json:"currency"`
+	Principal          money.Money       `db:"principal" json:"principal"`
+	RateBps            int               `db:"rate_bps" json:"rate_bps"`
+	PenaltyBps         int               `db:"penalty_bps" json:"penalty_bps"`
+	TermMonths         int               `db:"term_months" json:"term_months"`
+	StartsOn           time.Time         `db:"starts_on" json:"starts_on"`
+	MaturesOn          time.Time         `db:"matures_on" json:"matures_on"`
+	Rollover           bool              `db:"rollover" json:"rollover"`
+	Status             TermDepositStatus `db:"status" json:"status"`
+	Interest           *money.Money      `db:"interest" json:"interest,omitempty"`
+	Penalty            *money.Money      `db:"penalty" json:"penalty,omitempty"`
+	OpenTransactionID  *int64            `db:"open_transaction_id" json:"open_transaction_id,omitempty"`
+	CloseTransactionID *int64            `db:"close_transaction_id" json:"close_transaction_id,omitempty"`
+	RolledFromID       *int64            `db:"rolled_from_id" json:"rolled_from_id,omitempty"`
+	ClosedAt           *time.Time        `db:"closed_at" json:"closed_at,omitempty"`
+	CreatedAt          time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time         `db:"updated_at" json:"updated_at"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"MockBankGo/internal/money"
+	"time"
+)
 
 type TransactionType string
 
@@ -8,13 +11,143 @@ const (
 	Deposit  TransactionType = "deposit"
 	Withdraw TransactionType = "withdraw"
 	Transfer TransactionType = "transfer"
+	Reversal TransactionType = "reversal"
+	Refund   TransactionType = "refund"
+	Interest TransactionType = "interest"
+
+	LoanDisbursement TransactionType = "loan_disbursement"
+	LoanRepayment    TransactionType = "loan_repayment"
+
+	TermDepositOpen     TransactionType = "term_deposit"
+	TermDepositPayout   TransactionType = "term_deposit_payout"
+	TermDepositRollover TransactionType = "term_deposit_rollover"
+)
+
+func (t TransactionType) Valid() bool {
+	switch t {
+	case Deposit, Withdraw, Transfer, Reversal, Refund, Interest, LoanDisbursement, LoanRepayment,
+		TermDepositOpen, TermDepositPayout, TermDepositRollover:
+		return true
+	}
+	return false
+}
+
+// TransactionStatus is where a transaction is in its lifecycle. It starts
+// pending and ends completed or failed; a completed transfer becomes
+// reversed once all of it has been sent back.
+type TransactionStatus string
+
+const (
+	TransactionPending   TransactionStatus = "pending"
+	TransactionCompleted TransactionStatus = "completed"
+	TransactionFailed    TransactionStatus = "failed"
+	TransactionReversed  TransactionStatus = "reversed"
 )
 
+// transactionTransitions lists the statuses each status may move to. The
+// database enforces the same rules.
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionPending:   {TransactionCompleted, TransactionFailed},
+	TransactionCompleted: {TransactionReversed},
+}
+
+func (s TransactionStatus) Valid() bool {
+	switch s {
+	case TransactionPending, TransactionCompleted, TransactionFailed, TransactionReversed:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a transaction in status s may move to next.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range transactionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// FailureReason is why a transaction was refused.
+type FailureReason string
+
+const (
+	FailureInsufficientFunds FailureReason = "insufficient_funds"
+	FailureLimitExceeded     FailureReason = "limit_exceeded"
+	FailureFraudBlock        FailureReason = "fraud_block"
+	FailureFXRateUnavailable FailureReason = "fx_rate_unavailable"
+)
+
+// TransactionStatusChange is one step in a transaction's lifecycle. From is
+// nil for the status the transaction was created with.
+type TransactionStatusChange struct {
+	ID            int64              `db:"id" json:"-"`
+	TransactionID int64              `db:"transaction_id" json:"-"`
+	From          *TransactionStatus `db:"from_status" json:"from,omitempty"`
+	To            TransactionStatus  `db:"to_status" json:"to"`
+	Reason        *FailureReason     `db:"reason" json:"reason,omitempty"`
+	ChangedAt     time.Time          `db:"changed_at" json:"changed_at"`
+}
+
+// TransactionInfo records a money movement. Amount is what left the sender
+// (or arrived, for a deposit). A transfer between accounts of different
+// currencies also records ToAmount, what the receiver got, and FXRate, the
+// rate applied between the two. Fees are charged on top of Amount. Failed
+// transactions keep the attempt and its FailureReason but moved no money.
 type TransactionInfo struct {
-	ID         int64           `db:"id" json:"id"`
-	SenderID   int64           `db:"sender_id" json:"sender_id"`
-	ReceiverID int64           `db:"receiver_id" json:"receiver_id"`
-	Amount     float64         `db:"amount" json:"amount"`
-	Type       TransactionType `db:"type" json:"type"`
-	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	ID              int64                     `db:"id" json:"id"`
+	SenderID        int64                     `db:"sender_id" json:"sender_id"`
+	ReceiverID      int64                     `db:"receiver_id" json:"receiver_id"`
+	FromAccountID   *int64                    `db:"from_account_id" json:"from_account_id,omitempty"`
+	ToAccountID     *int64                    `db:"to_account_id" json:"to_account_id,omitempty"`
+	Amount          money.Money               `db:"amount" json:"amount"`
+	Currency        money.Currency            `db:"currency" json:"-"`
+	ToAmount        *money.Money              `db:"to_amount" json:"to_amount,omitempty"`
+	ToCurrency      *money.Currency           `db:"to_currency" json:"-"`
+	FXRate          *string                   `db:"fx_rate" json:"fx_rate,omitempty"`
+	FXQuoteID       *string                   `db:"fx_quote_id" json:"fx_quote_id,omitempty"`
+	Type            TransactionType           `db:"type" json:"type"`
+	ReversalOf      *int64                    `db:"reversal_of" json:"reversal_of,omitempty"`
+	Status          TransactionStatus         `db:"status" json:"status"`
+	FailureReason   *FailureReason            `db:"failure_reason" json:"failure_reason,omitempty"`
+	StatusChangedAt time.Time                 `db:"status_changed_at" json:"status_changed_at"`
+	StatusHistory   []TransactionStatusChange `db:"-" json:"status_history,omitempty"`
+	Fees            []TransactionFee          `db:"-" json:"fees,omitempty"`
+	CreatedAt       time.Time                 `db:"created_at" json:"created_at"`
+}
+
+type SortOrder string
+
+const (
+	SortDesc SortOrder = "desc"
+	SortAsc  SortOrder = "asc"
+)
+
+// TransactionCursor marks the last row of a page; the next page starts
+// strictly after it in (created_at, id) order.
+type TransactionCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"id"`
+}
+
+type TransactionFilter struct {
+	UserID         *int64 // only transactions where this user is sender or receiver
+	CounterpartyID *int64
+	Type           TransactionType
+	Status         TransactionStatus
+	From           *time.Time     // inclusive
+	To             *time.Time     // exclusive
+	Currency       money.Currency // MinAmount and MaxAmount are in this currency
+	MinAmount      *money.Money
+	MaxAmount      *money.Money
+	Order          SortOrder
+	Limit          int
+	Cursor         string
+	After          *TransactionCursor
+}
+
+type TransactionPage struct {
+	Transactions []TransactionInfo `json:"transactions"`
+	NextCursor   string            `json:"next_cursor,omitempty"`
 }
@@ -8,6 +8,5 @@ type User struct {
 	Name     string    `db:"name" json:"name"`         // Added json tag
 	Email    string    `db:"email" json:"email"`       // Added json tag
 	Password string    `db:"password" json:"password"` // Added json tag
-	Balance  float64   `db:"balance" json:"balance"`   // Added json tag
 	Role     auth.Role `db:"role" json:"role"`
 }
@@ -0,0 +1,283 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	CHF Currency = "CHF"
+	KZT Currency = "KZT"
+	JPY Currency = "JPY"
+)
+
+// DefaultCurrency is used when an amount arrives without an explicit currency.
+const DefaultCurrency = USD
+
+// exponents holds the number of minor-unit digits for each supported currency.
+var exponents = map[Currency]int{
+	USD: 2,
+	EUR: 2,
+	GBP: 2,
+	CHF: 2,
+	KZT: 2,
+	JPY: 0,
+}
+
+var (
+	ErrInvalidFormat       = errors.New("invalid money amount")
+	ErrTooPrecise          = errors.New("amount has more decimal places than the currency allows")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrOverflow            = errors.New("amount out of range")
+)
+
+func (c Currency) Valid() bool {
+	_, ok := exponents[c]
+	return ok
+}
+
+// Exponent returns the number of decimal places used by the currency.
+func (c Currency) Exponent() int {
+	return exponents[c]
+}
+
+// Money is an exact amount expressed in integer minor units (cents) of a currency.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func New(minor int64, currency Currency) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// Parse converts a decimal string such as "100.50" into Money, rejecting
+// values with more fractional digits than the currency supports.
+func Parse(s string, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, ErrUnsupportedCurrency
+	}
+
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, frac, hasDot := strings.Cut(s, ".")
+	if whole == "" || (hasDot && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, ErrInvalidFormat
+	}
+
+	exp := currency.Exponent()
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > exp {
+		return Money{}, ErrTooPrecise
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, ErrOverflow
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) currency() Currency {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// String formats the amount as a plain decimal, e.g. "-12.30".
+func (m Money) String() string {
+	exp := m.currency().Exponent()
+
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-m.Amount)
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+func (m Money) SameCurrency(o Money) bool {
+	return m.currency() == o.currency()
+}
+
+// Add returns m + o. Both values must share a currency.
+func (m Money) Add(o Money) Money {
+	m.mustMatch(o)
+	return Money{Amount: m.Amount + o.Amount, Currency: m.currency()}
+}
+
+// Sub returns m - o. Both values must share a currency.
+func (m Money) Sub(o Money) Money {
+	m.mustMatch(o)
+	return Money{Amount: m.Amount - o.Amount, Currency: m.currency()}
+}
+
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.currency()}
+}
+
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.Amount < o.Amount:
+		return -1
+	case m.Amount > o.Amount:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) mustMatch(o Money) {
+	if !m.SameCurrency(o) {
+		panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.currency(), o.currency()))
+	}
+}
+
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.currency()})
+}
+
+// UnmarshalJSON accepts a bare number (100.50), a string ("100.50") or an
+// object ({"amount": "100.50", "currency": "EUR"}). Numbers are parsed from
+// their literal text so no float rounding ever happens.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	var raw string
+	switch data[0] {
+	case '{':
+		var obj struct {
+			Amount   json.RawMessage `json:"amount"`
+			Currency Currency        `json:"currency"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return ErrInvalidFormat
+		}
+		if obj.Currency != "" {
+			currency = Currency(strings.ToUpper(string(obj.Currency)))
+		}
+		obj.Amount = bytes.TrimSpace(obj.Amount)
+		if len(obj.Amount) == 0 {
+			return ErrInvalidFormat
+		}
+		if obj.Amount[0] == '"' {
+			if err := json.Unmarshal(obj.Amount, &raw); err != nil {
+				return ErrInvalidFormat
+			}
+		} else {
+			raw = string(obj.Amount)
+		}
+	case '"':
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return ErrInvalidFormat
+		}
+	default:
+		raw = string(data)
+	}
+
+	if strings.ContainsAny(raw, "eE") {
+		return ErrInvalidFormat
+	}
+
+	parsed, err := Parse(raw, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as integer minor units. The currency lives in its
+// own column wherever it is persisted.
+func (m Money) Value() (driver.Value, error) {
+	return m.Amount, nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	var minor int64
+	switch v := src.(type) {
+	case int64:
+		minor = v
+	case []byte:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q: %w", v, err)
+		}
+		minor = n
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("money: cannot scan %q: %w", v, err)
+		}
+		minor = n
+	case nil:
+		minor = 0
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	m.Amount = minor
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_ExactMinorUnits(t *testing.T) {
+	m, err := Parse("100.50", USD)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10050), m.Amount)
+	assert.Equal(t, USD, m.Currency)
+	assert.Equal(t, "100.50", m.String())
+}
+
+func TestParse_RejectsExtraPrecision(t *testing.T) {
+	_, err := Parse("1.005", USD)
+	assert.ErrorIs(t, err, ErrTooPrecise)
+
+	_, err = Parse("10.5", JPY)
+	assert.ErrorIs(t, err, ErrTooPrecise)
+
+	// Trailing zeros carry no extra precision.
+	m, err := Parse("1.500", USD)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), m.Amount)
+}
+
+func TestParse_InvalidInput(t *testing.T) {
+	for _, in := range []string{"", ".5", "1.", "abc", "1,00", "1e2"} {
+		_, err := Parse(in, USD)
+		assert.ErrorIs(t, err, ErrInvalidFormat, in)
+	}
+
+	_, err := Parse("1", Currency("XXX"))
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestAdd_NoFloatDrift(t *testing.T) {
+	a, _ := Parse("0.1", USD)
+	b, _ := Parse("0.2", USD)
+	want, _ := Parse("0.3", USD)
+
+	assert.Equal(t, 0, a.Add(b).Cmp(want))
+}
+
+func TestString_Negative(t *testing.T) {
+	assert.Equal(t, "-0.05", New(-5, USD).String())
+	assert.Equal(t, "-1200", New(-1200, JPY).String())
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	var in struct {
+		Amount Money `json:"amount"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 19.99}`), &in))
+	assert.Equal(t, int64(1999), in.Amount.Amount)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": {"amount": "5", "currency": "jpy"}}`), &in))
+	assert.Equal(t, New(5, JPY), in.Amount)
+
+	err := json.Unmarshal([]byte(`{"amount": 0.001}`), &in)
+	assert.ErrorIs(t, err, ErrTooPrecise)
+
+	out, err := json.Marshal(New(1999, USD))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"19.99","currency":"USD"}`, string(out))
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWidth(t *testing.T) {
+	// "Hello": H 722, e 556, l 222, l 222, o 556 at 10pt
+	assert.InDelta(t, 22.78, Width(Helvetica, 10, "Hello"), 0.001)
+	assert.InDelta(t, 24.45, Width(HelveticaBold, 10, "Hello"), 0.001)
+	assert.InDelta(t, 5.56, Width(Helvetica, 10, "ë"), 0.001)
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, []byte("Zo\xeb \x80 5 \x96 ?"), encode("Zoë € 5 – 日"))
+	assert.Equal(t, `(a \(b\) \\ c)`, literal([]byte(`a (b) \ c`)))
+}
+
+func TestDocument_WriteTo(t *testing.T) {
+	doc := New("Statement (June)")
+	first := doc.AddPage()
+	first.Text(50, 800, HelveticaBold, 18, "MockBank")
+	first.Line(50, 790, 545.28, 790, 0.5)
+	doc.AddPage().TextRight(545, 40, Helvetica, 8, "Page 2 of 2")
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	require.NoError(t, err)
+	out := buf.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Kids [6 0 R 8 0 R] /Count 2")
+	assert.Contains(t, string(out), `/Title (Statement \(June\))`)
+	assert.Contains(t, string(out), "BT /F2 18 Tf 50 800 Td (MockBank) Tj ET\n")
+	assert.Contains(t, string(out), "0.5 w 50 790 m 545.28 790 l S\n")
+
+	// Every xref entry points at the start of its object, and startxref
+	// at the xref table.
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(out[xref:], []byte("xref\n0 10\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	require.Len(t, entries, 9)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestDocument_WriteToWithoutPages(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := New("Empty").WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "/Kids [6 0 R] /Count 1")
+}
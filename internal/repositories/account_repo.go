@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IAccountRepository interface {
+	NextAccountNumber() (string, error)
+	CreateAccount(account *models.Account) error
+	GetAccountsByUser(userID int64) ([]models.Account, error)
+	GetAccountByID(accountID int64) (*models.Account, error)
+	GetAccountByNumber(number string) (*models.Account, error)
+	GetAccountByIBAN(iban string) (*models.Account, error)
+	GetAccountsWithoutIBAN() ([]models.Account, error)
+	SetIBAN(accountID int64, iban string) error
+	GetAccountForUpdate(tx *sqlx.Tx, accountID int64) (*models.Account, error)
+	CloseAccount(tx *sqlx.Tx, accountID int64) error
+	HasOpenLoans(tx *sqlx.Tx, accountID int64) (bool, error)
+	HasActiveTermDeposits(tx *sqlx.Tx, accountID int64) (bool, error)
+}
+
+type AccountRepository struct {
+	database *sqlx.DB
+}
+
+func NewAccountRepository(db *sqlx.DB) *AccountRepository {
+	return &AccountRepository{database: db}
+}
+
+// withCurrency stamps the account's currency onto its balance and held
+// amount, since those columns only store minor units, and derives the
+// available balance.
+func withCurrency(account *models.Account) *models.Account {
+	account.Balance.Currency = account.Currency
+	account.Held.Currency = account.Currency
+	account.Available = account.Balance.Sub(account.Held)
+	return account
+}
+
+// NextAccountNumber takes the next number from the sequence the account
+// number column defaults to, so an account's IBAN can be worked out before
+// it is inserted.
+func (r *AccountRepository) NextAccountNumber() (string, error) {
+	var number string
+	err := r.database.Get(&number, "SELECT lpad(nextval('account_number_seq')::text, 12, '0')")
+	return number, err
+}
+
+// CreateAccount inserts the account with its number and IBAN and fills in
+// the generated ID and timestamps.
+func (r *AccountRepository) CreateAccount(account *models.Account) error {
+	err := r.database.QueryRowx(
+		`INSERT INTO accounts (user_id, type, currency, number, iban) VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, balance, held, status, created_at`,
+		account.UserID, account.Type, account.Currency, account.Number, account.IBAN,
+	).Scan(&account.ID, &account.Balance, &account.Held, &account.Status, &account.CreatedAt)
+	if err != nil {
+		return err
+	}
+	withCurrency(account)
+	return nil
+}
+
+func (r *AccountRepository) GetAccountsByUser(userID int64) ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.database.Select(&accounts, "SELECT * FROM accounts WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		withCurrency(&accounts[i])
+	}
+	return accounts, nil
+}
+
+func (r *AccountRepository) GetAccountByID(accountID int64) (*models.Account, error) {
+	var account models.Account
+	err := r.database.Get(&account, "SELECT * FROM accounts WHERE id = $1", accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return withCurrency(&account), nil
+}
+
+func (r *AccountRepository) GetAccountByNumber(number string) (*models.Account, error) {
+	var account models.Account
+	err := r.database.Get(&account, "SELECT * FROM accounts WHERE number = $1", number)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return withCurrency(&account), nil
+}
+
+// GetAccountByIBAN finds an account by its IBAN, which must be normalized.
+func (r *AccountRepository) GetAccountByIBAN(iban string) (*models.Account, error) {
+	var account models.Account
+	err := r.database.Get(&account, "SELECT * FROM accounts WHERE iban = $1", iban)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return withCurrency(&account), nil
+}
+
+func (r *AccountRepository) GetAccountsWithoutIBAN() ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.database.Select(&accounts, "SELECT * FROM accounts WHERE iban IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		withCurrency(&accounts[i])
+	}
+	return accounts, nil
+}
+
+// SetIBAN gives an account that has none its IBAN.
+func (r *AccountRepository) SetIBAN(accountID int64, iban string) error {
+	_, err := r.database.Exec("UPDATE accounts SET iban = $1 WHERE id = $2 AND iban IS NULL", iban, accountID)
+	return err
+}
+
+func (r *AccountRepository) GetAccountForUpdate(tx *sqlx.Tx, accountID int64) (*models.Account, error) {
+	var account models.Account
+	err := tx.Get(&account, "SELECT * FROM accounts WHERE id = $1 FOR UPDATE", accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return withCurrency(&account), nil
+}
+
+func (r *AccountRepository) CloseAccount(tx *sqlx.Tx, accountID int64) error {
+	_, err := tx.Exec(
+		"UPDATE accounts SET status = $1, closed_at = CURRENT_TIMESTAMP WHERE id = $2",
+		models.AccountClosed, accountID,
+	)
+	return err
+}
+
+// HasOpenLoans reports whether a loan applied for or being repaid uses the
+// account.
+func (r *AccountRepository) HasOpenLoans(tx *sqlx.Tx, accountID int64) (bool, error) {
+	var open bool
+	err := tx.Get(&open,
+		"SELECT EXISTS (SELECT 1 FROM loans WHERE account_id = $1 AND status IN ('pending', 'active'))",
+		accountID,
+	)
+	return open, err
+}
+
+// HasActiveTermDeposits reports whether a term deposit opened from the
+// account has yet to be paid back into it.
+func (r *AccountRepository) HasActiveTermDeposits(tx *sqlx.Tx, accountID int64) (bool, error) {
+	var active bool
+	err := tx.Get(&active,
+		"SELECT EXISTS (SELECT 1 FROM term_deposits WHERE account_id = $1 AND status = 'active')",
+		accountID,
+	)
+	return active, err
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// auditChainLock is the advisory lock key that serialises appends so every
+// entry links to the one committed just before it.
+const auditChainLock = 0x61756469740a
+
+type IAuditRepository interface {
+	LockChain(tx *sqlx.Tx) error
+	GetLastHash(tx *sqlx.Tx) (string, error)
+	Insert(tx *sqlx.Tx, entry *models.AuditEntry) error
+	Enqueue(tx *sqlx.Tx, entry *models.AuditEntry) error
+	ListQueued(tx *sqlx.Tx, limit int) ([]models.AuditEntry, error)
+	DeleteQueued(tx *sqlx.Tx, ids []int64) error
+	Search(filter models.AuditFilter) ([]models.AuditEntry, error)
+	ListAfter(afterID int64, limit int) ([]models.AuditEntry, error)
+}
+
+type AuditRepository struct {
+	database *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{database: db}
+}
+
+func auditWithCurrency(entry *models.AuditEntry) {
+	if entry.Amount != nil && entry.Currency != nil {
+		entry.Amount.Currency = *entry.Currency
+	}
+}
+
+// LockChain holds the chain lock until tx ends.
+func (r *AuditRepository) LockChain(tx *sqlx.Tx) error {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", auditChainLock)
+	return err
+}
+
+// GetLastHash returns the hash of the newest entry, or "" for an empty log.
+func (r *AuditRepository) GetLastHash(tx *sqlx.Tx) (string, error) {
+	var hash string
+	err := tx.Get(&hash, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1")
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+func (r *AuditRepository) Insert(tx *sqlx.Tx, entry *models.AuditEntry) error {
+	return tx.QueryRowx(
+		`INSERT INTO audit_log
+			(action, outcome, actor_id, target_type, target_id, amount, currency, error, details,
+			 ip, user_agent, request_id, created_at, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		 RETURNING id`,
+		entry.Action, entry.Outcome, entry.ActorID, entry.TargetType, entry.TargetID, entry.Amount, entry.Currency,
+		entry.Error, string(entry.Details), entry.IP, entry.UserAgent, entry.RequestID, entry.CreatedAt,
+		entry.PrevHash, entry.Hash,
+	).Scan(&entry.ID)
+}
+
+// Enqueue writes entry to the audit queue. Its ID is the queue's, not the
+// log's.
+func (r *AuditRepository) Enqueue(tx *sqlx.Tx, entry *models.AuditEntry) error {
+	return tx.QueryRowx(
+		`INSERT INTO audit_queue
+			(action, outcome, actor_id, target_type, target_id, amount, currency, error, details,
+			 ip, user_agent, request_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING id`,
+		entry.Action, entry.Outcome, entry.ActorID, entry.TargetType, entry.TargetID, entry.Amount, entry.Currency,
+		entry.Error, string(entry.Details), entry.IP, entry.UserAgent, entry.RequestID, entry.CreatedAt,
+	).Scan(&entry.ID)
+}
+
+// ListQueued returns up to limit queued entries, oldest first.
+func (r *AuditRepository) ListQueued(tx *sqlx.Tx, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	if err := tx.Select(&entries, "SELECT * FROM audit_queue ORDER BY id LIMIT $1", limit); err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		auditWithCurrency(&entries[i])
+	}
+	return entries, nil
+}
+
+func (r *AuditRepository) DeleteQueued(tx *sqlx.Tx, ids []int64) error {
+	_, err := tx.Exec("DELETE FROM audit_queue WHERE id = ANY($1)", pq.Array(ids))
+	return err
+}
+
+// Search returns entries matching the filter, newest first.
+func (r *AuditRepository) Search(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ActorID != nil {
+		conditions = append(conditions, "actor_id = "+arg(*filter.ActorID))
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = "+arg(filter.Action))
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = "+arg(filter.TargetType))
+	}
+	if filter.TargetID != nil {
+		conditions = append(conditions, "target_id = "+arg(*filter.TargetID))
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "request_id = "+arg(filter.RequestID))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.To))
+	}
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, "id < "+arg(filter.BeforeID))
+	}
+
+	query := "SELECT * FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT " + arg(filter.Limit)
+
+	var entries []models.AuditEntry
+	if err := r.database.Select(&entries, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		auditWithCurrency(&entries[i])
+	}
+	return entries, nil
+}
+
+// ListAfter returns up to limit entries with id greater than afterID, oldest first.
+func (r *AuditRepository) ListAfter(afterID int64, limit int) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.database.Select(&entries, "SELECT * FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2", afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		auditWithCurrency(&entries[i])
+	}
+	return entries, nil
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+// A second request claiming the same idempotency key under repeatable read
+// fails with a serialization error, which Claim reports as
+// ErrIdempotencyInProgress.
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, isSerializationFailure(&pq.Error{Code: "40001"}))
+	assert.True(t, isSerializationFailure(fmt.Errorf("claim: %w", &pq.Error{Code: "40001"})))
+	assert.False(t, isSerializationFailure(&pq.Error{Code: "23505"}))
+	assert.False(t, isSerializationFailure(errors.New("40001")))
+	assert.False(t, isSerializationFailure(nil))
+}
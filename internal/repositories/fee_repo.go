@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type IFeeRepository interface {
+	GetRules() ([]models.FeeRule, error)
+	GetRulesFor(q sqlx.Queryer, userID int64, types []models.FeeType, currency money.Currency) ([]models.FeeRule, error)
+	UpsertRule(rule *models.FeeRule) error
+	DeleteRule(ruleID int64) error
+	WriteFees(tx *sqlx.Tx, transactionID int64, fees []models.TransactionFee) error
+	GetFees(q sqlx.Queryer, transactionIDs []int64) ([]models.TransactionFee, error)
+}
+
+type FeeRepository struct {
+	database *sqlx.DB
+}
+
+func NewFeeRepository(db *sqlx.DB) *FeeRepository {
+	return &FeeRepository{database: db}
+}
+
+// ruleWithCurrency stamps the rule's currency onto its amounts.
+func ruleWithCurrency(rule *models.FeeRule) {
+	for _, amount := range []*money.Money{rule.Flat, rule.MinFee, rule.MaxFee} {
+		if amount != nil {
+			amount.Currency = rule.Currency
+		}
+	}
+}
+
+func (r *FeeRepository) GetRules() ([]models.FeeRule, error) {
+	var rules []models.FeeRule
+	err := r.database.Select(&rules, "SELECT * FROM fee_rules ORDER BY fee_type, currency, role NULLS FIRST")
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		ruleWithCurrency(&rules[i])
+	}
+	return rules, nil
+}
+
+// GetRulesFor returns the rules of the given types and currency that apply
+// to the user: those for everyone and those for the user's role.
+func (r *FeeRepository) GetRulesFor(q sqlx.Queryer, userID int64, types []models.FeeType, currency money.Currency) ([]models.FeeRule, error) {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+
+	var rules []models.FeeRule
+	err := sqlx.Select(q, &rules,
+		`SELECT f.* FROM fee_rules f
+		 JOIN users u ON u.id = $1
+		 WHERE f.fee_type = ANY($2) AND f.currency = $3
+		   AND (f.role IS NULL OR f.role = u.role)`,
+		userID, pq.Array(names), currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		ruleWithCurrency(&rules[i])
+	}
+	return rules, nil
+}
+
+// UpsertRule creates the rule or replaces the existing rule for the same
+// type, role and currency.
+func (r *FeeRepository) UpsertRule(rule *models.FeeRule) error {
+	err := r.database.QueryRowx(
+		`INSERT INTO fee_rules (fee_type, role, currency, kind, flat, bps, tiers, min_fee, max_fee)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (fee_type, COALESCE(role, ''), currency)
+		 DO UPDATE SET kind = EXCLUDED.kind, flat = EXCLUDED.flat, bps = EXCLUDED.bps, tiers = EXCLUDED.tiers,
+		               min_fee = EXCLUDED.min_fee, max_fee = EXCLUDED.max_fee, updated_at = CURRENT_TIMESTAMP
+		 RETURNING id, created_at, updated_at`,
+		rule.Type, rule.Role, rule.Currency, rule.Kind, rule.Flat, rule.Bps, rule.Tiers, rule.MinFee, rule.MaxFee,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	return err
+}
+
+func (r *FeeRepository) DeleteRule(ruleID int64) error {
+	result, err := r.database.Exec("DELETE FROM fee_rules WHERE id = $1", ruleID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *FeeRepository) WriteFees(tx *sqlx.Tx, transactionID int64, fees []models.TransactionFee) error {
+	for i := range fees {
+		fee := &fees[i]
+		fee.TransactionID = transactionID
+		fee.Currency = fee.Amount.Currency
+		err := tx.Get(&fee.ID,
+			`INSERT INTO transaction_fees (transaction_id, rule_id, fee_type, amount, currency)
+			 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			fee.TransactionID, fee.RuleID, fee.Type, fee.Amount, fee.Currency,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetFees returns the fees charged on the given transactions, in ID order.
+// q is the database, or a transaction whose snapshot the fees must match.
+func (r *FeeRepository) GetFees(q sqlx.Queryer, transactionIDs []int64) ([]models.TransactionFee, error) {
+	var fees []models.TransactionFee
+	err := sqlx.Select(q, &fees,
+		"SELECT * FROM transaction_fees WHERE transaction_id = ANY($1) ORDER BY id",
+		pq.Array(transactionIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fees {
+		fees[i].Amount.Currency = fees[i].Currency
+	}
+	return fees, nil
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrQuoteInUse means a concurrent transfer redeemed the quote first.
+var ErrQuoteInUse = errors.New("fx quote was redeemed by a concurrent request")
+
+type IFXRepository interface {
+	UpsertRates(rates []models.FXRate) error
+	GetLatestRate(base money.Currency, quote money.Currency, at time.Time) (*models.FXRate, error)
+	GetLatestRates(at time.Time) ([]models.FXRate, error)
+	CreateQuote(quote *models.FXQuote) error
+	GetQuoteForUpdate(tx *sqlx.Tx, quoteID string) (*models.FXQuote, error)
+	MarkQuoteUsed(tx *sqlx.Tx, quoteID string, at time.Time) error
+	DeleteExpiredQuotes(before time.Time) error
+}
+
+type FXRepository struct {
+	database *sqlx.DB
+}
+
+func NewFXRepository(db *sqlx.DB) *FXRepository {
+	return &FXRepository{database: db}
+}
+
+func rateTrimmed(rate *models.FXRate) *models.FXRate {
+	rate.Rate = trimDecimal(rate.Rate)
+	return rate
+}
+
+func quoteWithCurrency(quote *models.FXQuote) *models.FXQuote {
+	quote.FromAmount.Currency = quote.FromCurrency
+	quote.ToAmount.Currency = quote.ToCurrency
+	quote.Rate = trimDecimal(quote.Rate)
+	quote.MidRate = trimDecimal(quote.MidRate)
+	return quote
+}
+
+// UpsertRates stores a batch of rates atomically. A rate for a pair and
+// effective time that already exists is replaced, so reloading the same
+// file is harmless.
+func (r *FXRepository) UpsertRates(rates []models.FXRate) error {
+	tx, err := r.database.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range rates {
+		rate := &rates[i]
+		err := tx.QueryRowx(
+			`INSERT INTO fx_rates (base, quote, rate, spread_bps, source, effective_at)
+			 VALUES ($1, $2, $3, $4, $5, $6)
+			 ON CONFLICT (base, quote, effective_at)
+			 DO UPDATE SET rate = EXCLUDED.rate, spread_bps = EXCLUDED.spread_bps, source = EXCLUDED.source
+			 RETURNING id, created_at`,
+			rate.Base, rate.Quote, rate.Rate, rate.SpreadBps, rate.Source, rate.EffectiveAt,
+		).Scan(&rate.ID, &rate.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLatestRate returns the newest rate for base/quote in effect at the given
+// time, or sql.ErrNoRows.
+func (r *FXRepository) GetLatestRate(base money.Currency, quote money.Currency, at time.Time) (*models.FXRate, error) {
+	var rate models.FXRate
+	err := r.database.Get(&rate,
+		`SELECT * FROM fx_rates
+		 WHERE base = $1 AND quote = $2 AND effective_at <= $3
+		 ORDER BY effective_at DESC
+		 LIMIT 1`,
+		base, quote, at,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rateTrimmed(&rate), nil
+}
+
+// GetLatestRates returns the rate in effect at the given time for every pair.
+func (r *FXRepository) GetLatestRates(at time.Time) ([]models.FXRate, error) {
+	var rates []models.FXRate
+	err := r.database.Select(&rates,
+		`SELECT DISTINCT ON (base, quote) * FROM fx_rates
+		 WHERE effective_at <= $1
+		 ORDER BY base, quote, effective_at DESC`,
+		at,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rates {
+		rateTrimmed(&rates[i])
+	}
+	return rates, nil
+}
+
+func (r *FXRepository) CreateQuote(quote *models.FXQuote) error {
+	return r.database.QueryRowx(
+		`INSERT INTO fx_quotes
+			(id, user_id, from_amount, from_currency, to_amount, to_currency, rate, mid_rate, spread_bps,
+			 rate_effective_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 RETURNING created_at`,
+		quote.ID, quote.UserID, quote.FromAmount, quote.FromCurrency, quote.ToAmount, quote.ToCurrency,
+		quote.Rate, quote.MidRate, quote.SpreadBps, quote.RateEffectiveAt, quote.ExpiresAt,
+	).Scan(&quote.CreatedAt)
+}
+
+func (r *FXRepository) GetQuoteForUpdate(tx *sqlx.Tx, quoteID string) (*models.FXQuote, error) {
+	var quote models.FXQuote
+	err := tx.Get(&quote, "SELECT * FROM fx_quotes WHERE id = $1 FOR UPDATE", quoteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		if isSerializationFailure(err) {
+			return nil, ErrQuoteInUse
+		}
+		return nil, err
+	}
+	return quoteWithCurrency(&quote), nil
+}
+
+func (r *FXRepository) MarkQuoteUsed(tx *sqlx.Tx, quoteID string, at time.Time) error {
+	_, err := tx.Exec("UPDATE fx_quotes SET used_at = $1 WHERE id = $2", at, quoteID)
+	return err
+}
+
+// DeleteExpiredQuotes removes quotes that expired unused before the given time.
+func (r *FXRepository) DeleteExpiredQuotes(before time.Time) error {
+	_, err := r.database.Exec("DELETE FROM fx_quotes WHERE used_at IS NULL AND expires_at < $1", before)
+	return err
+}
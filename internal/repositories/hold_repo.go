@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrHoldInUse means another transaction captured, released or expired the
+// hold after this one started.
+var ErrHoldInUse = errors.New("hold is being changed by another transaction")
+
+type IHoldRepository interface {
+	CreateHold(tx *sqlx.Tx, hold *models.Hold) error
+	GetHold(holdID int64) (*models.Hold, error)
+	GetHoldForUpdate(tx *sqlx.Tx, holdID int64) (*models.Hold, error)
+	GetHoldsByUser(userID int64) ([]models.Hold, error)
+	CloseHold(tx *sqlx.Tx, hold *models.Hold) error
+	IncreaseHeld(tx *sqlx.Tx, accountID int64, amount money.Money) error
+	DecreaseHeld(tx *sqlx.Tx, accountID int64, amount money.Money) error
+	ExpireHolds(now time.Time) error
+}
+
+type HoldRepository struct {
+	database *sqlx.DB
+}
+
+func NewHoldRepository(db *sqlx.DB) *HoldRepository {
+	return &HoldRepository{database: db}
+}
+
+func holdWithCurrency(hold *models.Hold) *models.Hold {
+	hold.Amount.Currency = hold.Currency
+	if hold.CapturedAmount != nil {
+		hold.CapturedAmount.Currency = hold.Currency
+	}
+	return hold
+}
+
+func (r *HoldRepository) CreateHold(tx *sqlx.Tx, hold *models.Hold) error {
+	return tx.QueryRowx(
+		`INSERT INTO holds (user_id, account_id, to_account_id, amount, currency, description, status, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at`,
+		hold.UserID, hold.AccountID, hold.ToAccountID, hold.Amount, hold.Currency, hold.Description,
+		hold.Status, hold.ExpiresAt,
+	).Scan(&hold.ID, &hold.CreatedAt)
+}
+
+func (r *HoldRepository) GetHold(holdID int64) (*models.Hold, error) {
+	var hold models.Hold
+	err := r.database.Get(&hold, "SELECT * FROM holds WHERE id = $1", holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return holdWithCurrency(&hold), nil
+}
+
+func (r *HoldRepository) GetHoldForUpdate(tx *sqlx.Tx, holdID int64) (*models.Hold, error) {
+	var hold models.Hold
+	err := tx.Get(&hold, "SELECT * FROM holds WHERE id = $1 FOR UPDATE", holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		if isSerializationFailure(err) {
+			return nil, ErrHoldInUse
+		}
+		return nil, err
+	}
+	return holdWithCurrency(&hold), nil
+}
+
+// GetHoldsByUser returns the holds the user placed and those on the way to
+// one of the user's accounts, newest first.
+func (r *HoldRepository) GetHoldsByUser(userID int64) ([]models.Hold, error) {
+	var holds []models.Hold
+	err := r.database.Select(&holds,
+		`SELECT * FROM holds
+		 WHERE user_id = $1 OR to_account_id IN (SELECT id FROM accounts WHERE user_id = $1)
+		 ORDER BY id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holds {
+		holdWithCurrency(&holds[i])
+	}
+	return holds, nil
+}
+
+// CloseHold saves the final state of a hold.
+func (r *HoldRepository) CloseHold(tx *sqlx.Tx, hold *models.Hold) error {
+	_, err := tx.Exec(
+		`UPDATE holds SET status = $1, captured_amount = $2, transaction_id = $3, closed_at = $4
+		 WHERE id = $5`,
+		hold.Status, hold.CapturedAmount, hold.TransactionID, hold.ClosedAt, hold.ID,
+	)
+	return err
+}
+
+func (r *HoldRepository) IncreaseHeld(tx *sqlx.Tx, accountID int64, amount money.Money) error {
+	_, err := tx.Exec("UPDATE accounts SET held = held + $1 WHERE id = $2", amount, accountID)
+	return err
+}
+
+func (r *HoldRepository) DecreaseHeld(tx *sqlx.Tx, accountID int64, amount money.Money) error {
+	_, err := tx.Exec("UPDATE accounts SET held = held - $1 WHERE id = $2", amount, accountID)
+	return err
+}
+
+// ExpireHolds ends every active hold that expired by now and gives its
+// amount back to the account's available balance. A hold being captured or
+// released at the same time is waited for and then skipped.
+func (r *HoldRepository) ExpireHolds(now time.Time) error {
+	_, err := r.database.Exec(
+		`WITH expired AS (
+			UPDATE holds SET status = 'expired', closed_at = $1
+			WHERE status = 'active' AND expires_at <= $1
+			RETURNING account_id, amount
+		 )
+		 UPDATE accounts a SET held = a.held - e.total
+		 FROM (SELECT account_id, SUM(amount) AS total FROM expired GROUP BY account_id) e
+		 WHERE a.id = e.account_id`,
+		now,
+	)
+	return err
+}
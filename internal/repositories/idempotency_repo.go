@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrIdempotencyInProgress means another request holding the same key
+// committed while this one was running.
+var ErrIdempotencyInProgress = errors.New("idempotency key is in use by a concurrent request")
+
+type IIdempotencyRepository interface {
+	Claim(tx *sqlx.Tx, userID int64, key string, requestHash string) (*models.IdempotencyRecord, error)
+	Complete(tx *sqlx.Tx, userID int64, key string, statusCode int, body []byte) error
+}
+
+type IdempotencyRepository struct {
+	database *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{database: db}
+}
+
+// Claim reserves the key inside tx. It returns nil when the key is new, or
+// the previously stored record when the key has been used before. A
+// concurrent request with the same key blocks on the unique index until the
+// first one finishes.
+func (r *IdempotencyRepository) Claim(tx *sqlx.Tx, userID int64, key string, requestHash string) (*models.IdempotencyRecord, error) {
+	result, err := tx.Exec(
+		`INSERT INTO idempotency_keys (user_id, key, request_hash) VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, key) DO NOTHING`,
+		userID, key, requestHash,
+	)
+	if err != nil {
+		if isSerializationFailure(err) {
+			return nil, ErrIdempotencyInProgress
+		}
+		return nil, err
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 1 {
+		return nil, nil
+	}
+
+	var record models.IdempotencyRecord
+	err = tx.Get(&record,
+		"SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2",
+		userID, key,
+	)
+	if err != nil {
+		if isSerializationFailure(err) {
+			return nil, ErrIdempotencyInProgress
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) Complete(tx *sqlx.Tx, userID int64, key string, statusCode int, body []byte) error {
+	_, err := tx.Exec(
+		"UPDATE idempotency_keys SET status_code = $1, response_body = $2 WHERE user_id = $3 AND key = $4",
+		statusCode, body, userID, key,
+	)
+	return err
+}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IInterestRepository interface {
+	GetProducts() ([]models.InterestProduct, error)
+	GetProduct(productID int64) (*models.InterestProduct, error)
+	CreateProduct(product *models.InterestProduct) error
+	UpdateProduct(product *models.InterestProduct) error
+	GetAttachments() ([]models.InterestAttachment, error)
+	UpsertAttachment(attachment *models.InterestAttachment) error
+	DeleteAttachment(attachmentID int64) error
+	GetEarners() ([]models.InterestEarner, error)
+	GetEarner(accountID int64) (*models.InterestEarner, error)
+	GetAccrual(accountID int64) (*models.InterestAccrual, error)
+	LockAccrual(tx *sqlx.Tx, accountID int64) (*models.InterestAccrual, error)
+	SaveAccrual(tx *sqlx.Tx, accrual *models.InterestAccrual) error
+	RecordAccrualDay(tx *sqlx.Tx, day *models.InterestAccrualDay) error
+}
+
+type InterestRepository struct {
+	database *sqlx.DB
+}
+
+func NewInterestRepository(db *sqlx.DB) *InterestRepository {
+	return &InterestRepository{database: db}
+}
+
+func (r *InterestRepository) GetProducts() ([]models.InterestProduct, error) {
+	var products []models.InterestProduct
+	err := r.database.Select(&products, "SELECT * FROM interest_products ORDER BY id")
+	return products, err
+}
+
+func (r *InterestRepository) GetProduct(productID int64) (*models.InterestProduct, error) {
+	var product models.InterestProduct
+	err := r.database.Get(&product, "SELECT * FROM interest_products WHERE id = $1", productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *InterestRepository) CreateProduct(product *models.InterestProduct) error {
+	return r.database.QueryRowx(
+		`INSERT INTO interest_products (name, currency, account_type, rate_bps, day_count, compounding, payout)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at, updated_at`,
+		product.Name, product.Currency, product.AccountType, product.RateBps, product.DayCount,
+		product.Compounding, product.Payout,
+	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+}
+
+// UpdateProduct changes a product's name, rate and conventions. Its currency
+// and account type never change.
+func (r *InterestRepository) UpdateProduct(product *models.InterestProduct) error {
+	err := r.database.QueryRowx(
+		`UPDATE interest_products
+		 SET name = $1, rate_bps = $2, day_count = $3, compounding = $4, payout = $5, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $6
+		 RETURNING created_at, updated_at`,
+		product.Name, product.RateBps, product.DayCount, product.Compounding, product.Payout, product.ID,
+	).Scan(&product.CreatedAt, &product.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+func (r *InterestRepository) GetAttachments() ([]models.InterestAttachment, error) {
+	var attachments []models.InterestAttachment
+	err := r.database.Select(&attachments, "SELECT * FROM interest_attachments ORDER BY id")
+	return attachments, err
+}
+
+// UpsertAttachment attaches the product, replacing what was attached to the
+// same account, or to the same user for the same currency and account type.
+// It returns sql.ErrNoRows when the user does not exist.
+func (r *InterestRepository) UpsertAttachment(attachment *models.InterestAttachment) error {
+	target := "(account_id) WHERE account_id IS NOT NULL"
+	if attachment.UserID != nil {
+		target = "(user_id, currency, account_type) WHERE user_id IS NOT NULL"
+	}
+	err := r.database.QueryRowx(
+		`INSERT INTO interest_attachments (product_id, user_id, account_id, currency, account_type)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT `+target+`
+		 DO UPDATE SET product_id = EXCLUDED.product_id, updated_at = CURRENT_TIMESTAMP
+		 RETURNING id, created_at, updated_at`,
+		attachment.ProductID, attachment.UserID, attachment.AccountID, attachment.Currency, attachment.AccountType,
+	).Scan(&attachment.ID, &attachment.CreatedAt, &attachment.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *InterestRepository) DeleteAttachment(attachmentID int64) error {
+	result, err := r.database.Exec("DELETE FROM interest_attachments WHERE id = $1", attachmentID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// earners picks, for each open account, its own attachment or else its
+// owner's for the account's currency and type.
+const earners = `
+	SELECT a.id AS account_id,
+	       COALESCE(own.product_id, owner.product_id) AS product_id,
+	       COALESCE(own.updated_at, owner.updated_at) AS attached_at
+	FROM accounts a
+	LEFT JOIN interest_attachments own ON own.account_id = a.id
+	LEFT JOIN interest_attachments owner
+	       ON owner.user_id = a.user_id AND owner.currency = a.currency AND owner.account_type = a.type
+	WHERE a.status = 'open' AND (own.id IS NOT NULL OR owner.id IS NOT NULL)`
+
+// GetEarners returns every open account that earns interest.
+func (r *InterestRepository) GetEarners() ([]models.InterestEarner, error) {
+	var accounts []models.InterestEarner
+	err := r.database.Select(&accounts, earners+" ORDER BY a.id")
+	return accounts, err
+}
+
+// GetEarner returns the product an account earns interest under, or
+// sql.ErrNoRows when it earns none.
+func (r *InterestRepository) GetEarner(accountID int64) (*models.InterestEarner, error) {
+	var earner models.InterestEarner
+	err := r.database.Get(&earner, earners+" AND a.id = $1", accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &earner, nil
+}
+
+// GetAccrual returns what an account has accrued, or sql.ErrNoRows before
+// its first accrual.
+func (r *InterestRepository) GetAccrual(accountID int64) (*models.InterestAccrual, error) {
+	var accrual models.InterestAccrual
+	err := r.database.Get(&accrual, "SELECT * FROM interest_accruals WHERE account_id = $1", accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &accrual, nil
+}
+
+// LockAccrual returns an account's accrual, creating it empty on first use,
+// and keeps it locked until tx ends.
+func (r *InterestRepository) LockAccrual(tx *sqlx.Tx, accountID int64) (*models.InterestAccrual, error) {
+	_, err := tx.Exec(
+		"INSERT INTO interest_accruals (account_id) VALUES ($1) ON CONFLICT (account_id) DO NOTHING",
+		accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var accrual models.InterestAccrual
+	err = tx.Get(&accrual, "SELECT * FROM interest_accruals WHERE account_id = $1 FOR UPDATE", accountID)
+	if err != nil {
+		return nil, err
+	}
+	return &accrual, nil
+}
+
+func (r *InterestRepository) SaveAccrual(tx *sqlx.Tx, accrual *models.InterestAccrual) error {
+	_, err := tx.Exec(
+		`UPDATE interest_accruals SET accrued = $1, compounded = $2, accrued_through = $3, updated_at = CURRENT_TIMESTAMP
+		 WHERE account_id = $4`,
+		accrual.Accrued, accrual.Compounded, accrual.AccruedThrough, accrual.AccountID,
+	)
+	return err
+}
+
+func (r *InterestRepository) RecordAccrualDay(tx *sqlx.Tx, day *models.InterestAccrualDay) error {
+	_, err := tx.Exec(
+		`INSERT INTO interest_accrual_days (account_id, day, product_id, base, rate_bps, amount, transaction_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		day.AccountID, day.Day.Format("2006-01-02"), day.ProductID, day.Base, day.RateBps, day.Amount, day.TransactionID,
+	)
+	return err
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrUnbalancedJournal = errors.New("journal entries do not sum to zero")
+
+type ILedgerRepository interface {
+	GetAccountByCode(tx *sqlx.Tx, code string) (*models.LedgerAccount, error)
+	GetOrCreateAccountLedger(tx *sqlx.Tx, account *models.Account) (*models.LedgerAccount, error)
+	GetOrCreateTermDepositLedger(tx *sqlx.Tx, deposit *models.TermDeposit) (*models.LedgerAccount, error)
+	GetOrCreateSystemAccount(tx *sqlx.Tx, code string, currency money.Currency) (*models.LedgerAccount, error)
+	PostJournal(tx *sqlx.Tx, transactionID int64, description string, entries []models.LedgerEntry) (int64, error)
+	GetAccountBalance(tx *sqlx.Tx, accountID int64) (money.Money, error)
+	GetAccountBalanceBefore(tx *sqlx.Tx, accountID int64, before time.Time) (money.Money, error)
+}
+
+type LedgerRepository struct {
+	database *sqlx.DB
+}
+
+func NewLedgerRepository(db *sqlx.DB) *LedgerRepository {
+	return &LedgerRepository{database: db}
+}
+
+func accountLedgerCode(accountID int64) string {
+	return fmt.Sprintf("account:%d", accountID)
+}
+
+func (r *LedgerRepository) GetAccountByCode(tx *sqlx.Tx, code string) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	err := tx.Get(&account, "SELECT * FROM ledger_accounts WHERE code = $1", code)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetOrCreateAccountLedger returns the liability ledger account that holds
+// what the bank owes on a customer account, creating it on first use.
+func (r *LedgerRepository) GetOrCreateAccountLedger(tx *sqlx.Tx, account *models.Account) (*models.LedgerAccount, error) {
+	code := accountLedgerCode(account.ID)
+	_, err := tx.Exec(
+		`INSERT INTO ledger_accounts (code, type, user_id, account_id, currency) VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (code) DO NOTHING`,
+		code, models.LedgerLiability, account.UserID, account.ID, account.Currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAccountByCode(tx, code)
+}
+
+func termDepositLedgerCode(depositID int64) string {
+	return fmt.Sprintf("term_deposit:%d", depositID)
+}
+
+// GetOrCreateTermDepositLedger returns the liability ledger account that
+// holds the money locked in a term deposit, creating it on first use.
+func (r *LedgerRepository) GetOrCreateTermDepositLedger(tx *sqlx.Tx, deposit *models.TermDeposit) (*models.LedgerAccount, error) {
+	code := termDepositLedgerCode(deposit.ID)
+	_, err := tx.Exec(
+		`INSERT INTO ledger_accounts (code, type, user_id, currency) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (code) DO NOTHING`,
+		code, models.LedgerLiability, deposit.UserID, deposit.Currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAccountByCode(tx, code)
+}
+
+// GetOrCreateSystemAccount returns the system account with the given code
+// in a currency, creating it on first use.
+func (r *LedgerRepository) GetOrCreateSystemAccount(tx *sqlx.Tx, code string, currency money.Currency) (*models.LedgerAccount, error) {
+	accountType, ok := models.SystemLedgerTypes[code]
+	if !ok {
+		return nil, fmt.Errorf("unknown system ledger account %q", code)
+	}
+
+	code = models.SystemLedgerCode(code, currency)
+	_, err := tx.Exec(
+		`INSERT INTO ledger_accounts (code, type, currency) VALUES ($1, $2, $3)
+		 ON CONFLICT (code) DO NOTHING`,
+		code, accountType, currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetAccountByCode(tx, code)
+}
+
+// PostJournal writes a journal and its entries. Entries must sum to zero
+// within each currency. The database enforces the same rule at commit time;
+// checking here gives a clearer error.
+func (r *LedgerRepository) PostJournal(tx *sqlx.Tx, transactionID int64, description string, entries []models.LedgerEntry) (int64, error) {
+	sums := make(map[money.Currency]int64)
+	for _, e := range entries {
+		sums[e.Amount.Currency] += e.Amount.Amount
+	}
+	if len(entries) < 2 {
+		return 0, ErrUnbalancedJournal
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return 0, ErrUnbalancedJournal
+		}
+	}
+
+	var journalID int64
+	err := tx.Get(&journalID,
+		`INSERT INTO journals (transaction_id, description) VALUES ($1, $2) RETURNING id`,
+		transactionID, description,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range entries {
+		_, err := tx.Exec(
+			`INSERT INTO ledger_entries (journal_id, account_id, amount) VALUES ($1, $2, $3)`,
+			journalID, e.AccountID, e.Amount,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return journalID, nil
+}
+
+// GetAccountBalance returns the raw debit-positive sum of an account's entries.
+func (r *LedgerRepository) GetAccountBalance(tx *sqlx.Tx, accountID int64) (money.Money, error) {
+	var balance money.Money
+	err := tx.Get(&balance, "SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account_id = $1", accountID)
+	return balance, err
+}
+
+// GetAccountBalanceBefore is GetAccountBalance counting only entries posted
+// before the given time.
+func (r *LedgerRepository) GetAccountBalanceBefore(tx *sqlx.Tx, accountID int64, before time.Time) (money.Money, error) {
+	var balance money.Money
+	err := tx.Get(&balance,
+		"SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account_id = $1 AND created_at < $2",
+		accountID, before,
+	)
+	return balance, err
+}
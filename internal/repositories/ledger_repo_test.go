@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// PostJournal checks the entries balance before writing anything, so these
+// run without a database.
+func TestPostJournal_RejectsUnbalancedJournals(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.LedgerEntry
+	}{
+		{"no entries", nil},
+		{"one entry", []models.LedgerEntry{models.Debit(1, money.New(100, money.USD))}},
+		{"debits exceed credits", []models.LedgerEntry{
+			models.Debit(1, money.New(100, money.USD)),
+			models.Credit(2, money.New(99, money.USD)),
+		}},
+		{"balanced only across currencies", []models.LedgerEntry{
+			models.Debit(1, money.New(100, money.USD)),
+			models.Credit(2, money.New(100, money.EUR)),
+		}},
+	}
+
+	repo := NewLedgerRepository(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.PostJournal(nil, 1, "test", tt.entries)
+			assert.Equal(t, ErrUnbalancedJournal, err)
+		})
+	}
+}
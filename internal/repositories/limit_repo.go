@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ILimitRepository interface {
+	GetLimits() ([]models.TransactionLimit, error)
+	GetLimitsFor(tx *sqlx.Tx, userID int64, operation models.TransactionType, currency money.Currency) ([]models.TransactionLimit, error)
+	UpsertLimit(limit *models.TransactionLimit) error
+	DeleteLimit(limitID int64) error
+	AddUsage(tx *sqlx.Tx, userID int64, operation models.TransactionType, amount money.Money, day time.Time) (*models.LimitUsage, error)
+	GetUsageSince(tx *sqlx.Tx, userID int64, operation models.TransactionType, currency money.Currency, since time.Time) (int64, error)
+}
+
+type LimitRepository struct {
+	database *sqlx.DB
+}
+
+func NewLimitRepository(db *sqlx.DB) *LimitRepository {
+	return &LimitRepository{database: db}
+}
+
+// limitWithValue fills in Amount or Count from the stored value.
+func limitWithValue(limit *models.TransactionLimit) {
+	if limit.Kind.IsCount() {
+		count := limit.Value
+		limit.Count = &count
+	} else {
+		limit.Amount = &money.Money{Amount: limit.Value, Currency: limit.Currency}
+	}
+}
+
+func (r *LimitRepository) GetLimits() ([]models.TransactionLimit, error) {
+	var limits []models.TransactionLimit
+	err := r.database.Select(&limits, "SELECT * FROM transaction_limits ORDER BY scope, role, user_id, operation, kind, currency")
+	if err != nil {
+		return nil, err
+	}
+	for i := range limits {
+		limitWithValue(&limits[i])
+	}
+	return limits, nil
+}
+
+// GetLimitsFor returns every limit that applies to the user for the
+// operation and currency: global ones, ones for the user's role and ones set
+// for the user.
+func (r *LimitRepository) GetLimitsFor(tx *sqlx.Tx, userID int64, operation models.TransactionType, currency money.Currency) ([]models.TransactionLimit, error) {
+	var limits []models.TransactionLimit
+	err := tx.Select(&limits,
+		`SELECT l.* FROM transaction_limits l
+		 JOIN users u ON u.id = $1
+		 WHERE l.operation = $2 AND l.currency = $3
+		   AND (l.scope = 'global'
+		        OR (l.scope = 'role' AND l.role = u.role)
+		        OR (l.scope = 'user' AND l.user_id = u.id))`,
+		userID, operation, currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range limits {
+		limitWithValue(&limits[i])
+	}
+	return limits, nil
+}
+
+// UpsertLimit creates the limit or replaces the value of the existing limit
+// with the same scope, target, operation, kind and currency. It returns
+// sql.ErrNoRows when a user limit names a user that does not exist.
+func (r *LimitRepository) UpsertLimit(limit *models.TransactionLimit) error {
+	err := r.database.QueryRowx(
+		`INSERT INTO transaction_limits (scope, role, user_id, operation, kind, currency, value)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (scope, COALESCE(role, ''), COALESCE(user_id, 0), operation, kind, currency)
+		 DO UPDATE SET value = EXCLUDED.value, updated_at = CURRENT_TIMESTAMP
+		 RETURNING id, created_at, updated_at`,
+		limit.Scope, limit.Role, limit.UserID, limit.Operation, limit.Kind, limit.Currency, limit.Value,
+	).Scan(&limit.ID, &limit.CreatedAt, &limit.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+	limitWithValue(limit)
+	return nil
+}
+
+func (r *LimitRepository) DeleteLimit(limitID int64) error {
+	result, err := r.database.Exec("DELETE FROM transaction_limits WHERE id = $1", limitID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AddUsage adds one transaction of amount to the user's usage for day and
+// returns the new totals. The row stays locked until tx ends.
+func (r *LimitRepository) AddUsage(tx *sqlx.Tx, userID int64, operation models.TransactionType, amount money.Money, day time.Time) (*models.LimitUsage, error) {
+	var usage models.LimitUsage
+	err := tx.Get(&usage,
+		`INSERT INTO limit_usage (user_id, operation, currency, day, amount, count)
+		 VALUES ($1, $2, $3, $4, $5, 1)
+		 ON CONFLICT (user_id, operation, currency, day)
+		 DO UPDATE SET amount = limit_usage.amount + EXCLUDED.amount, count = limit_usage.count + 1
+		 RETURNING amount, count`,
+		userID, operation, amount.Currency, day.Format("2006-01-02"), amount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// GetUsageSince sums the amount used from the day since onwards.
+func (r *LimitRepository) GetUsageSince(tx *sqlx.Tx, userID int64, operation models.TransactionType, currency money.Currency, since time.Time) (int64, error) {
+	var total int64
+	err := tx.Get(&total,
+		`SELECT COALESCE(SUM(amount), 0) FROM limit_usage
+		 WHERE user_id = $1 AND operation = $2 AND currency = $3 AND day >= $4`,
+		userID, operation, currency, since.Format("2006-01-02"),
+	)
+	return total, err
+}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ILoanRepository interface {
+	CreateLoan(loan *models.Loan) error
+	GetLoan(loanID int64) (*models.Loan, error)
+	GetLoanForUpdate(tx *sqlx.Tx, loanID int64) (*models.Loan, error)
+	GetLoansByUser(userID int64) ([]models.Loan, error)
+	GetLoans(status models.LoanStatus) ([]models.Loan, error)
+	UpdateLoan(tx *sqlx.Tx, loan *models.Loan) error
+	GetSchedule(loanID int64) ([]models.LoanInstallment, error)
+	GetUnpaidInstallments(tx *sqlx.Tx, loanID int64) ([]models.LoanInstallment, error)
+	ReplaceInstallments(tx *sqlx.Tx, loanID int64, fromSeq int, installments []models.LoanInstallment) error
+	UpdateInstallment(tx *sqlx.Tx, installment *models.LoanInstallment) error
+	GetLoansDue(day time.Time) ([]int64, error)
+}
+
+type LoanRepository struct {
+	database *sqlx.DB
+}
+
+func NewLoanRepository(db *sqlx.DB) *LoanRepository {
+	return &LoanRepository{database: db}
+}
+
+// loanWithCurrency stamps the loan's currency onto its amounts, since those
+// columns only store minor units.
+func loanWithCurrency(loan *models.Loan) *models.Loan {
+	loan.Principal.Currency = loan.Currency
+	loan.Outstanding.Currency = loan.Currency
+	loan.LateFee.Currency = loan.Currency
+	return loan
+}
+
+func (r *LoanRepository) CreateLoan(loan *models.Loan) error {
+	return r.database.QueryRowx(
+		`INSERT INTO loans (user_id, account_id, currency, principal, outstanding, term_months, method, status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, late_fee, created_at, updated_at`,
+		loan.UserID, loan.AccountID, loan.Currency, loan.Principal, loan.Outstanding, loan.TermMonths,
+		loan.Method, loan.Status,
+	).Scan(&loan.ID, &loan.LateFee, &loan.CreatedAt, &loan.UpdatedAt)
+}
+
+func (r *LoanRepository) GetLoan(loanID int64) (*models.Loan, error) {
+	var loan models.Loan
+	err := r.database.Get(&loan, "SELECT * FROM loans WHERE id = $1", loanID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return loanWithCurrency(&loan), nil
+}
+
+func (r *LoanRepository) GetLoanForUpdate(tx *sqlx.Tx, loanID int64) (*models.Loan, error) {
+	var loan models.Loan
+	err := tx.Get(&loan, "SELECT * FROM loans WHERE id = $1 FOR UPDATE", loanID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return loanWithCurrency(&loan), nil
+}
+
+func (r *LoanRepository) selectLoans(query string, args ...interface{}) ([]models.Loan, error) {
+	var loans []models.Loan
+	if err := r.database.Select(&loans, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range loans {
+		loanWithCurrency(&loans[i])
+	}
+	return loans, nil
+}
+
+func (r *LoanRepository) GetLoansByUser(userID int64) ([]models.Loan, error) {
+	return r.selectLoans("SELECT * FROM loans WHERE user_id = $1 ORDER BY id DESC", userID)
+}
+
+// GetLoans returns every loan in a status, or every loan when status is
+// empty, oldest first.
+func (r *LoanRepository) GetLoans(status models.LoanStatus) ([]models.Loan, error) {
+	if status == "" {
+		return r.selectLoans("SELECT * FROM loans ORDER BY id")
+	}
+	return r.selectLoans("SELECT * FROM loans WHERE status = $1 ORDER BY id", status)
+}
+
+// UpdateLoan saves what changes over a loan's life: its decision, its
+// disbursement, what is left to repay and its status.
+func (r *LoanRepository) UpdateLoan(tx *sqlx.Tx, loan *models.Loan) error {
+	return tx.QueryRowx(
+		`UPDATE loans SET outstanding = $1, rate_bps = $2, late_fee = $3, status = $4, decided_by = $5,
+		     decided_at = $6, disbursed_at = $7, disbursement_transaction_id = $8, closed_at = $9,
+		     updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $10
+		 RETURNING updated_at`,
+		loan.Outstanding, loan.RateBps, loan.LateFee, loan.Status, loan.DecidedBy, loan.DecidedAt,
+		loan.DisbursedAt, loan.DisbursementTransactionID, loan.ClosedAt, loan.ID,
+	).Scan(&loan.UpdatedAt)
+}
+
+// installmentWithCurrency stamps the loan's currency, joined in by the
+// queries below, onto an installment's amounts.
+func installmentWithCurrency(installment *models.LoanInstallment) *models.LoanInstallment {
+	installment.Principal.Currency = installment.Currency
+	installment.Interest.Currency = installment.Currency
+	installment.LateFee.Currency = installment.Currency
+	return installment
+}
+
+const selectInstallments = `SELECT i.*, l.currency FROM loan_installments i JOIN loans l ON l.id = i.loan_id`
+
+func selectInstallmentsWith(q sqlx.Queryer, query string, args ...interface{}) ([]models.LoanInstallment, error) {
+	var installments []models.LoanInstallment
+	if err := sqlx.Select(q, &installments, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range installments {
+		installmentWithCurrency(&installments[i])
+	}
+	return installments, nil
+}
+
+// GetSchedule returns every installment of a loan, paid or not, in order.
+func (r *LoanRepository) GetSchedule(loanID int64) ([]models.LoanInstallment, error) {
+	return selectInstallmentsWith(r.database, selectInstallments+" WHERE i.loan_id = $1 ORDER BY i.seq", loanID)
+}
+
+// GetUnpaidInstallments returns the installments of a loan still to be
+// collected, in order. The caller holds the loan's lock.
+func (r *LoanRepository) GetUnpaidInstallments(tx *sqlx.Tx, loanID int64) ([]models.LoanInstallment, error) {
+	return selectInstallmentsWith(tx,
+		selectInstallments+" WHERE i.loan_id = $1 AND i.status = 'due' ORDER BY i.seq",
+		loanID,
+	)
+}
+
+// ReplaceInstallments drops a loan's unpaid installments from fromSeq on and
+// writes installments in their place.
+func (r *LoanRepository) ReplaceInstallments(tx *sqlx.Tx, loanID int64, fromSeq int, installments []models.LoanInstallment) error {
+	_, err := tx.Exec(
+		"DELETE FROM loan_installments WHERE loan_id = $1 AND seq >= $2 AND status = 'due'",
+		loanID, fromSeq,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, installment := range installments {
+		_, err := tx.Exec(
+			`INSERT INTO loan_installments (loan_id, seq, due_date, principal, interest)
+			 VALUES ($1, $2, $3, $4, $5)`,
+			loanID, installment.Seq, installment.DueDate.Format("2006-01-02"), installment.Principal, installment.Interest,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateInstallment saves a collection attempt, a late fee or a payment.
+func (r *LoanRepository) UpdateInstallment(tx *sqlx.Tx, installment *models.LoanInstallment) error {
+	var lastAttempt *string
+	if installment.LastAttemptOn != nil {
+		day := installment.LastAttemptOn.Format("2006-01-02")
+		lastAttempt = &day
+	}
+	_, err := tx.Exec(
+		`UPDATE loan_installments SET late_fee = $1, status = $2, last_attempt_on = $3, transaction_id = $4, paid_at = $5
+		 WHERE loan_id = $6 AND seq = $7`,
+		installment.LateFee, installment.Status, lastAttempt, installment.TransactionID, installment.PaidAt,
+		installment.LoanID, installment.Seq,
+	)
+	return err
+}
+
+// GetLoansDue returns the active loans with an unpaid installment due by
+// day that has not been tried on day yet.
+func (r *LoanRepository) GetLoansDue(day time.Time) ([]int64, error) {
+	var loanIDs []int64
+	err := r.database.Select(&loanIDs,
+		`SELECT DISTINCT i.loan_id FROM loan_installments i JOIN loans l ON l.id = i.loan_id
+		 WHERE l.status = 'active' AND i.status = 'due' AND i.due_date <= $1
+		   AND (i.last_attempt_on IS NULL OR i.last_attempt_on < $1)
+		 ORDER BY i.loan_id`,
+		day.Format("2006-01-02"),
+	)
+	return loanIDs, err
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type INotificationRepository interface {
+	CreateNotification(tx *sqlx.Tx, notification *models.Notification) error
+	GetNotifications(userID int64, unreadOnly bool) ([]models.Notification, error)
+	MarkRead(userID int64, notificationID int64, at time.Time) (*models.Notification, error)
+}
+
+type NotificationRepository struct {
+	database *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{database: db}
+}
+
+// CreateNotification inserts the notification in tx, so it only appears if
+// what it reports commits.
+func (r *NotificationRepository) CreateNotification(tx *sqlx.Tx, notification *models.Notification) error {
+	return tx.QueryRowx(
+		`INSERT INTO notifications (user_id, kind, message, account_id)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		notification.UserID, notification.Kind, notification.Message, notification.AccountID,
+	).Scan(&notification.ID, &notification.CreatedAt)
+}
+
+// GetNotifications returns the user's notifications, newest first.
+func (r *NotificationRepository) GetNotifications(userID int64, unreadOnly bool) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.database.Select(&notifications,
+		`SELECT * FROM notifications
+		 WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		 ORDER BY id DESC`,
+		userID, unreadOnly,
+	)
+	return notifications, err
+}
+
+// MarkRead marks one of the user's notifications as read. A notification
+// already read keeps its original read_at.
+func (r *NotificationRepository) MarkRead(userID int64, notificationID int64, at time.Time) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.database.Get(&notification,
+		`UPDATE notifications SET read_at = COALESCE(read_at, $1)
+		 WHERE id = $2 AND user_id = $3
+		 RETURNING *`,
+		at, notificationID, userID,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
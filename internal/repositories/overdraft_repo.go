@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IOverdraftRepository interface {
+	GetOverdrafts(userID int64) ([]models.Overdraft, error)
+	GetOverdraft(tx *sqlx.Tx, userID int64, currency money.Currency) (*models.Overdraft, error)
+	DrawOverdraft(tx *sqlx.Tx, userID int64, currency money.Currency, now time.Time) (*models.Overdraft, error)
+	GetUsedElsewhere(tx *sqlx.Tx, userID int64, currency money.Currency, accountID int64) (money.Money, error)
+	UpsertOverdraft(overdraft *models.Overdraft) error
+	GetAccountsDueInterest(day time.Time) ([]int64, error)
+	InterestCharged(tx *sqlx.Tx, accountID int64, day time.Time) (bool, error)
+	RecordInterest(tx *sqlx.Tx, accountID int64, day time.Time, amount money.Money, transactionID int64) error
+}
+
+type OverdraftRepository struct {
+	database *sqlx.DB
+}
+
+func NewOverdraftRepository(db *sqlx.DB) *OverdraftRepository {
+	return &OverdraftRepository{database: db}
+}
+
+// overdraftUsed sums how far the available balances of a user's checking
+// accounts in the overdraft's currency are below zero.
+const overdraftUsed = `COALESCE((
+	SELECT SUM(GREATEST(a.held - a.balance, 0)) FROM accounts a
+	WHERE a.user_id = o.user_id AND a.currency = o.currency AND a.type = 'checking'
+), 0) AS used`
+
+// overdraftWithCurrency stamps the overdraft's currency onto its amounts and
+// derives what remains of it.
+func overdraftWithCurrency(overdraft *models.Overdraft) *models.Overdraft {
+	overdraft.Limit.Currency = overdraft.Currency
+	overdraft.Used.Currency = overdraft.Currency
+	overdraft.Remaining = money.New(0, overdraft.Currency)
+	if overdraft.Used.Cmp(overdraft.Limit) < 0 {
+		overdraft.Remaining = overdraft.Limit.Sub(overdraft.Used)
+	}
+	return overdraft
+}
+
+func (r *OverdraftRepository) GetOverdrafts(userID int64) ([]models.Overdraft, error) {
+	var overdrafts []models.Overdraft
+	err := r.database.Select(&overdrafts,
+		"SELECT o.*, "+overdraftUsed+" FROM overdrafts o WHERE o.user_id = $1 ORDER BY o.currency",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range overdrafts {
+		overdraftWithCurrency(&overdrafts[i])
+	}
+	return overdrafts, nil
+}
+
+func (r *OverdraftRepository) GetOverdraft(tx *sqlx.Tx, userID int64, currency money.Currency) (*models.Overdraft, error) {
+	var overdraft models.Overdraft
+	err := tx.Get(&overdraft,
+		"SELECT o.*, "+overdraftUsed+" FROM overdrafts o WHERE o.user_id = $1 AND o.currency = $2",
+		userID, currency,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return overdraftWithCurrency(&overdraft), nil
+}
+
+// DrawOverdraft marks the overdraft as drawn on and keeps it locked until tx
+// ends. Used is not filled in; see GetUsedElsewhere. It returns
+// sql.ErrNoRows when the user has no overdraft in the currency.
+func (r *OverdraftRepository) DrawOverdraft(tx *sqlx.Tx, userID int64, currency money.Currency, now time.Time) (*models.Overdraft, error) {
+	var overdraft models.Overdraft
+	err := tx.Get(&overdraft,
+		`UPDATE overdrafts SET last_drawn_at = $1
+		 WHERE user_id = $2 AND currency = $3
+		 RETURNING *`,
+		now, userID, currency,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return overdraftWithCurrency(&overdraft), nil
+}
+
+// GetUsedElsewhere returns how much of the user's overdraft in the currency
+// is used by accounts other than accountID.
+func (r *OverdraftRepository) GetUsedElsewhere(tx *sqlx.Tx, userID int64, currency money.Currency, accountID int64) (money.Money, error) {
+	used := money.New(0, currency)
+	err := tx.Get(&used.Amount,
+		`SELECT COALESCE(SUM(GREATEST(held - balance, 0)), 0) FROM accounts
+		 WHERE user_id = $1 AND currency = $2 AND type = 'checking' AND id <> $3`,
+		userID, currency, accountID,
+	)
+	return used, err
+}
+
+// UpsertOverdraft creates or replaces the user's overdraft in its currency.
+// It returns sql.ErrNoRows when the user does not exist.
+func (r *OverdraftRepository) UpsertOverdraft(overdraft *models.Overdraft) error {
+	err := r.database.QueryRowx(
+		`INSERT INTO overdrafts (user_id, currency, limit_amount, interest_bps)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (user_id, currency)
+		 DO UPDATE SET limit_amount = EXCLUDED.limit_amount, interest_bps = EXCLUDED.interest_bps,
+		               updated_at = CURRENT_TIMESTAMP
+		 RETURNING last_drawn_at, created_at, updated_at`,
+		overdraft.UserID, overdraft.Currency, overdraft.Limit, overdraft.InterestBps,
+	).Scan(&overdraft.LastDrawnAt, &overdraft.CreatedAt, &overdraft.UpdatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
+
+// GetAccountsDueInterest returns the overdrawn accounts with an interest
+// bearing overdraft that have not been charged for day.
+func (r *OverdraftRepository) GetAccountsDueInterest(day time.Time) ([]int64, error) {
+	var ids []int64
+	err := r.database.Select(&ids,
+		`SELECT a.id FROM accounts a
+		 JOIN overdrafts o ON o.user_id = a.user_id AND o.currency = a.currency
+		 WHERE a.balance < 0 AND o.interest_bps > 0
+		   AND NOT EXISTS (SELECT 1 FROM overdraft_interest i WHERE i.account_id = a.id AND i.day = $1)
+		 ORDER BY a.id`,
+		day.Format("2006-01-02"),
+	)
+	return ids, err
+}
+
+func (r *OverdraftRepository) InterestCharged(tx *sqlx.Tx, accountID int64, day time.Time) (bool, error) {
+	var charged bool
+	err := tx.Get(&charged,
+		"SELECT EXISTS (SELECT 1 FROM overdraft_interest WHERE account_id = $1 AND day = $2)",
+		accountID, day.Format("2006-01-02"),
+	)
+	return charged, err
+}
+
+func (r *OverdraftRepository) RecordInterest(tx *sqlx.Tx, accountID int64, day time.Time, amount money.Money, transactionID int64) error {
+	_, err := tx.Exec(
+		"INSERT INTO overdraft_interest (account_id, day, amount, transaction_id) VALUES ($1, $2, $3, $4)",
+		accountID, day.Format("2006-01-02"), amount, transactionID,
+	)
+	return err
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrPayeeExists means the user already has a payee with that name.
+var ErrPayeeExists = errors.New("payee name is already in use")
+
+type IPayeeRepository interface {
+	GetPayeesByUser(userID int64) ([]models.Payee, error)
+	GetPayee(payeeID int64) (*models.Payee, error)
+	CreatePayee(payee *models.Payee) error
+	DeletePayee(userID int64, payeeID int64) error
+}
+
+type PayeeRepository struct {
+	database *sqlx.DB
+}
+
+func NewPayeeRepository(db *sqlx.DB) *PayeeRepository {
+	return &PayeeRepository{database: db}
+}
+
+// payeeColumns selects a payee with the number and currency of its account
+// and the name of the account's owner.
+const payeeColumns = `p.id, p.user_id, p.name, p.account_id, p.created_at,
+	a.number AS account_number, a.currency, u.name AS display_name
+	FROM payees p
+	JOIN accounts a ON a.id = p.account_id
+	JOIN users u ON u.id = a.user_id`
+
+func (r *PayeeRepository) GetPayeesByUser(userID int64) ([]models.Payee, error) {
+	var payees []models.Payee
+	err := r.database.Select(&payees, "SELECT "+payeeColumns+" WHERE p.user_id = $1 ORDER BY p.name", userID)
+	return payees, err
+}
+
+func (r *PayeeRepository) GetPayee(payeeID int64) (*models.Payee, error) {
+	var payee models.Payee
+	err := r.database.Get(&payee, "SELECT "+payeeColumns+" WHERE p.id = $1", payeeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &payee, nil
+}
+
+// CreatePayee inserts the payee and fills in its ID and creation time. It
+// returns ErrPayeeExists when the user already has a payee by that name.
+func (r *PayeeRepository) CreatePayee(payee *models.Payee) error {
+	err := r.database.QueryRowx(
+		"INSERT INTO payees (user_id, name, account_id) VALUES ($1, $2, $3) RETURNING id, created_at",
+		payee.UserID, payee.Name, payee.AccountID,
+	).Scan(&payee.ID, &payee.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrPayeeExists
+	}
+	return err
+}
+
+// DeletePayee removes one of the user's payees. It returns sql.ErrNoRows
+// when the user has no such payee.
+func (r *PayeeRepository) DeletePayee(userID int64, payeeID int64) error {
+	result, err := r.database.Exec("DELETE FROM payees WHERE id = $1 AND user_id = $2", payeeID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
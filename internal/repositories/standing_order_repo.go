@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IStandingOrderRepository interface {
+	CreateStandingOrder(order *models.StandingOrder) error
+	GetStandingOrdersByUser(userID int64) ([]models.StandingOrder, error)
+	GetStandingOrder(orderID int64) (*models.StandingOrder, error)
+	GetStandingOrderForUpdate(tx *sqlx.Tx, orderID int64) (*models.StandingOrder, error)
+	UpdateStandingOrder(tx *sqlx.Tx, order *models.StandingOrder) error
+	LockNextDue(tx *sqlx.Tx, now time.Time) (*models.StandingOrder, error)
+	RecordExecution(tx *sqlx.Tx, execution *models.StandingOrderExecution) error
+	GetExecutions(orderID int64) ([]models.StandingOrderExecution, error)
+}
+
+type StandingOrderRepository struct {
+	database *sqlx.DB
+}
+
+func NewStandingOrderRepository(db *sqlx.DB) *StandingOrderRepository {
+	return &StandingOrderRepository{database: db}
+}
+
+func orderWithCurrency(order *models.StandingOrder) *models.StandingOrder {
+	order.Amount.Currency = order.Currency
+	return order
+}
+
+func (r *StandingOrderRepository) CreateStandingOrder(order *models.StandingOrder) error {
+	return r.database.QueryRowx(
+		`INSERT INTO standing_orders
+			(user_id, from_account_id, to_account_id, amount, currency, frequency, start_date, end_date,
+			 max_occurrences, next_run_at, status, description)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING id, created_at, updated_at`,
+		order.UserID, order.FromAccountID, order.ToAccountID, order.Amount, order.Currency, order.Frequency,
+		order.StartDate, order.EndDate, order.MaxOccurrences, order.NextRunAt, order.Status, order.Description,
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
+}
+
+func (r *StandingOrderRepository) GetStandingOrdersByUser(userID int64) ([]models.StandingOrder, error) {
+	var orders []models.StandingOrder
+	err := r.database.Select(&orders, "SELECT * FROM standing_orders WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		orderWithCurrency(&orders[i])
+	}
+	return orders, nil
+}
+
+func (r *StandingOrderRepository) GetStandingOrder(orderID int64) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	err := r.database.Get(&order, "SELECT * FROM standing_orders WHERE id = $1", orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return orderWithCurrency(&order), nil
+}
+
+func (r *StandingOrderRepository) GetStandingOrderForUpdate(tx *sqlx.Tx, orderID int64) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	err := tx.Get(&order, "SELECT * FROM standing_orders WHERE id = $1 FOR UPDATE", orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return orderWithCurrency(&order), nil
+}
+
+// UpdateStandingOrder saves the mutable fields of an order.
+func (r *StandingOrderRepository) UpdateStandingOrder(tx *sqlx.Tx, order *models.StandingOrder) error {
+	_, err := tx.Exec(
+		`UPDATE standing_orders
+		 SET amount = $1, end_date = $2, max_occurrences = $3, occurrence = $4, next_run_at = $5,
+		     retry_count = $6, status = $7, description = $8, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $9`,
+		order.Amount, order.EndDate, order.MaxOccurrences, order.Occurrence, order.NextRunAt,
+		order.RetryCount, order.Status, order.Description, order.ID,
+	)
+	return err
+}
+
+// LockNextDue locks one active order that is due, skipping orders another
+// worker is already executing. It returns sql.ErrNoRows when nothing is due.
+func (r *StandingOrderRepository) LockNextDue(tx *sqlx.Tx, now time.Time) (*models.StandingOrder, error) {
+	var order models.StandingOrder
+	err := tx.Get(&order,
+		`SELECT * FROM standing_orders
+		 WHERE status = 'active' AND next_run_at <= $1
+		 ORDER BY next_run_at, id
+		 LIMIT 1
+		 FOR UPDATE SKIP LOCKED`,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return orderWithCurrency(&order), nil
+}
+
+func (r *StandingOrderRepository) RecordExecution(tx *sqlx.Tx, execution *models.StandingOrderExecution) error {
+	return tx.QueryRowx(
+		`INSERT INTO standing_order_executions
+			(standing_order_id, occurrence, attempt, scheduled_for, status, transaction_id, error)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, executed_at`,
+		execution.StandingOrderID, execution.Occurrence, execution.Attempt, execution.ScheduledFor,
+		execution.Status, execution.TransactionID, execution.Error,
+	).Scan(&execution.ID, &execution.ExecutedAt)
+}
+
+func (r *StandingOrderRepository) GetExecutions(orderID int64) ([]models.StandingOrderExecution, error) {
+	var executions []models.StandingOrderExecution
+	err := r.database.Select(&executions,
+		"SELECT * FROM standing_order_executions WHERE standing_order_id = $1 ORDER BY id", orderID)
+	return executions, err
+}
@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrStatementExists means the account already has a statement for the
+// period.
+var ErrStatementExists = errors.New("statement already exists for this period")
+
+type IStatementRepository interface {
+	GetBalanceBefore(tx *sqlx.Tx, accountID int64, before time.Time) (money.Money, error)
+	GetMovements(tx *sqlx.Tx, accountID int64, from time.Time, to time.Time) ([]models.StatementLine, error)
+	GetMovementsPage(tx *sqlx.Tx, accountID int64, from time.Time, to time.Time, after *models.StatementLine, limit int) ([]models.StatementLine, error)
+	GetStatementsByAccount(accountID int64) ([]models.StatementSummary, error)
+	GetStatement(statementID int64) (*models.Statement, error)
+	GetStatementForPeriod(accountID int64, from time.Time, to time.Time) (*models.Statement, error)
+	GetAccountsWithoutStatement(from time.Time, to time.Time) ([]int64, error)
+	CreateStatement(tx *sqlx.Tx, statement *models.Statement) error
+	GetStatementPDF(userID int64, from time.Time) (*models.StatementPDF, error)
+	GetUsersWithoutStatementPDF(from time.Time, to time.Time) ([]int64, error)
+	CreateStatementPDF(statement *models.StatementPDF) error
+}
+
+type StatementRepository struct {
+	database *sqlx.DB
+}
+
+func NewStatementRepository(db *sqlx.DB) *StatementRepository {
+	return &StatementRepository{database: db}
+}
+
+// statementWithCurrency stamps the statement's currency onto its balances,
+// totals and lines, since those columns only store minor units.
+func statementWithCurrency(statement *models.Statement) *models.Statement {
+	currency := statement.Currency
+	statement.OpeningBalance.Currency = currency
+	statement.TotalIn.Currency = currency
+	statement.TotalOut.Currency = currency
+	statement.ClosingBalance.Currency = currency
+	for i := range statement.Lines {
+		statement.Lines[i].Amount.Currency = currency
+		statement.Lines[i].Balance.Currency = currency
+	}
+	return statement
+}
+
+// GetBalanceBefore returns an account's balance from its ledger, counting
+// only entries posted before the given time. Unlike the raw ledger sum it is
+// positive when the bank owes the customer.
+func (r *StatementRepository) GetBalanceBefore(tx *sqlx.Tx, accountID int64, before time.Time) (money.Money, error) {
+	var balance money.Money
+	err := tx.Get(&balance,
+		`SELECT COALESCE(-SUM(e.amount), 0) FROM ledger_entries e
+		 JOIN ledger_accounts la ON la.id = e.account_id
+		 WHERE la.account_id = $1 AND e.created_at < $2`,
+		accountID, before,
+	)
+	return balance, err
+}
+
+// movementsQuery selects what each journal posted from $2 up to $3 did to
+// account $1, oldest first. Journals that left the account unchanged are
+// left out.
+const movementsQuery = `SELECT j.id AS journal_id, j.transaction_id, t.type, j.description,
+		MIN(e.created_at) AS posted_at, -SUM(e.amount) AS amount
+	 FROM ledger_entries e
+	 JOIN ledger_accounts la ON la.id = e.account_id
+	 JOIN journals j ON j.id = e.journal_id
+	 LEFT JOIN transactions t ON t.id = j.transaction_id
+	 WHERE la.account_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+	 GROUP BY j.id, t.type
+	 HAVING SUM(e.amount) <> 0`
+
+// GetMovements returns what each journal posted from from up to to did to
+// an account, oldest first, without running balances. Journals that left
+// the account unchanged are left out.
+func (r *StatementRepository) GetMovements(tx *sqlx.Tx, accountID int64, from time.Time, to time.Time) ([]models.StatementLine, error) {
+	var lines []models.StatementLine
+	err := tx.Select(&lines, movementsQuery+" ORDER BY posted_at, j.id", accountID, from, to)
+	return lines, err
+}
+
+// GetMovementsPage is GetMovements a page of at most limit movements at a
+// time, starting after the given one, or from the first when after is nil.
+func (r *StatementRepository) GetMovementsPage(tx *sqlx.Tx, accountID int64, from time.Time, to time.Time, after *models.StatementLine, limit int) ([]models.StatementLine, error) {
+	query := movementsQuery
+	args := []interface{}{accountID, from, to}
+	if after != nil {
+		query += " AND (MIN(e.created_at), j.id) > ($4, $5)"
+		args = append(args, after.PostedAt, after.JournalID)
+	}
+	query += fmt.Sprintf(" ORDER BY posted_at, j.id LIMIT %d", limit)
+
+	var lines []models.StatementLine
+	err := tx.Select(&lines, query, args...)
+	return lines, err
+}
+
+// statementColumns selects a statement with the number and IBAN of its
+// account.
+const statementColumns = `s.id, s.account_id, a.number AS account_number, a.iban, s.currency,
+	s.period_start, s.period_end, s.opening_balance, s.total_in, s.total_out, s.closing_balance, s.created_at
+	FROM statements s
+	JOIN accounts a ON a.id = s.account_id`
+
+func (r *StatementRepository) GetStatementsByAccount(accountID int64) ([]models.StatementSummary, error) {
+	var summaries []models.StatementSummary
+	err := r.database.Select(&summaries,
+		"SELECT "+statementColumns+" WHERE s.account_id = $1 ORDER BY s.period_start DESC", accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		statement := statementWithCurrency(&models.Statement{StatementSummary: summaries[i]})
+		summaries[i] = statement.StatementSummary
+	}
+	return summaries, nil
+}
+
+// getStatement loads the statement matching where, with its lines.
+func (r *StatementRepository) getStatement(where string, args ...interface{}) (*models.Statement, error) {
+	var statement models.Statement
+	err := r.database.Get(&statement.StatementSummary, "SELECT "+statementColumns+" WHERE "+where, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+
+	err = r.database.Select(&statement.Lines,
+		`SELECT journal_id, transaction_id, type, description, posted_at, amount, balance
+		 FROM statement_lines WHERE statement_id = $1 ORDER BY line_no`,
+		*statement.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if statement.Lines == nil {
+		statement.Lines = []models.StatementLine{}
+	}
+	return statementWithCurrency(&statement), nil
+}
+
+func (r *StatementRepository) GetStatement(statementID int64) (*models.Statement, error) {
+	return r.getStatement("s.id = $1", statementID)
+}
+
+// GetStatementForPeriod returns the stored statement of an account covering
+// exactly from up to to.
+func (r *StatementRepository) GetStatementForPeriod(accountID int64, from time.Time, to time.Time) (*models.Statement, error) {
+	return r.getStatement("s.account_id = $1 AND s.period_start = $2 AND s.period_end = $3", accountID, from, to)
+}
+
+// GetAccountsWithoutStatement returns the accounts that were open at some
+// point from from up to to and have no statement starting at from.
+func (r *StatementRepository) GetAccountsWithoutStatement(from time.Time, to time.Time) ([]int64, error) {
+	var accountIDs []int64
+	err := r.database.Select(&accountIDs,
+		`SELECT a.id FROM accounts a
+		 WHERE a.created_at < $2 AND (a.closed_at IS NULL OR a.closed_at >= $1)
+		   AND NOT EXISTS (SELECT 1 FROM statements s WHERE s.account_id = a.id AND s.period_start = $1)
+		 ORDER BY a.id`,
+		from, to,
+	)
+	return accountIDs, err
+}
+
+// CreateStatement stores a statement and its lines and fills in its ID and
+// creation time. It returns ErrStatementExists when the account already has
+// a statement starting at the same time.
+func (r *StatementRepository) CreateStatement(tx *sqlx.Tx, statement *models.Statement) error {
+	var id int64
+	err := tx.QueryRowx(
+		`INSERT INTO statements
+			(account_id, period_start, period_end, currency, opening_balance, total_in, total_out, closing_balance)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at`,
+		statement.AccountID, statement.From, statement.To, statement.Currency, statement.OpeningBalance,
+		statement.TotalIn, statement.TotalOut, statement.ClosingBalance,
+	).Scan(&id, &statement.GeneratedAt)
+	if isUniqueViolation(err) {
+		return ErrStatementExists
+	}
+	if err != nil {
+		return err
+	}
+	statement.ID = &id
+
+	for i, line := range statement.Lines {
+		_, err := tx.Exec(
+			`INSERT INTO statement_lines
+				(statement_id, line_no, journal_id, transaction_id, type, description, posted_at, amount, balance)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			id, i+1, line.JournalID, line.TransactionID, line.Type, line.Description, line.PostedAt,
+			line.Amount, line.Balance,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStatementPDF returns the user's statement PDF for the period starting
+// at from.
+func (r *StatementRepository) GetStatementPDF(userID int64, from time.Time) (*models.StatementPDF, error) {
+	var statement models.StatementPDF
+	err := r.database.Get(&statement,
+		"SELECT * FROM statement_pdfs WHERE user_id = $1 AND period_start = $2",
+		userID, from,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// GetUsersWithoutStatementPDF returns the users who had an account open at
+// some point from from up to to and have no statement PDF starting at from.
+func (r *StatementRepository) GetUsersWithoutStatementPDF(from time.Time, to time.Time) ([]int64, error) {
+	var userIDs []int64
+	err := r.database.Select(&userIDs,
+		`SELECT DISTINCT a.user_id FROM accounts a
+		 WHERE a.created_at < $2 AND (a.closed_at IS NULL OR a.closed_at >= $1)
+		   AND NOT EXISTS (SELECT 1 FROM statement_pdfs p WHERE p.user_id = a.user_id AND p.period_start = $1)
+		 ORDER BY a.user_id`,
+		from, to,
+	)
+	return userIDs, err
+}
+
+// CreateStatementPDF stores a statement PDF and fills in its ID and creation
+// time. It returns ErrStatementExists when the user already has one
+// starting at the same time.
+func (r *StatementRepository) CreateStatementPDF(statement *models.StatementPDF) error {
+	err := r.database.QueryRowx(
+		`INSERT INTO statement_pdfs (user_id, period_start, period_end, pdf)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		statement.UserID, statement.From, statement.To, statement.PDF,
+	).Scan(&statement.ID, &statement.GeneratedAt)
+	if isUniqueViolation(err) {
+		return ErrStatementExists
+	}
+	return err
+}
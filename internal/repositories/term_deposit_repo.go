@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ITermDepositRepository interface {
+	GetProducts(activeOnly bool) ([]models.TermDepositProduct, error)
+	GetProduct(productID int64) (*models.TermDepositProduct, error)
+	CreateProduct(product *models.TermDepositProduct) error
+	UpdateProduct(product *models.TermDepositProduct) error
+	CreateTermDeposit(tx *sqlx.Tx, deposit *models.TermDeposit) error
+	GetTermDeposit(depositID int64) (*models.TermDeposit, error)
+	GetTermDepositForUpdate(tx *sqlx.Tx, depositID int64) (*models.TermDeposit, error)
+	GetTermDepositsByUser(userID int64) ([]models.TermDeposit, error)
+	UpdateTermDeposit(tx *sqlx.Tx, deposit *models.TermDeposit) error
+	GetMaturedTermDeposits(day time.Time) ([]int64, error)
+}
+
+type TermDepositRepository struct {
+	database *sqlx.DB
+}
+
+func NewTermDepositRepository(db *sqlx.DB) *TermDepositRepository {
+	return &TermDepositRepository{database: db}
+}
+
+// termDepositWithCurrency stamps the deposit's currency onto its amounts.
+func termDepositWithCurrency(deposit *models.TermDeposit) *models.TermDeposit {
+	deposit.Principal.Currency = deposit.Currency
+	if deposit.Interest != nil {
+		deposit.Interest.Currency = deposit.Currency
+	}
+	if deposit.Penalty != nil {
+		deposit.Penalty.Currency = deposit.Currency
+	}
+	return deposit
+}
+
+// GetProducts returns the term deposit products, or only those taking new
+// deposits when activeOnly is set.
+func (r *TermDepositRepository) GetProducts(activeOnly bool) ([]models.TermDepositProduct, error) {
+	var products []models.TermDepositProduct
+	err := r.database.Select(&products,
+		"SELECT * FROM term_deposit_products WHERE active OR NOT $1 ORDER BY currency, term_months, id",
+		activeOnly,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		products[i].MinAmount.Currency = products[i].Currency
+	}
+	return products, nil
+}
+
+func (r *TermDepositRepository) GetProduct(productID int64) (*models.TermDepositProduct, error) {
+	var product models.TermDepositProduct
+	err := r.database.Get(&product, "SELECT * FROM term_deposit_products WHERE id = $1", productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	product.MinAmount.Currency = product.Currency
+	return &product, nil
+}
+
+func (r *TermDepositRepository) CreateProduct(product *models.TermDepositProduct) error {
+	return r.database.QueryRowx(
+		`INSERT INTO term_deposit_products (name, currency, term_months, rate_bps, penalty_bps, min_amount, active)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at, updated_at`,
+		product.Name, product.Currency, product.TermMonths, product.RateBps, product.PenaltyBps,
+		product.MinAmount, product.Active,
+	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+}
+
+// UpdateProduct changes a product's name, terms and whether it takes new
+// deposits. It returns sql.ErrNoRows when the product does not exist.
+func (r *TermDepositRepository) UpdateProduct(product *models.TermDepositProduct) error {
+	err := r.database.QueryRowx(
+		`UPDATE term_deposit_products
+		 SET name = $1, term_months = $2, rate_bps = $3, penalty_bps = $4, min_amount = $5, active = $6,
+		     updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $7
+		 RETURNING created_at, updated_at`,
+		product.Name, product.TermMonths, product.RateBps, product.PenaltyBps, product.MinAmount,
+		product.Active, product.ID,
+	).Scan(&product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return sql.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *TermDepositRepository) CreateTermDeposit(tx *sqlx.Tx, deposit *models.TermDeposit) error {
+	return tx.QueryRowx(
+		`INSERT INTO term_deposits
+			(user_id, account_id, product_id, currency, principal, rate_bps, penalty_bps, term_months,
+			 starts_on, matures_on, rollover, status, rolled_from_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING id, created_at, updated_at`,
+		deposit.UserID, deposit.AccountID, deposit.ProductID, deposit.Currency, deposit.Principal,
+		deposit.RateBps, deposit.PenaltyBps, deposit.TermMonths, deposit.StartsOn.Format("2006-01-02"),
+		deposit.MaturesOn.Format("2006-01-02"), deposit.Rollover, deposit.Status, deposit.RolledFromID,
+	).Scan(&deposit.ID, &deposit.CreatedAt, &deposit.UpdatedAt)
+}
+
+func (r *TermDepositRepository) GetTermDeposit(depositID int64) (*models.TermDeposit, error) {
+	var deposit models.TermDeposit
+	err := r.database.Get(&deposit, "SELECT * FROM term_deposits WHERE id = $1", depositID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return termDepositWithCurrency(&deposit), nil
+}
+
+func (r *TermDepositRepository) GetTermDepositForUpdate(tx *sqlx.Tx, depositID int64) (*models.TermDeposit, error) {
+	var deposit models.TermDeposit
+	err := tx.Get(&deposit, "SELECT * FROM term_deposits WHERE id = $1 FOR UPDATE", depositID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return termDepositWithCurrency(&deposit), nil
+}
+
+func (r *TermDepositRepository) GetTermDepositsByUser(userID int64) ([]models.TermDeposit, error) {
+	var deposits []models.TermDeposit
+	err := r.database.Select(&deposits, "SELECT * FROM term_deposits WHERE user_id = $1 ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range deposits {
+		termDepositWithCurrency(&deposits[i])
+	}
+	return deposits, nil
+}
+
+// UpdateTermDeposit saves the rollover choice and how the deposit ended.
+func (r *TermDepositRepository) UpdateTermDeposit(tx *sqlx.Tx, deposit *models.TermDeposit) error {
+	return tx.QueryRowx(
+		`UPDATE term_deposits
+		 SET rollover = $1, status = $2, interest = $3, penalty = $4, open_transaction_id = $5,
+		     close_transaction_id = $6, closed_at = $7, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = $8
+		 RETURNING updated_at`,
+		deposit.Rollover, deposit.Status, deposit.Interest, deposit.Penalty, deposit.OpenTransactionID,
+		deposit.CloseTransactionID, deposit.ClosedAt, deposit.ID,
+	).Scan(&deposit.UpdatedAt)
+}
+
+// GetMaturedTermDeposits returns the active deposits maturing on or before
+// day.
+func (r *TermDepositRepository) GetMaturedTermDeposits(day time.Time) ([]int64, error) {
+	var depositIDs []int64
+	err := r.database.Select(&depositIDs,
+		"SELECT id FROM term_deposits WHERE status = 'active' AND matures_on <= $1 ORDER BY matures_on, id",
+		day.Format("2006-01-02"),
+	)
+	return depositIDs, err
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"MockBankGo/internal/models"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ITokenRepository interface {
+	CreateRefreshToken(tx *sqlx.Tx, token *models.RefreshToken) error
+	GetRefreshTokenForUpdate(tx *sqlx.Tx, tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(tx *sqlx.Tx, id int64, now time.Time) error
+	GetFamilyByAccessToken(tx *sqlx.Tx, jti string) (string, error)
+	RevokeFamily(tx *sqlx.Tx, familyID string, now time.Time) error
+	RevokeUserTokens(tx *sqlx.Tx, userID int64, now time.Time) error
+	RevokeAccessToken(tx *sqlx.Tx, jti string, userID int64, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+	DeleteExpired(now time.Time) error
+}
+
+type TokenRepository struct {
+	database *sqlx.DB
+}
+
+func NewTokenRepository(db *sqlx.DB) *TokenRepository {
+	return &TokenRepository{database: db}
+}
+
+func (r *TokenRepository) CreateRefreshToken(tx *sqlx.Tx, token *models.RefreshToken) error {
+	return tx.QueryRowx(
+		`INSERT INTO refresh_tokens
+			(user_id, family_id, token_hash, access_token_id, access_expires_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		token.UserID, token.FamilyID, token.TokenHash, token.AccessTokenID, token.AccessExpiresAt, token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+func (r *TokenRepository) GetRefreshTokenForUpdate(tx *sqlx.Tx, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := tx.Get(&token, "SELECT * FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE", tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *TokenRepository) MarkRefreshTokenUsed(tx *sqlx.Tx, id int64, now time.Time) error {
+	_, err := tx.Exec("UPDATE refresh_tokens SET used_at = $1 WHERE id = $2", now, id)
+	return err
+}
+
+// GetFamilyByAccessToken finds the session an access token was issued in.
+func (r *TokenRepository) GetFamilyByAccessToken(tx *sqlx.Tx, jti string) (string, error) {
+	var familyID string
+	err := tx.Get(&familyID, "SELECT family_id FROM refresh_tokens WHERE access_token_id = $1", jti)
+	return familyID, err
+}
+
+// revokeRefreshTokens revokes the refresh tokens matched by where and adds
+// every access token issued with them that has not yet expired to the
+// revocation list.
+func (r *TokenRepository) revokeRefreshTokens(tx *sqlx.Tx, where string, arg interface{}, now time.Time) error {
+	_, err := tx.Exec(
+		`WITH revoked AS (
+			UPDATE refresh_tokens SET revoked_at = $2
+			WHERE `+where+` AND revoked_at IS NULL
+			RETURNING user_id, access_token_id, access_expires_at
+		)
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		SELECT access_token_id, user_id, access_expires_at, $2 FROM revoked
+		WHERE access_expires_at > $2
+		ON CONFLICT (jti) DO NOTHING`,
+		arg, now,
+	)
+	return err
+}
+
+func (r *TokenRepository) RevokeFamily(tx *sqlx.Tx, familyID string, now time.Time) error {
+	return r.revokeRefreshTokens(tx, "family_id = $1", familyID, now)
+}
+
+func (r *TokenRepository) RevokeUserTokens(tx *sqlx.Tx, userID int64, now time.Time) error {
+	return r.revokeRefreshTokens(tx, "user_id = $1", userID, now)
+}
+
+func (r *TokenRepository) RevokeAccessToken(tx *sqlx.Tx, jti string, userID int64, expiresAt time.Time) error {
+	_, err := tx.Exec(
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES ($1, $2, $3)
+		 ON CONFLICT (jti) DO NOTHING`,
+		jti, userID, expiresAt,
+	)
+	return err
+}
+
+func (r *TokenRepository) IsRevoked(jti string) (bool, error) {
+	var revoked bool
+	err := r.database.Get(&revoked, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti)
+	return revoked, err
+}
+
+// DeleteExpired drops revocations for access tokens that have expired anyway
+// and refresh tokens that can no longer be presented.
+func (r *TokenRepository) DeleteExpired(now time.Time) error {
+	if _, err := r.database.Exec("DELETE FROM revoked_tokens WHERE expires_at <= $1", now); err != nil {
+		return err
+	}
+	_, err := r.database.Exec("DELETE FROM refresh_tokens WHERE expires_at <= $1", now)
+	return err
+}
@@ -2,18 +2,33 @@ package repositories
 
 import (
 	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type ITransationRepository interface {
-	WriteTransaction(tx *sqlx.Tx, senderID int64, receiverID int64, amount float64, transactionType models.TransactionType, createdAt time.Time) error
-	IncreaseBalance(tx *sqlx.Tx, userID int64, amount float64) error
-	DecreaseBalance(tx *sqlx.Tx, userID int64, amount float64) error
-	GetTransactions() ([]models.TransactionInfo, error)
+	WriteTransaction(tx *sqlx.Tx, transaction *models.TransactionInfo) (int64, error)
+	IncreaseBalance(tx *sqlx.Tx, accountID int64, amount money.Money) error
+	DecreaseBalance(tx *sqlx.Tx, accountID int64, amount money.Money) error
+	GetTransactions(filter models.TransactionFilter) ([]models.TransactionInfo, error)
+	GetTransactionForUpdate(tx *sqlx.Tx, transactionID int64) (*models.TransactionInfo, error)
+	GetTransactionsByIDs(tx *sqlx.Tx, transactionIDs []int64) ([]models.TransactionInfo, error)
+	GetReversedTotals(tx *sqlx.Tx, transactionID int64) (sent money.Money, received money.Money, err error)
+	UpdateStatus(tx *sqlx.Tx, transactionID int64, from models.TransactionStatus, to models.TransactionStatus, reason *models.FailureReason, at time.Time) error
+	GetStatusHistory(transactionIDs []int64) ([]models.TransactionStatusChange, error)
 }
 
+// ErrStatusChanged means the transaction was no longer in the status an
+// update expected.
+var ErrStatusChanged = errors.New("transaction status changed concurrently")
+
 type TransactionRepository struct {
 	database *sqlx.DB
 }
@@ -22,29 +37,198 @@ func NewTransactionsRepo(db *sqlx.DB) *TransactionRepository {
 	return &TransactionRepository{database: db}
 }
 
-func (r *TransactionRepository) WriteTransaction(tx *sqlx.Tx, senderID int64, receiverID int64, amount float64, transactionType models.TransactionType, createdAt time.Time) error {
-	_, err := tx.Exec(
-		`INSERT INTO transactions (sender_id, receiver_id, amount, type, created_at) VALUES ($1, $2, $3, $4, $5)`,
-		senderID, receiverID, amount, transactionType, createdAt,
+// transactionWithCurrency stamps the currency columns onto the amounts,
+// which only store minor units.
+func transactionWithCurrency(t *models.TransactionInfo) *models.TransactionInfo {
+	t.Amount.Currency = t.Currency
+	if t.ToAmount != nil && t.ToCurrency != nil {
+		t.ToAmount.Currency = *t.ToCurrency
+	}
+	if t.FXRate != nil {
+		rate := trimDecimal(*t.FXRate)
+		t.FXRate = &rate
+	}
+	return t
+}
+
+// trimDecimal drops the trailing zeros NUMERIC columns are padded with.
+func trimDecimal(value string) string {
+	if !strings.Contains(value, ".") {
+		return value
+	}
+	return strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+}
+
+// WriteTransaction inserts the transaction as pending.
+func (r *TransactionRepository) WriteTransaction(tx *sqlx.Tx, t *models.TransactionInfo) (int64, error) {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	t.Currency = t.Amount.Currency
+	if t.ToAmount != nil {
+		t.ToCurrency = &t.ToAmount.Currency
+	}
+	t.Status = models.TransactionPending
+	t.StatusChangedAt = t.CreatedAt
+	err := tx.Get(&t.ID,
+		`INSERT INTO transactions
+			(sender_id, receiver_id, from_account_id, to_account_id, amount, currency, to_amount, to_currency,
+			 fx_rate, fx_quote_id, type, reversal_of, status, status_changed_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`,
+		t.SenderID, t.ReceiverID, t.FromAccountID, t.ToAccountID, t.Amount, t.Currency, t.ToAmount, t.ToCurrency,
+		t.FXRate, t.FXQuoteID, t.Type, t.ReversalOf, t.Status, t.StatusChangedAt, t.CreatedAt,
 	)
-	return err
+	return t.ID, err
 }
 
-func (r *TransactionRepository) IncreaseBalance(tx *sqlx.Tx, userID int64, amount float64) error {
-	_, err := tx.Exec("UPDATE users SET balance = balance + $1 WHERE id = $2", amount, userID)
+// UpdateStatus moves a transaction from one status to another, returning
+// ErrStatusChanged if it is no longer in the from status. The database
+// rejects transitions the lifecycle does not allow and records each change
+// in transaction_status_history.
+func (r *TransactionRepository) UpdateStatus(tx *sqlx.Tx, transactionID int64, from models.TransactionStatus, to models.TransactionStatus, reason *models.FailureReason, at time.Time) error {
+	result, err := tx.Exec(
+		`UPDATE transactions SET status = $1, failure_reason = $2, status_changed_at = $3
+		 WHERE id = $4 AND status = $5`,
+		to, reason, at, transactionID, from,
+	)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// GetStatusHistory returns every status change of the given transactions,
+// oldest first.
+func (r *TransactionRepository) GetStatusHistory(transactionIDs []int64) ([]models.TransactionStatusChange, error) {
+	var changes []models.TransactionStatusChange
+	err := r.database.Select(&changes,
+		"SELECT * FROM transaction_status_history WHERE transaction_id = ANY($1) ORDER BY changed_at, id",
+		pq.Array(transactionIDs),
+	)
+	return changes, err
+}
+
+func (r *TransactionRepository) IncreaseBalance(tx *sqlx.Tx, accountID int64, amount money.Money) error {
+	_, err := tx.Exec("UPDATE accounts SET balance = balance + $1 WHERE id = $2", amount, accountID)
 	return err
 }
 
-func (r *TransactionRepository) DecreaseBalance(tx *sqlx.Tx, userID int64, amount float64) error {
-	_, err := tx.Exec("UPDATE users SET balance = balance - $1 WHERE id = $2", amount, userID)
+func (r *TransactionRepository) DecreaseBalance(tx *sqlx.Tx, accountID int64, amount money.Money) error {
+	_, err := tx.Exec("UPDATE accounts SET balance = balance - $1 WHERE id = $2", amount, accountID)
 	return err
 }
 
-func (r *TransactionRepository) GetTransactions() ([]models.TransactionInfo, error) {
+// GetTransactions returns up to filter.Limit transactions matching the
+// filter in (created_at, id) order, starting after filter.After if set.
+func (r *TransactionRepository) GetTransactions(filter models.TransactionFilter) ([]models.TransactionInfo, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	switch {
+	case filter.UserID != nil && filter.CounterpartyID != nil:
+		u, c := arg(*filter.UserID), arg(*filter.CounterpartyID)
+		conditions = append(conditions, fmt.Sprintf(
+			"((sender_id = %s AND receiver_id = %s) OR (sender_id = %s AND receiver_id = %s))", u, c, c, u))
+	case filter.UserID != nil:
+		u := arg(*filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("(sender_id = %s OR receiver_id = %s)", u, u))
+	case filter.CounterpartyID != nil:
+		c := arg(*filter.CounterpartyID)
+		conditions = append(conditions, fmt.Sprintf("(sender_id = %s OR receiver_id = %s)", c, c))
+	}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "type = "+arg(filter.Type))
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = "+arg(filter.Status))
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < "+arg(*filter.To))
+	}
+	if filter.Currency != "" {
+		conditions = append(conditions, "currency = "+arg(filter.Currency))
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "amount >= "+arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "amount <= "+arg(*filter.MaxAmount))
+	}
+
+	direction, comparison := "DESC", "<"
+	if filter.Order == models.SortAsc {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) %s (%s, %s)",
+			comparison, arg(filter.After.CreatedAt), arg(filter.After.ID)))
+	}
+
+	query := "SELECT * FROM transactions"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY created_at %s, id %s LIMIT %s", direction, direction, arg(filter.Limit))
+
+	var transactions []models.TransactionInfo
+	err := r.database.Select(&transactions, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		transactionWithCurrency(&transactions[i])
+	}
+	return transactions, nil
+}
+
+func (r *TransactionRepository) GetTransactionForUpdate(tx *sqlx.Tx, transactionID int64) (*models.TransactionInfo, error) {
+	var transaction models.TransactionInfo
+	err := tx.Get(&transaction, "SELECT * FROM transactions WHERE id = $1 FOR UPDATE", transactionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return transactionWithCurrency(&transaction), nil
+}
+
+// GetTransactionsByIDs returns the transactions with the given IDs, in no
+// particular order.
+func (r *TransactionRepository) GetTransactionsByIDs(tx *sqlx.Tx, transactionIDs []int64) ([]models.TransactionInfo, error) {
 	var transactions []models.TransactionInfo
-	err := r.database.Select(&transactions, "SELECT * FROM transactions")
+	err := tx.Select(&transactions, "SELECT * FROM transactions WHERE id = ANY($1)", pq.Array(transactionIDs))
 	if err != nil {
 		return nil, err
 	}
+	for i := range transactions {
+		transactionWithCurrency(&transactions[i])
+	}
 	return transactions, nil
 }
+
+// GetReversedTotals sums every reversal and refund already made against a
+// transaction: what the original receiver sent back and what the original
+// sender got. The two differ only when the original was a currency exchange.
+func (r *TransactionRepository) GetReversedTotals(tx *sqlx.Tx, transactionID int64) (money.Money, money.Money, error) {
+	var sent, received money.Money
+	err := tx.QueryRowx(
+		`SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(COALESCE(to_amount, amount)), 0)
+		 FROM transactions WHERE reversal_of = $1 AND status = 'completed'`,
+		transactionID,
+	).Scan(&sent, &received)
+	return sent, received, err
+}
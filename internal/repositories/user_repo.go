@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"MockBankGo/auth"
 	"MockBankGo/internal/models"
 	"database/sql"
 
@@ -10,9 +11,10 @@ import (
 type IUserRepository interface {
 	GetUserByEmail(email string) (*models.User, error)
 	GetUserByUsername(username string) (*models.User, error)
-	CreateUser(user *models.User) error
+	CreateUser(user *models.User, account *models.Account) error
 	GetUsers() ([]models.User, error)
 	GetUserById(id int64) (*models.User, error)
+	UpdateUserRole(id int64, role auth.Role) error
 }
 
 type UserRepository struct {
@@ -35,15 +37,9 @@ func (r *UserRepository) GetUserById(userId int64) (*models.User, error) {
 	return &fetchedUser, nil
 }
 
-func (r *UserRepository) GetBalance(tx *sqlx.Tx, userID int64) (float64, error) {
-	var balance float64
-	err := tx.Get(&balance, "SELECT balance FROM users WHERE id = $1", userID)
-	return balance, err
-}
-
 func (r *UserRepository) GetUsers() ([]models.User, error) {
 	var users []models.User
-	err := r.database.Select(&users, "SELECT id, username, name, email, balance, role FROM users")
+	err := r.database.Select(&users, "SELECT id, username, name, email, role FROM users")
 	return users, err
 }
 
@@ -71,12 +67,25 @@ func (r *UserRepository) GetUserByUsername(username string) (*models.User, error
 	return &user, nil
 }
 
-func (r *UserRepository) CreateUser(user *models.User) error {
-	_, err := r.database.Exec(
-		`INSERT INTO users (username, name, email, password, balance, role) VALUES ($1, $2, $3, $4, $5, $6)`,
-		user.Username, user.Name, user.Email, user.Password, user.Balance, user.Role,
-	)
-	return err
+// CreateUser inserts the user together with their default account, which
+// already has its number and IBAN, and fills in both IDs and the account's
+// generated fields.
+func (r *UserRepository) CreateUser(user *models.User, account *models.Account) error {
+	err := r.database.QueryRowx(
+		`WITH new_user AS (
+			INSERT INTO users (username, name, email, password, role) VALUES ($1, $2, $3, $4, $5) RETURNING id
+		)
+		INSERT INTO accounts (user_id, type, currency, number, iban) SELECT id, $6, $7, $8, $9 FROM new_user
+		RETURNING user_id, id, balance, held, status, created_at`,
+		user.Username, user.Name, user.Email, user.Password, user.Role,
+		account.Type, account.Currency, account.Number, account.IBAN,
+	).Scan(&user.ID, &account.ID, &account.Balance, &account.Held, &account.Status, &account.CreatedAt)
+	if err != nil {
+		return err
+	}
+	account.UserID = user.ID
+	withCurrency(account)
+	return nil
 }
 
 func (r *UserRepository) GetUserByIdForUpdate(tx *sqlx.Tx, userID int64) (*models.User, error) {
@@ -90,3 +99,14 @@ func (r *UserRepository) GetUserByIdForUpdate(tx *sqlx.Tx, userID int64) (*model
 	}
 	return &user, nil
 }
+
+func (r *UserRepository) UpdateUserRole(userID int64, role auth.Role) error {
+	result, err := r.database.Exec("UPDATE users SET role = $1 WHERE id = $2", role, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/iban"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountService struct {
+	accountRepo repositories.IAccountRepository
+	ibanIssuer  iban.Issuer
+	database    *sqlx.DB
+}
+
+func NewAccountService(arepo repositories.IAccountRepository, ibanIssuer iban.Issuer, db *sqlx.DB) *AccountService {
+	return &AccountService{accountRepo: arepo, ibanIssuer: ibanIssuer, database: db}
+}
+
+func (s *AccountService) OpenAccount(userID int64, accountType models.AccountType, currency money.Currency) (*models.Account, error) {
+	if accountType == "" {
+		accountType = models.Checking
+	}
+	if !accountType.Valid() {
+		return nil, apperrors.ErrInvalidAccountType
+	}
+
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	if !currency.Valid() {
+		return nil, apperrors.ErrUnsupportedCurrency
+	}
+
+	account, err := s.newAccount(userID, accountType, currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accountRepo.CreateAccount(account); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return account, nil
+}
+
+// newAccount gives a new account its number and IBAN, ready to be stored.
+// A number whose account is never stored is simply skipped.
+func (s *AccountService) newAccount(userID int64, accountType models.AccountType, currency money.Currency) (*models.Account, error) {
+	number, err := s.accountRepo.NextAccountNumber()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	accountIBAN, err := s.ibanIssuer.IBAN(number)
+	if err != nil {
+		return nil, apperrors.ErrInternalServer
+	}
+
+	return &models.Account{
+		UserID:   userID,
+		Number:   number,
+		IBAN:     &accountIBAN,
+		Type:     accountType,
+		Currency: currency,
+	}, nil
+}
+
+func (s *AccountService) ListAccounts(userID int64) ([]models.Account, error) {
+	accounts, err := s.accountRepo.GetAccountsByUser(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if accounts == nil {
+		accounts = []models.Account{}
+	}
+	return accounts, nil
+}
+
+func (s *AccountService) GetAccount(userID int64, accountID int64) (*models.Account, error) {
+	account, err := s.accountRepo.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrAccountNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if account.UserID != userID {
+		return nil, apperrors.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// CloseAccount closes an empty account owned by the user. The row is kept so
+// historical transactions still resolve.
+func (s *AccountService) CloseAccount(userID int64, accountID int64) error {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	account, err := s.accountRepo.GetAccountForUpdate(tx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrAccountNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+
+	if account.UserID != userID {
+		return apperrors.ErrAccountNotFound
+	}
+	if account.Status == models.AccountClosed {
+		return apperrors.ErrAccountClosed
+	}
+	if !account.Balance.IsZero() {
+		return apperrors.ErrAccountNotEmpty
+	}
+	if !account.Held.IsZero() {
+		return apperrors.ErrAccountHasHolds
+	}
+	hasLoans, err := s.accountRepo.HasOpenLoans(tx, accountID)
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if hasLoans {
+		return apperrors.ErrAccountHasLoans
+	}
+	hasDeposits, err := s.accountRepo.HasActiveTermDeposits(tx, accountID)
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if hasDeposits {
+		return apperrors.ErrAccountHasTermDeposits
+	}
+
+	if err := s.accountRepo.CloseAccount(tx, accountID); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	return nil
+}
+
+// AssignIBANs gives every account opened before IBANs were issued its IBAN.
+// It runs at start-up.
+func (s *AccountService) AssignIBANs() error {
+	accounts, err := s.accountRepo.GetAccountsWithoutIBAN()
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		accountIBAN, err := s.ibanIssuer.IBAN(account.Number)
+		if err != nil {
+			return fmt.Errorf("account %d: %w", account.ID, err)
+		}
+		if err := s.accountRepo.SetIBAN(account.ID, accountIBAN); err != nil {
+			return fmt.Errorf("account %d: %w", account.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/repositories"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// verifyBatchSize is how many entries VerifyChain loads at a time.
+const verifyBatchSize = 1000
+
+type AuditService struct {
+	auditRepo repositories.IAuditRepository
+	database  *sqlx.DB
+}
+
+func NewAuditService(arepo repositories.IAuditRepository, db *sqlx.DB) *AuditService {
+	return &AuditService{auditRepo: arepo, database: db}
+}
+
+// auditHash is the SHA-256 of the previous hash and every recorded field of
+// the entry, in a fixed order.
+func auditHash(prevHash string, entry *models.AuditEntry) string {
+	var amount *int64
+	var currency string
+	if entry.Amount != nil {
+		amount = &entry.Amount.Amount
+		currency = string(entry.Amount.Currency)
+	}
+
+	canonical, _ := json.Marshal([]interface{}{
+		prevHash,
+		entry.Action,
+		entry.Outcome,
+		entry.ActorID,
+		entry.TargetType,
+		entry.TargetID,
+		amount,
+		currency,
+		entry.Error,
+		string(entry.Details),
+		entry.IP,
+		entry.UserAgent,
+		entry.RequestID,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// auditQueueBatchSize is how many queued entries AppendQueued moves onto the
+// chain in one transaction.
+const auditQueueBatchSize = 100
+
+type auditContextKey struct{}
+
+// WithAudit hands entry to the service carrying out a money action. When the
+// action succeeds the service queues the entry, completed with what the
+// action produced, in the same transaction as the ledger posting.
+func WithAudit(ctx context.Context, entry *models.AuditEntry) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, entry)
+}
+
+// stamp fills in the fields every entry gets when it is written.
+func stamp(entry *models.AuditEntry) {
+	if len(entry.Details) == 0 {
+		entry.Details = json.RawMessage("{}")
+	}
+	if entry.Amount != nil {
+		entry.Currency = &entry.Amount.Currency
+	}
+	// Postgres keeps microseconds; truncate so the stored time hashes the same.
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// withAuditDetail returns details with key set to value.
+func withAuditDetail(details json.RawMessage, key string, value interface{}) json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	json.Unmarshal(details, &fields)
+	fields[key], _ = json.Marshal(value)
+	raw, _ := json.Marshal(fields)
+	return raw
+}
+
+// chain links entry to the newest entry and appends it. tx must hold the
+// chain lock.
+func (s *AuditService) chain(tx *sqlx.Tx, entry *models.AuditEntry) error {
+	prevHash, err := s.auditRepo.GetLastHash(tx)
+	if err != nil {
+		return err
+	}
+	entry.PrevHash = prevHash
+	entry.Hash = auditHash(prevHash, entry)
+	return s.auditRepo.Insert(tx, entry)
+}
+
+// Record appends entry to the audit log, linking it to the newest entry.
+// Appends are serialised by a lock held until commit.
+func (s *AuditService) Record(entry *models.AuditEntry) error {
+	stamp(entry)
+
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	if err := s.auditRepo.LockChain(tx); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if err := s.chain(tx, entry); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// Queue writes entry to the audit queue inside tx, the transaction of the
+// action it records, so the entry is kept exactly when the action is.
+// AppendQueued later moves it onto the chain.
+func (s *AuditService) Queue(tx *sqlx.Tx, entry *models.AuditEntry) error {
+	stamp(entry)
+	if err := s.auditRepo.Enqueue(tx, entry); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// queueAudit queues the entry the request carries, if any, as a success
+// inside tx. complete fills in what the action produced. The caller's entry
+// is left untouched, so a commit that fails is still recorded as what was
+// asked for.
+func (s *AuditService) queueAudit(ctx context.Context, tx *sqlx.Tx, complete func(entry *models.AuditEntry)) error {
+	requested, ok := ctx.Value(auditContextKey{}).(*models.AuditEntry)
+	if !ok {
+		return nil
+	}
+
+	entry := *requested
+	entry.Outcome = models.AuditSuccess
+	complete(&entry)
+	return s.Queue(tx, &entry)
+}
+
+// AppendQueued moves queued entries onto the chain, oldest first. It runs
+// after each money action and as the audit-queue background job, which
+// picks up whatever an earlier run could not append.
+func (s *AuditService) AppendQueued(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		appended, err := s.appendQueuedBatch()
+		if err != nil {
+			return err
+		}
+		if appended < auditQueueBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *AuditService) appendQueuedBatch() (int, error) {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	// Taken before reading the queue, so two runs never append one entry twice.
+	if err := s.auditRepo.LockChain(tx); err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+
+	entries, err := s.auditRepo.ListQueued(tx, auditQueueBatchSize)
+	if err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(entries))
+	for i := range entries {
+		ids[i] = entries[i].ID
+		if err := s.chain(tx, &entries[i]); err != nil {
+			return 0, apperrors.ErrDatabaseError
+		}
+	}
+	if err := s.auditRepo.DeleteQueued(tx, ids); err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	return len(entries), nil
+}
+
+// Search returns one page of audit entries, newest first.
+func (s *AuditService) Search(filter models.AuditFilter) (*models.AuditPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	if filter.Cursor != "" {
+		beforeID, err := strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, apperrors.ErrInvalidCursor
+		}
+		filter.BeforeID = beforeID
+	}
+
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	entries, err := s.auditRepo.Search(filter)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	page := &models.AuditPage{Entries: entries}
+	if len(entries) > pageSize {
+		page.Entries = entries[:pageSize]
+		page.NextCursor = strconv.FormatInt(page.Entries[pageSize-1].ID, 10)
+	}
+	if page.Entries == nil {
+		page.Entries = []models.AuditEntry{}
+	}
+	return page, nil
+}
+
+// VerifyChain recomputes every hash from the first entry and reports the
+// first entry whose stored hash or link does not match.
+func (s *AuditService) VerifyChain() (*models.AuditVerification, error) {
+	result := &models.AuditVerification{Valid: true}
+	prevHash := ""
+	var afterID int64
+
+	for {
+		entries, err := s.auditRepo.ListAfter(afterID, verifyBatchSize)
+		if err != nil {
+			return nil, apperrors.ErrDatabaseError
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if entry.PrevHash != prevHash || auditHash(prevHash, entry) != entry.Hash {
+				result.Valid = false
+				result.BrokenAt = entry.ID
+				return result, nil
+			}
+			prevHash = entry.Hash
+			afterID = entry.ID
+			result.Entries++
+		}
+
+		if len(entries) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func auditEntry() *models.AuditEntry {
+	actorID := int64(7)
+	targetID := int64(42)
+	return &models.AuditEntry{
+		Action:     models.AuditTransfer,
+		Outcome:    models.AuditSuccess,
+		ActorID:    &actorID,
+		TargetType: models.AuditTargetTransaction,
+		TargetID:   &targetID,
+		Amount:     &money.Money{Amount: 1050, Currency: money.USD},
+		Details:    json.RawMessage(`{"from_account_id":1,"to_account_id":2}`),
+		IP:         "10.0.0.1",
+		UserAgent:  "curl/8.0",
+		RequestID:  "req-1",
+		CreatedAt:  time.Date(2025, 6, 23, 12, 0, 0, 123456000, time.UTC),
+	}
+}
+
+func TestAuditHash_Deterministic(t *testing.T) {
+	assert.Equal(t, auditHash("prev", auditEntry()), auditHash("prev", auditEntry()))
+	assert.Len(t, auditHash("", auditEntry()), 64)
+}
+
+func TestAuditHash_DetectsTampering(t *testing.T) {
+	original := auditHash("prev", auditEntry())
+
+	amount := auditEntry()
+	amount.Amount.Amount = 105000
+	assert.NotEqual(t, original, auditHash("prev", amount))
+
+	actor := auditEntry()
+	otherActor := int64(8)
+	actor.ActorID = &otherActor
+	assert.NotEqual(t, original, auditHash("prev", actor))
+
+	assert.NotEqual(t, original, auditHash("other", auditEntry()), "link to previous entry")
+}
+
+func TestAuditHash_IgnoresTimeZone(t *testing.T) {
+	entry := auditEntry()
+	local := auditEntry()
+	local.CreatedAt = entry.CreatedAt.In(time.FixedZone("UTC+5", 5*60*60))
+
+	assert.Equal(t, auditHash("prev", entry), auditHash("prev", local))
+}
+
+// fakeAuditLog keeps the audit queue and log in memory.
+type fakeAuditLog struct {
+	repositories.IAuditRepository
+	queue []models.AuditEntry
+	log   []models.AuditEntry
+}
+
+func (f *fakeAuditLog) LockChain(tx *sqlx.Tx) error {
+	return nil
+}
+
+func (f *fakeAuditLog) GetLastHash(tx *sqlx.Tx) (string, error) {
+	if len(f.log) == 0 {
+		return "", nil
+	}
+	return f.log[len(f.log)-1].Hash, nil
+}
+
+func (f *fakeAuditLog) Insert(tx *sqlx.Tx, entry *models.AuditEntry) error {
+	entry.ID = int64(len(f.log) + 1)
+	f.log = append(f.log, *entry)
+	return nil
+}
+
+func (f *fakeAuditLog) Enqueue(tx *sqlx.Tx, entry *models.AuditEntry) error {
+	entry.ID = int64(len(f.queue) + 1)
+	f.queue = append(f.queue, *entry)
+	return nil
+}
+
+func (f *fakeAuditLog) ListQueued(tx *sqlx.Tx, limit int) ([]models.AuditEntry, error) {
+	if len(f.queue) < limit {
+		limit = len(f.queue)
+	}
+	return append([]models.AuditEntry(nil), f.queue[:limit]...), nil
+}
+
+func (f *fakeAuditLog) DeleteQueued(tx *sqlx.Tx, ids []int64) error {
+	f.queue = f.queue[len(ids):]
+	return nil
+}
+
+func (f *fakeAuditLog) ListAfter(afterID int64, limit int) ([]models.AuditEntry, error) {
+	return f.log[afterID:], nil
+}
+
+func TestQueueAudit_QueuesACompletedCopy(t *testing.T) {
+	fake := &fakeAuditLog{}
+	service := NewAuditService(fake, txOnlyDB())
+	tx := txOnlyDB().MustBegin()
+	defer tx.Rollback()
+
+	requested := auditEntry()
+	requested.Outcome = ""
+	requested.TargetID = nil
+	transaction := &models.TransactionInfo{
+		ID:     99,
+		Amount: money.New(1050, money.USD),
+		Fees:   []models.TransactionFee{{Type: models.FeeTransfer, Amount: money.New(25, money.USD)}},
+	}
+
+	err := service.queueAudit(WithAudit(context.Background(), requested), tx, auditTransaction(transaction))
+	require.NoError(t, err)
+
+	require.Len(t, fake.queue, 1)
+	queued := fake.queue[0]
+	assert.Equal(t, models.AuditSuccess, queued.Outcome)
+	assert.Equal(t, int64(99), *queued.TargetID)
+	assert.JSONEq(t, `{"from_account_id":1,"to_account_id":2,"fees":[{"type":"transfer","amount":{"amount":"0.25","currency":"USD"}}]}`, string(queued.Details))
+
+	assert.Empty(t, requested.Outcome, "a failed commit is recorded from the request's own entry")
+	assert.Nil(t, requested.TargetID)
+}
+
+func TestQueueAudit_NothingToQueueWithoutAnEntry(t *testing.T) {
+	fake := &fakeAuditLog{}
+	tx := txOnlyDB().MustBegin()
+	defer tx.Rollback()
+
+	err := NewAuditService(fake, txOnlyDB()).queueAudit(context.Background(), tx, func(entry *models.AuditEntry) {})
+	require.NoError(t, err)
+	assert.Empty(t, fake.queue)
+
+	var missing *AuditService
+	assert.NoError(t, missing.queueAudit(context.Background(), tx, nil), "services built without an audit log")
+}
+
+func TestAppendQueued_ChainsEntriesInOrder(t *testing.T) {
+	fake := &fakeAuditLog{}
+	service := NewAuditService(fake, txOnlyDB())
+	require.NoError(t, service.Record(auditEntry()))
+
+	tx := txOnlyDB().MustBegin()
+	for _, action := range []string{models.AuditWithdraw, models.AuditDeposit} {
+		entry := auditEntry()
+		entry.Action = action
+		require.NoError(t, service.Queue(tx, entry))
+	}
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, service.AppendQueued(context.Background()))
+
+	assert.Empty(t, fake.queue)
+	require.Len(t, fake.log, 3)
+	assert.Equal(t, models.AuditWithdraw, fake.log[1].Action)
+	assert.Equal(t, models.AuditDeposit, fake.log[2].Action)
+	assert.Equal(t, fake.log[1].Hash, fake.log[2].PrevHash)
+
+	verification, err := service.VerifyChain()
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, int64(3), verification.Entries)
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/export"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// exportPageSize is how many movements an export reads at a time.
+const exportPageSize = 500
+
+var exportDescriptions = map[models.TransactionType]string{
+	models.Deposit:             "Deposit",
+	models.Withdraw:            "Withdrawal",
+	models.Transfer:            "Transfer",
+	models.Refund:              "Refund",
+	models.Reversal:            "Reversal",
+	models.Interest:            "Interest",
+	models.LoanDisbursement:    "Loan disbursement",
+	models.LoanRepayment:       "Loan repayment",
+	models.TermDepositOpen:     "Term deposit",
+	models.TermDepositPayout:   "Term deposit payout",
+	models.TermDepositRollover: "Term deposit rollover",
+}
+
+// exportEntry turns a movement of the account into an export entry. The
+// amount and time are what the ledger posted, fees included, so an export
+// adds up like a statement. The transaction behind the movement names it;
+// a journal without one, such as an opening balance carried over when the
+// ledger was introduced, keeps its own description and is identified by
+// its journal. Only fees the account paid are mentioned: those of a
+// transaction it is debited by, or of one that only credits it, as with
+// the penalty for breaking a term deposit.
+func exportEntry(line *models.StatementLine, t *models.TransactionInfo, accountID int64) export.Entry {
+	entry := export.Entry{
+		ID:          fmt.Sprintf("J%d", line.JournalID),
+		PostedAt:    line.PostedAt,
+		Amount:      line.Amount,
+		Description: line.Description,
+	}
+	if t == nil {
+		return entry
+	}
+
+	outgoing := t.FromAccountID != nil && *t.FromAccountID == accountID
+	fees := money.New(0, line.Amount.Currency)
+	if outgoing || t.FromAccountID == nil {
+		for _, fee := range t.Fees {
+			fees = fees.Add(fee.Amount)
+		}
+	}
+
+	description := exportDescriptions[t.Type]
+	switch {
+	case t.Type == models.Interest && outgoing:
+		description = "Overdraft interest"
+	case t.Type == models.Transfer && outgoing:
+		description = "Transfer out"
+	case t.Type == models.Transfer:
+		description = "Transfer in"
+	}
+	if !fees.IsZero() {
+		description += fmt.Sprintf(", fees %s", fees)
+	}
+
+	entry.ID = strconv.FormatInt(t.ID, 10)
+	entry.Type = t.Type
+	entry.Description = description
+	return entry
+}
+
+// statementMonth reads a statement PDF's period, a calendar month written
+// YYYY-MM, and returns when it starts and ends.
+func statementMonth(period string) (time.Time, time.Time, error) {
+	from, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, time.Time{}, apperrors.ErrInvalidStatementMonth
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
+
+// openDuring reports whether the account was open at some point from from
+// up to to.
+func openDuring(account *models.Account, from time.Time, to time.Time) bool {
+	return account.CreatedAt.Before(to) && (account.ClosedAt == nil || !account.ClosedAt.Before(from))
+}
+
+type ExportService struct {
+	transactionRepo repositories.ITransationRepository
+	statementRepo   repositories.IStatementRepository
+	accountRepo     repositories.IAccountRepository
+	userRepo        repositories.IUserRepository
+	feeService      *FeeService
+	bankID          string
+	bankName        string
+	database        *sqlx.DB
+}
+
+func NewExportService(trepo repositories.ITransationRepository, srepo repositories.IStatementRepository, arepo repositories.IAccountRepository, urepo repositories.IUserRepository, feeService *FeeService, bankID string, bankName string, db *sqlx.DB) *ExportService {
+	return &ExportService{transactionRepo: trepo, statementRepo: srepo, accountRepo: arepo, userRepo: urepo, feeService: feeService, bankID: bankID, bankName: bankName, database: db}
+}
+
+// PrepareExport checks that the user may export one of their accounts from
+// from up to to, which is cut off at now, and works out what goes at the
+// top of the export. Nothing has been written when it fails.
+func (s *ExportService) PrepareExport(ctx context.Context, userID int64, accountID int64, from time.Time, to time.Time, now time.Time) (*models.Account, *export.Statement, error) {
+	if to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		return nil, nil, apperrors.ErrInvalidStatementPeriod
+	}
+
+	account, err := s.accountRepo.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, apperrors.ErrAccountNotFound
+		}
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	if account.UserID != userID {
+		return nil, nil, apperrors.ErrAccountNotFound
+	}
+
+	return account, s.exportStatement(account, from, to, now), nil
+}
+
+// exportStatement works out what goes at the top of an export of the
+// account from from up to to. The opening balance is filled in by
+// WriteExport.
+func (s *ExportService) exportStatement(account *models.Account, from time.Time, to time.Time, now time.Time) *export.Statement {
+	statement := &export.Statement{
+		BankID:        s.bankID,
+		AccountNumber: account.Number,
+		AccountType:   account.Type,
+		Currency:      account.Currency,
+		From:          from,
+		To:            to,
+		GeneratedAt:   now,
+	}
+	if account.IBAN != nil {
+		statement.IBAN = *account.IBAN
+	}
+	return statement
+}
+
+// WriteExport writes everything the ledger posted to the account in the
+// statement's period, oldest first, starting from its opening balance. It
+// all comes from one snapshot of the ledger, read a page at a time so an
+// export of any length is streamed, and the closing balance is checked
+// against the ledger before it is written.
+func (s *ExportService) WriteExport(ctx context.Context, account *models.Account, statement *export.Statement, w export.Writer) error {
+	tx, err := s.database.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	opening, err := s.statementRepo.GetBalanceBefore(tx, account.ID, statement.From)
+	if err != nil {
+		return err
+	}
+	statement.Opening = money.New(opening.Amount, account.Currency)
+	if err := w.Begin(*statement); err != nil {
+		return err
+	}
+
+	balance := statement.Opening
+	var after *models.StatementLine
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		lines, err := s.statementRepo.GetMovementsPage(tx, account.ID, statement.From, statement.To, after, exportPageSize)
+		if err != nil {
+			return err
+		}
+		transactions, err := s.movementTransactions(tx, lines)
+		if err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].Amount.Currency = account.Currency
+			var t *models.TransactionInfo
+			if lines[i].TransactionID != nil {
+				t = transactions[*lines[i].TransactionID]
+			}
+			entry := exportEntry(&lines[i], t, account.ID)
+			if err := w.Write(entry); err != nil {
+				return err
+			}
+			balance = balance.Add(entry.Amount)
+		}
+
+		if len(lines) < exportPageSize {
+			break
+		}
+		after = &lines[len(lines)-1]
+	}
+
+	closing, err := s.statementRepo.GetBalanceBefore(tx, account.ID, statement.To)
+	if err != nil {
+		return err
+	}
+	if closing.Amount != balance.Amount {
+		return fmt.Errorf("export of account %d does not add up: entries give %s, the ledger %d", account.ID, balance, closing.Amount)
+	}
+	return w.End(balance)
+}
+
+// movementTransactions loads the transactions behind the movements, with
+// their fees, by ID.
+func (s *ExportService) movementTransactions(tx *sqlx.Tx, lines []models.StatementLine) (map[int64]*models.TransactionInfo, error) {
+	var ids []int64
+	for _, line := range lines {
+		if line.TransactionID != nil {
+			ids = append(ids, *line.TransactionID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	transactions, err := s.transactionRepo.GetTransactionsByIDs(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.feeService.AttachFees(tx, transactions); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*models.TransactionInfo, len(transactions))
+	for i := range transactions {
+		byID[transactions[i].ID] = &transactions[i]
+	}
+	return byID, nil
+}
+
+// renderStatementPDF lays out the user's printable statement from from up
+// to to: a section for each account they had open at some point in it,
+// with the same entries as an export but the account number masked.
+func (s *ExportService) renderStatementPDF(ctx context.Context, userID int64, from time.Time, to time.Time, now time.Time) ([]byte, error) {
+	user, err := s.userRepo.GetUserById(userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.accountRepo.GetAccountsByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	w := export.NewPDFWriter(s.bankName, user.Name, fmt.Sprintf("%s statement %s", s.bankName, from.Format("January 2006")))
+	for i := range accounts {
+		account := &accounts[i]
+		if !openDuring(account, from, to) {
+			continue
+		}
+		statement := s.exportStatement(account, from, to, now)
+		statement.AccountNumber = maskAccountNumber(account.Number)
+		statement.IBAN = ""
+		if err := s.WriteExport(ctx, account, statement, w); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := w.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// StatementPDF returns the user's printable statement for period, a month
+// written YYYY-MM. A month the statement PDF job has been through is served
+// as it was issued; any other, including the current one up to now, is
+// rendered from the ledger and transactions as they are.
+func (s *ExportService) StatementPDF(ctx context.Context, userID int64, period string, now time.Time) ([]byte, error) {
+	from, to, err := statementMonth(period)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(now) {
+		return nil, apperrors.ErrInvalidStatementMonth
+	}
+
+	stored, err := s.statementRepo.GetStatementPDF(userID, from)
+	if err == nil {
+		return stored.PDF, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if to.After(now) {
+		to = now
+	}
+	pdf, err := s.renderStatementPDF(ctx, userID, from, to, now)
+	if err != nil {
+		if _, ok := err.(*apperrors.AppError); ok {
+			return nil, err
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return pdf, nil
+}
+
+// GenerateStatementPDFs renders and stores the statement PDF from from up
+// to to of every user who had an account open in that time and has none
+// yet. A user whose PDF fails is logged and counted, and the rest carry on.
+func (s *ExportService) GenerateStatementPDFs(ctx context.Context, from time.Time, to time.Time, now time.Time) (generated int, failed int, err error) {
+	userIDs, err := s.statementRepo.GetUsersWithoutStatementPDF(from, to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return generated, failed, err
+		}
+
+		pdf, err := s.renderStatementPDF(ctx, userID, from, to, now)
+		if err == nil {
+			err = s.statementRepo.CreateStatementPDF(&models.StatementPDF{UserID: userID, From: from, To: to, PDF: pdf})
+		}
+		switch {
+		case err == nil:
+			generated++
+		case err == repositories.ErrStatementExists:
+			// Generated by another run since the users were listed.
+		default:
+			log.Printf("statement PDFs: user %d: %v", userID, err)
+			failed++
+		}
+	}
+	return generated, failed, nil
+}
+
+// GenerateStatementPDFsForMonth is GenerateStatementPDFs for period, a month
+// written YYYY-MM, which must be over and settled.
+func (s *ExportService) GenerateStatementPDFsForMonth(ctx context.Context, period string, now time.Time) (int, int, error) {
+	from, to, err := statementMonth(period)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, last := lastStatementPeriod(now); to.After(last) {
+		return 0, 0, apperrors.ErrStatementMonthNotOver
+	}
+
+	generated, failed, err := s.GenerateStatementPDFs(ctx, from, to, now)
+	if err != nil {
+		return generated, failed, apperrors.ErrDatabaseError
+	}
+	return generated, failed, nil
+}
+
+// TakeMonthlyStatementPDFs generates the statement PDFs for the last month
+// once it has settled, like TakeMonthlyStatements. It is safe to run
+// repeatedly; users who already have theirs are skipped.
+func (s *ExportService) TakeMonthlyStatementPDFs(ctx context.Context, now time.Time) error {
+	from, to := lastStatementPeriod(now)
+	generated, failed, err := s.GenerateStatementPDFs(ctx, from, to, now)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d statement PDFs failed", failed, generated+failed)
+	}
+	return nil
+}
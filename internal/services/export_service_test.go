@@ -0,0 +1,223 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/export"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportEntry_DebitIncludesFees(t *testing.T) {
+	from, to := int64(1), int64(2)
+	transfer := &models.TransactionInfo{
+		ID:            57,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        money.New(12000, money.USD),
+		Type:          models.Transfer,
+		Fees:          []models.TransactionFee{{Type: models.FeeTransfer, Amount: money.New(150, money.USD)}},
+	}
+	line := &models.StatementLine{JournalID: 90, TransactionID: &transfer.ID, Amount: money.New(-12150, money.USD),
+		PostedAt: time.Date(2025, time.June, 14, 18, 2, 33, 0, time.UTC)}
+
+	entry := exportEntry(line, transfer, from)
+	assert.Equal(t, "57", entry.ID)
+	assert.Equal(t, models.Transfer, entry.Type)
+	assert.Equal(t, money.New(-12150, money.USD), entry.Amount)
+	assert.Equal(t, "Transfer out, fees 1.50", entry.Description)
+	assert.Equal(t, line.PostedAt, entry.PostedAt)
+}
+
+func TestExportEntry_CreditLeavesOutSendersFees(t *testing.T) {
+	from, to := int64(1), int64(2)
+	toAmount := money.New(9215, money.EUR)
+	transfer := &models.TransactionInfo{
+		ID:            58,
+		FromAccountID: &from,
+		ToAccountID:   &to,
+		Amount:        money.New(10000, money.USD),
+		ToAmount:      &toAmount,
+		Type:          models.Transfer,
+		Fees:          []models.TransactionFee{{Type: models.FeeFX, Amount: money.New(50, money.USD)}},
+	}
+	line := &models.StatementLine{JournalID: 91, TransactionID: &transfer.ID, Amount: money.New(9215, money.EUR)}
+
+	entry := exportEntry(line, transfer, to)
+	assert.Equal(t, money.New(9215, money.EUR), entry.Amount)
+	assert.Equal(t, "Transfer in", entry.Description)
+}
+
+func TestExportEntry_CreditLessPenalty(t *testing.T) {
+	account := int64(3)
+	payout := &models.TransactionInfo{
+		ID:          60,
+		ToAccountID: &account,
+		Amount:      money.New(101000, money.USD),
+		Type:        models.TermDepositPayout,
+		Fees:        []models.TransactionFee{{Type: models.FeeEarlyWithdrawal, Amount: money.New(1000, money.USD)}},
+	}
+	line := &models.StatementLine{JournalID: 92, TransactionID: &payout.ID, Amount: money.New(100000, money.USD)}
+
+	entry := exportEntry(line, payout, account)
+	assert.Equal(t, money.New(100000, money.USD), entry.Amount)
+	assert.Equal(t, "Term deposit payout, fees 10.00", entry.Description)
+}
+
+func TestExportEntry_OverdraftInterest(t *testing.T) {
+	account := int64(3)
+	interest := &models.TransactionInfo{
+		ID:            61,
+		FromAccountID: &account,
+		Amount:        money.New(42, money.USD),
+		Type:          models.Interest,
+	}
+	line := &models.StatementLine{JournalID: 93, TransactionID: &interest.ID, Amount: money.New(-42, money.USD)}
+
+	entry := exportEntry(line, interest, account)
+	assert.Equal(t, money.New(-42, money.USD), entry.Amount)
+	assert.Equal(t, "Overdraft interest", entry.Description)
+}
+
+func TestExportEntry_JournalWithoutTransaction(t *testing.T) {
+	line := &models.StatementLine{JournalID: 4, Description: "opening balance for user 7", Amount: money.New(5000, money.USD)}
+
+	entry := exportEntry(line, nil, 3)
+	assert.Equal(t, "J4", entry.ID)
+	assert.Equal(t, models.TransactionType(""), entry.Type)
+	assert.Equal(t, money.New(5000, money.USD), entry.Amount)
+	assert.Equal(t, "opening balance for user 7", entry.Description)
+}
+
+// fakeLedger is one account's ledger: its balance before the period, its
+// movements in it and its balance at the end.
+type fakeLedger struct {
+	repositories.IStatementRepository
+	repositories.ITransationRepository
+	repositories.IFeeRepository
+	opening      int64
+	closing      int64
+	lines        []models.StatementLine
+	transactions []models.TransactionInfo
+	feesReadBy   sqlx.Queryer
+}
+
+func (f *fakeLedger) GetBalanceBefore(tx *sqlx.Tx, accountID int64, before time.Time) (money.Money, error) {
+	if before.Equal(date(2025, time.June, 1)) {
+		return money.Money{Amount: f.opening}, nil
+	}
+	return money.Money{Amount: f.closing}, nil
+}
+
+func (f *fakeLedger) GetMovementsPage(tx *sqlx.Tx, accountID int64, from time.Time, to time.Time, after *models.StatementLine, limit int) ([]models.StatementLine, error) {
+	if after != nil {
+		return nil, nil
+	}
+	return append([]models.StatementLine(nil), f.lines...), nil
+}
+
+func (f *fakeLedger) GetTransactionsByIDs(tx *sqlx.Tx, transactionIDs []int64) ([]models.TransactionInfo, error) {
+	return f.transactions, nil
+}
+
+func (f *fakeLedger) GetFees(q sqlx.Queryer, transactionIDs []int64) ([]models.TransactionFee, error) {
+	f.feesReadBy = q
+	return nil, nil
+}
+
+// collectWriter keeps what an export writes.
+type collectWriter struct {
+	statement export.Statement
+	entries   []export.Entry
+	closing   *money.Money
+}
+
+func (c *collectWriter) Begin(statement export.Statement) error {
+	c.statement = statement
+	return nil
+}
+
+func (c *collectWriter) Write(entry export.Entry) error {
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+func (c *collectWriter) End(closing money.Money) error {
+	c.closing = &closing
+	return nil
+}
+
+func exportLedger(closing int64) *fakeLedger {
+	deposit := int64(41)
+	return &fakeLedger{
+		opening: 0,
+		closing: closing,
+		lines: []models.StatementLine{
+			{JournalID: 4, Description: "opening balance for user 7", Amount: money.Money{Amount: 100000},
+				PostedAt: date(2025, time.June, 2)},
+			{JournalID: 50, TransactionID: &deposit, Amount: money.Money{Amount: 25000},
+				PostedAt: date(2025, time.June, 3)},
+		},
+		transactions: []models.TransactionInfo{{ID: deposit, Amount: money.New(25000, money.USD), Type: models.Deposit}},
+	}
+}
+
+func TestWriteExport_ReadsTheLedger(t *testing.T) {
+	ledger := exportLedger(125000)
+	service := NewExportService(ledger, ledger, nil, nil, NewFeeService(ledger, nil), "12345678", "MockBank", txOnlyDB())
+	account := &models.Account{ID: 3, UserID: 7, Number: "001000000003", Currency: money.USD}
+	statement := service.exportStatement(account, date(2025, time.June, 1), date(2025, time.July, 1), date(2025, time.July, 2))
+
+	w := &collectWriter{}
+	assert.NoError(t, service.WriteExport(context.Background(), account, statement, w))
+
+	assert.Equal(t, money.New(0, money.USD), w.statement.Opening)
+	if assert.Len(t, w.entries, 2) {
+		assert.Equal(t, "J4", w.entries[0].ID)
+		assert.Equal(t, money.New(100000, money.USD), w.entries[0].Amount)
+		assert.Equal(t, "41", w.entries[1].ID)
+		assert.Equal(t, "Deposit", w.entries[1].Description)
+	}
+	assert.Equal(t, money.New(125000, money.USD), *w.closing)
+	assert.IsType(t, &sqlx.Tx{}, ledger.feesReadBy, "fees are read in the export's snapshot")
+}
+
+func TestWriteExport_RefusesAnExportThatDoesNotAddUp(t *testing.T) {
+	ledger := exportLedger(120000)
+	service := NewExportService(ledger, ledger, nil, nil, NewFeeService(ledger, nil), "12345678", "MockBank", txOnlyDB())
+	account := &models.Account{ID: 3, UserID: 7, Number: "001000000003", Currency: money.USD}
+	statement := service.exportStatement(account, date(2025, time.June, 1), date(2025, time.July, 1), date(2025, time.July, 2))
+
+	w := &collectWriter{}
+	assert.Error(t, service.WriteExport(context.Background(), account, statement, w))
+	assert.Nil(t, w.closing)
+}
+
+func TestStatementMonth(t *testing.T) {
+	from, to, err := statementMonth("2025-12")
+	assert.NoError(t, err)
+	assert.Equal(t, date(2025, time.December, 1), from)
+	assert.Equal(t, date(2026, time.January, 1), to)
+
+	for _, period := range []string{"2025-13", "2025-6", "25-06", "2025-06-01"} {
+		_, _, err := statementMonth(period)
+		assert.Equal(t, apperrors.ErrInvalidStatementMonth, err, period)
+	}
+}
+
+func TestOpenDuring(t *testing.T) {
+	from, to := date(2025, time.June, 1), date(2025, time.July, 1)
+	closed := func(t time.Time) *time.Time { return &t }
+
+	assert.True(t, openDuring(&models.Account{CreatedAt: date(2025, time.January, 1)}, from, to))
+	assert.True(t, openDuring(&models.Account{CreatedAt: date(2025, time.June, 30)}, from, to))
+	assert.False(t, openDuring(&models.Account{CreatedAt: to}, from, to))
+	assert.True(t, openDuring(&models.Account{CreatedAt: date(2025, time.January, 1), ClosedAt: closed(from)}, from, to))
+	assert.False(t, openDuring(&models.Account{CreatedAt: date(2025, time.January, 1), ClosedAt: closed(date(2025, time.May, 31))}, from, to))
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"math/big"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const bpsPerUnit = 10000
+
+// percentOf returns bps basis points of amount, rounded up to the next
+// minor unit.
+func percentOf(amount int64, bps int) int64 {
+	fee := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(bps)))
+	fee.Add(fee, big.NewInt(bpsPerUnit-1))
+	fee.Div(fee, big.NewInt(bpsPerUnit))
+	return fee.Int64()
+}
+
+// ruleFee computes what a rule charges on amount.
+func ruleFee(rule models.FeeRule, amount money.Money) money.Money {
+	var fee int64
+	switch rule.Kind {
+	case models.FeeFlat:
+		fee = rule.Flat.Amount
+	case models.FeePercentage:
+		fee = percentOf(amount.Amount, *rule.Bps)
+	case models.FeeTiered:
+		for _, tier := range rule.Tiers {
+			if tier.UpTo != nil && amount.Amount > tier.UpTo.Amount {
+				continue
+			}
+			if tier.Flat != nil {
+				fee = tier.Flat.Amount
+			}
+			fee += percentOf(amount.Amount, tier.Bps)
+			break
+		}
+	}
+
+	if rule.MinFee != nil && fee < rule.MinFee.Amount {
+		fee = rule.MinFee.Amount
+	}
+	if rule.MaxFee != nil && fee > rule.MaxFee.Amount {
+		fee = rule.MaxFee.Amount
+	}
+	return money.New(fee, amount.Currency)
+}
+
+// effectiveFeeRules keeps, for each fee type, the rule for the user's role
+// over the rule for everyone.
+func effectiveFeeRules(rules []models.FeeRule) map[models.FeeType]models.FeeRule {
+	effective := make(map[models.FeeType]models.FeeRule)
+	for _, rule := range rules {
+		if _, ok := effective[rule.Type]; !ok || rule.Role != nil {
+			effective[rule.Type] = rule
+		}
+	}
+	return effective
+}
+
+type FeeService struct {
+	feeRepo  repositories.IFeeRepository
+	database *sqlx.DB
+}
+
+func NewFeeService(frepo repositories.IFeeRepository, db *sqlx.DB) *FeeService {
+	return &FeeService{feeRepo: frepo, database: db}
+}
+
+// Assess returns the fees the user pays to move amount by operation and
+// their total. A converted transfer pays the FX fee as well. Fees of zero
+// are left out.
+func (s *FeeService) Assess(q sqlx.Queryer, userID int64, operation models.TransactionType, amount money.Money, converted bool) ([]models.TransactionFee, money.Money, error) {
+	total := money.New(0, amount.Currency)
+
+	var types []models.FeeType
+	switch operation {
+	case models.Withdraw:
+		types = append(types, models.FeeWithdraw)
+	case models.Transfer:
+		types = append(types, models.FeeTransfer)
+		if converted {
+			types = append(types, models.FeeFX)
+		}
+	}
+	if len(types) == 0 {
+		return nil, total, nil
+	}
+
+	rules, err := s.feeRepo.GetRulesFor(q, userID, types, amount.Currency)
+	if err != nil {
+		return nil, total, apperrors.ErrDatabaseError
+	}
+	effective := effectiveFeeRules(rules)
+
+	var fees []models.TransactionFee
+	for _, feeType := range types {
+		rule, ok := effective[feeType]
+		if !ok {
+			continue
+		}
+		fee := ruleFee(rule, amount)
+		if !fee.IsPositive() {
+			continue
+		}
+		ruleID := rule.ID
+		fees = append(fees, models.TransactionFee{RuleID: &ruleID, Type: feeType, Amount: fee})
+		total = total.Add(fee)
+	}
+	return fees, total, nil
+}
+
+// Preview prices an operation without making it. toCurrency, when it differs
+// from the amount's currency, marks a transfer as converted.
+func (s *FeeService) Preview(userID int64, operation models.TransactionType, amount money.Money, toCurrency money.Currency) (*models.FeePreview, error) {
+	if operation != models.Withdraw && operation != models.Transfer {
+		return nil, apperrors.ErrInvalidFeeOperation
+	}
+	if !amount.IsPositive() {
+		return nil, apperrors.ErrInvalidAmount
+	}
+	if toCurrency != "" && !toCurrency.Valid() {
+		return nil, apperrors.ErrUnsupportedCurrency
+	}
+
+	converted := operation == models.Transfer && toCurrency != "" && toCurrency != amount.Currency
+	fees, total, err := s.Assess(s.database, userID, operation, amount, converted)
+	if err != nil {
+		return nil, err
+	}
+	if fees == nil {
+		fees = []models.TransactionFee{}
+	}
+
+	return &models.FeePreview{
+		Operation:  operation,
+		Amount:     amount,
+		Fees:       fees,
+		TotalFee:   total,
+		TotalDebit: amount.Add(total),
+	}, nil
+}
+
+// RecordFees itemises the fees charged on a transaction.
+func (s *FeeService) RecordFees(tx *sqlx.Tx, transaction *models.TransactionInfo, fees []models.TransactionFee) error {
+	if len(fees) == 0 {
+		return nil
+	}
+	if err := s.feeRepo.WriteFees(tx, transaction.ID, fees); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	transaction.Fees = fees
+	return nil
+}
+
+// AttachFees loads the fees charged on each transaction, reading through q.
+func (s *FeeService) AttachFees(q sqlx.Queryer, transactions []models.TransactionInfo) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+	fees, err := s.feeRepo.GetFees(q, ids)
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	byTransaction := make(map[int64][]models.TransactionFee)
+	for _, fee := range fees {
+		byTransaction[fee.TransactionID] = append(byTransaction[fee.TransactionID], fee)
+	}
+	for i := range transactions {
+		transactions[i].Fees = byTransaction[transactions[i].ID]
+	}
+	return nil
+}
+
+func (s *FeeService) ListRules() ([]models.FeeRule, error) {
+	rules, err := s.feeRepo.GetRules()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if rules == nil {
+		rules = []models.FeeRule{}
+	}
+	return rules, nil
+}
+
+// SetRule creates or replaces the rule for its type, role and currency. Only
+// the fields used by the rule's kind are kept, and every amount must be in
+// the rule's currency.
+func (s *FeeService) SetRule(rule *models.FeeRule) error {
+	if !rule.Type.Valid() {
+		return apperrors.ErrInvalidFeeType
+	}
+	if rule.Role != nil && !rule.Role.Valid() {
+		return apperrors.ErrInvalidRole
+	}
+	if rule.Currency == "" {
+		rule.Currency = money.DefaultCurrency
+	}
+	if !rule.Currency.Valid() {
+		return apperrors.ErrUnsupportedCurrency
+	}
+
+	inCurrency := func(amount *money.Money, positive bool) bool {
+		if amount == nil {
+			return true
+		}
+		if amount.Currency != rule.Currency || amount.IsNegative() {
+			return false
+		}
+		return !positive || amount.IsPositive()
+	}
+	validBps := func(bps int) bool { return bps >= 0 && bps <= bpsPerUnit }
+
+	switch rule.Kind {
+	case models.FeeFlat:
+		if rule.Flat == nil || !inCurrency(rule.Flat, true) {
+			return apperrors.ErrInvalidFeeRule
+		}
+		rule.Bps, rule.Tiers = nil, nil
+	case models.FeePercentage:
+		if rule.Bps == nil || *rule.Bps <= 0 || !validBps(*rule.Bps) {
+			return apperrors.ErrInvalidFeeRule
+		}
+		rule.Flat, rule.Tiers = nil, nil
+	case models.FeeTiered:
+		if len(rule.Tiers) == 0 {
+			return apperrors.ErrInvalidFeeRule
+		}
+		for i, tier := range rule.Tiers {
+			last := i == len(rule.Tiers)-1
+			if (tier.UpTo == nil) != last || !inCurrency(tier.UpTo, true) || !inCurrency(tier.Flat, false) || !validBps(tier.Bps) {
+				return apperrors.ErrInvalidFeeRule
+			}
+			if i > 0 && !last && tier.UpTo.Amount <= rule.Tiers[i-1].UpTo.Amount {
+				return apperrors.ErrInvalidFeeRule
+			}
+		}
+		rule.Flat, rule.Bps = nil, nil
+	default:
+		return apperrors.ErrInvalidFeeKind
+	}
+
+	if !inCurrency(rule.MinFee, false) || !inCurrency(rule.MaxFee, false) {
+		return apperrors.ErrInvalidFeeRule
+	}
+	if rule.MinFee != nil && rule.MaxFee != nil && rule.MinFee.Amount > rule.MaxFee.Amount {
+		return apperrors.ErrInvalidFeeRule
+	}
+
+	if err := s.feeRepo.UpsertRule(rule); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *FeeService) DeleteRule(ruleID int64) error {
+	if err := s.feeRepo.DeleteRule(ruleID); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrFeeRuleNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func usd(minor int64) *money.Money {
+	m := money.New(minor, money.USD)
+	return &m
+}
+
+func TestPercentOf_RoundsUp(t *testing.T) {
+	assert.Equal(t, int64(50), percentOf(10000, 50))
+	assert.Equal(t, int64(1), percentOf(1, 50))
+	assert.Equal(t, int64(0), percentOf(0, 50))
+	assert.Equal(t, int64(4611686018427388), percentOf(1<<62, 10))
+}
+
+func TestRuleFee_FlatAndPercentage(t *testing.T) {
+	flat := models.FeeRule{Kind: models.FeeFlat, Flat: usd(150)}
+	assert.Equal(t, money.New(150, money.USD), ruleFee(flat, money.New(999999, money.USD)))
+
+	bps := 125
+	percentage := models.FeeRule{Kind: models.FeePercentage, Bps: &bps}
+	// 1.25% of 10.01 = 0.125125, rounded up
+	assert.Equal(t, money.New(13, money.USD), ruleFee(percentage, money.New(1001, money.USD)))
+}
+
+func TestRuleFee_MinAndMaxCaps(t *testing.T) {
+	bps := 100
+	rule := models.FeeRule{Kind: models.FeePercentage, Bps: &bps, MinFee: usd(50), MaxFee: usd(1000)}
+
+	assert.Equal(t, money.New(50, money.USD), ruleFee(rule, money.New(1000, money.USD)))
+	assert.Equal(t, money.New(300, money.USD), ruleFee(rule, money.New(30000, money.USD)))
+	assert.Equal(t, money.New(1000, money.USD), ruleFee(rule, money.New(500000, money.USD)))
+}
+
+func TestRuleFee_Tiered(t *testing.T) {
+	rule := models.FeeRule{Kind: models.FeeTiered, Tiers: models.FeeTiers{
+		{UpTo: usd(10000), Flat: usd(100)},
+		{UpTo: usd(100000), Flat: usd(50), Bps: 20},
+		{Bps: 10},
+	}}
+
+	assert.Equal(t, money.New(100, money.USD), ruleFee(rule, money.New(10000, money.USD)))
+	// 0.50 + 0.2% of 100.01 = 0.50 + 0.20002, rounded up
+	assert.Equal(t, money.New(71, money.USD), ruleFee(rule, money.New(10001, money.USD)))
+	assert.Equal(t, money.New(250, money.USD), ruleFee(rule, money.New(250000, money.USD)))
+}
+
+func TestEffectiveFeeRules_RoleOverridesEveryone(t *testing.T) {
+	role := auth.User
+	rules := []models.FeeRule{
+		{ID: 1, Type: models.FeeTransfer, Role: &role},
+		{ID: 2, Type: models.FeeTransfer},
+		{ID: 3, Type: models.FeeFX},
+	}
+
+	effective := effectiveFeeRules(rules)
+
+	assert.Equal(t, int64(1), effective[models.FeeTransfer].ID)
+	assert.Equal(t, int64(3), effective[models.FeeFX].ID)
+	_, ok := effective[models.FeeWithdraw]
+	assert.False(t, ok)
+}
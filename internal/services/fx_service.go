@@ -0,0 +1,273 @@
+package services
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/fx"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// fxQuoteTTL is how long a quote can be redeemed by a transfer.
+	fxQuoteTTL = time.Minute
+	// fxRateMaxAge is how old a rate may be before conversions using it are refused.
+	fxRateMaxAge = 24 * time.Hour
+)
+
+type FXService struct {
+	fxRepo   repositories.IFXRepository
+	database *sqlx.DB
+}
+
+func NewFXService(fxrepo repositories.IFXRepository, db *sqlx.DB) *FXService {
+	return &FXService{fxRepo: fxrepo, database: db}
+}
+
+// Conversion is a priced exchange of From into To at Rate, which is the mid
+// rate less the spread.
+type Conversion struct {
+	From        money.Money
+	To          money.Money
+	Rate        fx.Rate
+	Mid         fx.Rate
+	SpreadBps   int
+	EffectiveAt time.Time
+	QuoteID     *string
+}
+
+// midRate returns the rate in effect for from/to. A pair only loaded the
+// other way round is inverted.
+func (s *FXService) midRate(from money.Currency, to money.Currency, now time.Time) (fx.Rate, *models.FXRate, error) {
+	stored, err := s.fxRepo.GetLatestRate(from, to, now)
+	inverted := false
+	if err == sql.ErrNoRows {
+		stored, err = s.fxRepo.GetLatestRate(to, from, now)
+		inverted = true
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fx.Rate{}, nil, apperrors.ErrFXRateUnavailable
+		}
+		return fx.Rate{}, nil, apperrors.ErrDatabaseError
+	}
+	if now.Sub(stored.EffectiveAt) > fxRateMaxAge {
+		return fx.Rate{}, nil, apperrors.ErrFXRateUnavailable
+	}
+
+	mid, err := fx.ParseRate(stored.Base, stored.Quote, stored.Rate)
+	if err != nil {
+		return fx.Rate{}, nil, apperrors.ErrInternalServer
+	}
+	if inverted {
+		mid = mid.Invert()
+	}
+	return mid, stored, nil
+}
+
+// Convert prices amount in another currency at the current rate.
+func (s *FXService) Convert(amount money.Money, to money.Currency, now time.Time) (*Conversion, error) {
+	if !to.Valid() {
+		return nil, apperrors.ErrUnsupportedCurrency
+	}
+	if amount.Currency == to {
+		return nil, apperrors.ErrSameCurrency
+	}
+
+	mid, stored, err := s.midRate(amount.Currency, to, now)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := mid.WithSpread(stored.SpreadBps)
+	if err != nil {
+		return nil, apperrors.ErrInternalServer
+	}
+
+	converted, err := rate.Convert(amount)
+	if err != nil {
+		return nil, apperrors.ErrInvalidAmount
+	}
+	if !converted.IsPositive() {
+		return nil, apperrors.ErrFXAmountTooSmall
+	}
+
+	return &Conversion{
+		From:        amount,
+		To:          converted,
+		Rate:        rate,
+		Mid:         mid,
+		SpreadBps:   stored.SpreadBps,
+		EffectiveAt: stored.EffectiveAt,
+	}, nil
+}
+
+// CreateQuote prices a conversion and holds that price for fxQuoteTTL.
+func (s *FXService) CreateQuote(userID int64, amount money.Money, to money.Currency, now time.Time) (*models.FXQuote, error) {
+	if !amount.IsPositive() {
+		return nil, apperrors.ErrInvalidAmount
+	}
+	if !amount.Currency.Valid() {
+		return nil, apperrors.ErrUnsupportedCurrency
+	}
+
+	conversion, err := s.Convert(amount, to, now)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := auth.NewTokenID()
+	if err != nil {
+		return nil, apperrors.ErrInternalServer
+	}
+
+	quote := &models.FXQuote{
+		ID:              id,
+		UserID:          userID,
+		FromAmount:      conversion.From,
+		FromCurrency:    conversion.From.Currency,
+		ToAmount:        conversion.To,
+		ToCurrency:      conversion.To.Currency,
+		Rate:            conversion.Rate.String(),
+		MidRate:         conversion.Mid.String(),
+		SpreadBps:       conversion.SpreadBps,
+		RateEffectiveAt: conversion.EffectiveAt,
+		ExpiresAt:       now.Add(fxQuoteTTL),
+	}
+	if err := s.fxRepo.CreateQuote(quote); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return quote, nil
+}
+
+// RedeemQuote marks a quote used inside tx and returns its conversion. The
+// quote must belong to the user, be unexpired and unused, and match the
+// amount being sent and the currency being received.
+func (s *FXService) RedeemQuote(tx *sqlx.Tx, userID int64, quoteID string, amount money.Money, to money.Currency, now time.Time) (*Conversion, error) {
+	quote, err := s.fxRepo.GetQuoteForUpdate(tx, quoteID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, apperrors.ErrFXQuoteNotFound
+		case repositories.ErrQuoteInUse:
+			return nil, apperrors.ErrFXQuoteUsed
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if quote.UserID != userID {
+		return nil, apperrors.ErrFXQuoteNotFound
+	}
+	if quote.UsedAt != nil {
+		return nil, apperrors.ErrFXQuoteUsed
+	}
+	if !now.Before(quote.ExpiresAt) {
+		return nil, apperrors.ErrFXQuoteExpired
+	}
+	if quote.FromAmount != amount || quote.ToCurrency != to {
+		return nil, apperrors.ErrFXQuoteMismatch
+	}
+
+	rate, err := fx.ParseRate(quote.FromCurrency, quote.ToCurrency, quote.Rate)
+	if err != nil {
+		return nil, apperrors.ErrInternalServer
+	}
+	mid, err := fx.ParseRate(quote.FromCurrency, quote.ToCurrency, quote.MidRate)
+	if err != nil {
+		return nil, apperrors.ErrInternalServer
+	}
+
+	if err := s.fxRepo.MarkQuoteUsed(tx, quote.ID, now); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return &Conversion{
+		From:        quote.FromAmount,
+		To:          quote.ToAmount,
+		Rate:        rate,
+		Mid:         mid,
+		SpreadBps:   quote.SpreadBps,
+		EffectiveAt: quote.RateEffectiveAt,
+		QuoteID:     &quote.ID,
+	}, nil
+}
+
+// SetRates validates and stores a batch of rates. Rates without an
+// effective time take effect now.
+func (s *FXService) SetRates(rates []models.FXRate, source string, now time.Time) ([]models.FXRate, error) {
+	if len(rates) == 0 {
+		return nil, apperrors.ErrInvalidFXRate
+	}
+
+	for i := range rates {
+		rate := &rates[i]
+		if !rate.Base.Valid() || !rate.Quote.Valid() || rate.Base == rate.Quote {
+			return nil, apperrors.ErrInvalidFXRate
+		}
+		parsed, err := fx.ParseRate(rate.Base, rate.Quote, rate.Rate)
+		if err != nil {
+			return nil, apperrors.ErrInvalidFXRate
+		}
+		if _, err := parsed.WithSpread(rate.SpreadBps); err != nil {
+			return nil, apperrors.ErrInvalidFXRate
+		}
+
+		rate.Rate = parsed.String()
+		rate.Source = source
+		if rate.EffectiveAt.IsZero() {
+			rate.EffectiveAt = now
+		}
+		rate.EffectiveAt = rate.EffectiveAt.Truncate(time.Microsecond)
+	}
+
+	if err := s.fxRepo.UpsertRates(rates); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return rates, nil
+}
+
+// LoadRatesFile stores the rates in a JSON file holding an array of
+// {"base", "quote", "rate", "spread_bps", "effective_at"} objects. Rates
+// without effective_at take the file's modification time, so loading an
+// unchanged file again changes nothing.
+func (s *FXService) LoadRatesFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var rates []models.FXRate
+	if err := json.Unmarshal(raw, &rates); err != nil {
+		return err
+	}
+	_, err = s.SetRates(rates, models.FXSourceFile, info.ModTime())
+	return err
+}
+
+// ListRates returns the rate currently in effect for every pair.
+func (s *FXService) ListRates(now time.Time) ([]models.FXRate, error) {
+	rates, err := s.fxRepo.GetLatestRates(now)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if rates == nil {
+		rates = []models.FXRate{}
+	}
+	return rates, nil
+}
+
+// DeleteExpiredQuotes removes quotes that can no longer be redeemed.
+func (s *FXService) DeleteExpiredQuotes(ctx context.Context, now time.Time) error {
+	return s.fxRepo.DeleteExpiredQuotes(now)
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultHoldTTL is how long a hold lasts when neither the request nor
+// HOLD_TTL says otherwise. It is also the longest a hold may be asked for.
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+type HoldService struct {
+	holdRepo           repositories.IHoldRepository
+	accountRepo        repositories.IAccountRepository
+	transactionService *TransactionService
+	ttl                time.Duration
+	auditService       *AuditService
+	database           *sqlx.DB
+}
+
+func NewHoldService(hrepo repositories.IHoldRepository, arepo repositories.IAccountRepository, transactionService *TransactionService, ttl time.Duration, auditService *AuditService, db *sqlx.DB) *HoldService {
+	return &HoldService{holdRepo: hrepo, accountRepo: arepo, transactionService: transactionService, ttl: ttl, auditService: auditService, database: db}
+}
+
+// PlaceHold reserves hold.Amount on one of the user's accounts for a later
+// transfer to hold.ToAccountID. The amount must be available, and stops being
+// available until the hold ends. expiresIn of zero means the default
+// lifetime.
+func (s *HoldService) PlaceHold(ctx context.Context, userID int64, hold *models.Hold, expiresIn time.Duration, now time.Time) error {
+	if err := s.transactionService.validateAmount(hold.Amount); err != nil {
+		return err
+	}
+	if hold.AccountID == hold.ToAccountID {
+		return apperrors.ErrSelfTransfer
+	}
+	if expiresIn == 0 {
+		expiresIn = s.ttl
+	}
+	if expiresIn < 0 || expiresIn > s.ttl {
+		return apperrors.ErrInvalidHoldExpiry
+	}
+
+	to, err := s.accountRepo.GetAccountByID(hold.ToAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrReceiverNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if to.Status != models.AccountOpen {
+		return apperrors.ErrAccountClosed
+	}
+
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	account, err := s.transactionService.lockOwnAccount(tx, userID, hold.AccountID, hold.Amount)
+	if err != nil {
+		return err
+	}
+	if account.Available.Cmp(hold.Amount) < 0 {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	hold.UserID = userID
+	hold.Currency = account.Currency
+	hold.Status = models.HoldActive
+	hold.ExpiresAt = now.Add(expiresIn)
+
+	if err := s.holdRepo.CreateHold(tx, hold); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if err := s.holdRepo.IncreaseHeld(tx, account.ID, hold.Amount); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.TargetID = &hold.ID
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *HoldService) ListHolds(userID int64) ([]models.Hold, error) {
+	holds, err := s.holdRepo.GetHoldsByUser(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if holds == nil {
+		holds = []models.Hold{}
+	}
+	return holds, nil
+}
+
+// payeeOf returns the user who owns the account a hold is for.
+func (s *HoldService) payeeOf(hold *models.Hold) (int64, error) {
+	to, err := s.accountRepo.GetAccountByID(hold.ToAccountID)
+	if err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	return to.UserID, nil
+}
+
+// GetHold returns a hold to the user who placed it or the owner of the
+// account it is for.
+func (s *HoldService) GetHold(userID int64, holdID int64) (*models.Hold, error) {
+	hold, err := s.holdRepo.GetHold(holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrHoldNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if hold.UserID == userID {
+		return hold, nil
+	}
+
+	payeeID, err := s.payeeOf(hold)
+	if err != nil {
+		return nil, err
+	}
+	if payeeID != userID {
+		return nil, apperrors.ErrHoldNotFound
+	}
+	return hold, nil
+}
+
+// lockPayeeHold locks an active hold the user may capture or release: one
+// for an account the user owns. The user who placed it only sees it.
+func (s *HoldService) lockPayeeHold(tx *sqlx.Tx, userID int64, holdID int64) (*models.Hold, error) {
+	hold, err := s.holdRepo.GetHoldForUpdate(tx, holdID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, apperrors.ErrHoldNotFound
+		case repositories.ErrHoldInUse:
+			return nil, apperrors.ErrHoldNotActive
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	payeeID, err := s.payeeOf(hold)
+	if err != nil {
+		return nil, err
+	}
+	if payeeID != userID {
+		if hold.UserID == userID {
+			return nil, apperrors.ErrForbidden
+		}
+		return nil, apperrors.ErrHoldNotFound
+	}
+	if hold.Status != models.HoldActive {
+		return nil, apperrors.ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+// CaptureHold turns a hold into a transfer of amount, or of the whole held
+// amount when amount is nil. The hold ends either way: anything not captured
+// becomes available again. The transfer pays fees and is checked against
+// limits like any other.
+func (s *HoldService) CaptureHold(ctx context.Context, userID int64, holdID int64, amount *money.Money, now time.Time) (_ *models.Hold, _ *models.TransactionInfo, err error) {
+	// A capture refused like a transfer is recorded like one. Deferred
+	// before the rollback below, so it runs after it.
+	var attempt *models.TransactionInfo
+	defer func() {
+		if attempt != nil {
+			s.transactionService.recordFailure(*attempt, err)
+		}
+	}()
+
+	tx, err := s.database.BeginTxx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+	})
+	if err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	hold, err := s.lockPayeeHold(tx, userID, holdID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !now.Before(hold.ExpiresAt) {
+		return nil, nil, apperrors.ErrHoldExpired
+	}
+
+	capture := hold.Amount
+	if amount != nil {
+		capture = *amount
+		if err := s.transactionService.validateAmount(capture); err != nil {
+			return nil, nil, err
+		}
+		if capture.Currency != hold.Currency {
+			return nil, nil, apperrors.ErrCurrencyMismatch
+		}
+		if capture.Cmp(hold.Amount) > 0 {
+			return nil, nil, apperrors.ErrCaptureExceedsHold
+		}
+	}
+
+	attempt = &models.TransactionInfo{
+		SenderID:      hold.UserID,
+		FromAccountID: &hold.AccountID,
+		ToAccountID:   &hold.ToAccountID,
+		Amount:        capture,
+		Type:          models.Transfer,
+	}
+
+	// Lock both accounts in ID order before touching either, as transfer
+	// does, then free the held money so the transfer can spend it.
+	if _, _, err := s.transactionService.lockPair(tx, hold.AccountID, hold.ToAccountID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.holdRepo.DecreaseHeld(tx, hold.AccountID, hold.Amount); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+
+	transaction, err := s.transactionService.transfer(tx, hold.UserID, hold.AccountID, hold.ToAccountID, capture, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hold.Status = models.HoldCaptured
+	hold.CapturedAmount = &capture
+	hold.TransactionID = &transaction.ID
+	hold.ClosedAt = &now
+	if err := s.holdRepo.CloseHold(tx, hold); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.Amount = &capture
+		entry.Details = withAuditDetail(entry.Details, "transaction_id", transaction.ID)
+		if len(transaction.Fees) > 0 {
+			entry.Details = withAuditDetail(entry.Details, "fees", transaction.Fees)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	return hold, transaction, nil
+}
+
+// ReleaseHold ends a hold without moving any money.
+func (s *HoldService) ReleaseHold(ctx context.Context, userID int64, holdID int64, now time.Time) (*models.Hold, error) {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	hold, err := s.lockPayeeHold(tx, userID, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.holdRepo.DecreaseHeld(tx, hold.AccountID, hold.Amount); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	hold.Status = models.HoldReleased
+	hold.ClosedAt = &now
+	if err := s.holdRepo.CloseHold(tx, hold); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.Amount = &hold.Amount
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return hold, nil
+}
+
+// ExpireHolds ends holds past their expiry. It is the body of the
+// expired-holds background job.
+func (s *HoldService) ExpireHolds(ctx context.Context, now time.Time) error {
+	return s.holdRepo.ExpireHolds(now)
+}
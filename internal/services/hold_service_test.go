@@ -0,0 +1,37 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// PlaceHold validates its input before touching the database, so these run
+// without repositories.
+func TestPlaceHold_RejectsInvalidInput(t *testing.T) {
+	service := NewHoldService(nil, nil, nil, time.Hour, nil, nil)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		hold      models.Hold
+		expiresIn time.Duration
+		want      error
+	}{
+		{"zero amount", models.Hold{AccountID: 1, ToAccountID: 2, Amount: money.New(0, money.USD)}, 0, apperrors.ErrInvalidAmount},
+		{"same account", models.Hold{AccountID: 1, ToAccountID: 1, Amount: money.New(100, money.USD)}, 0, apperrors.ErrSelfTransfer},
+		{"negative expiry", models.Hold{AccountID: 1, ToAccountID: 2, Amount: money.New(100, money.USD)}, -time.Minute, apperrors.ErrInvalidHoldExpiry},
+		{"expiry beyond TTL", models.Hold{AccountID: 1, ToAccountID: 2, Amount: money.New(100, money.USD)}, 2 * time.Hour, apperrors.ErrInvalidHoldExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hold := tt.hold
+			assert.Equal(t, tt.want, service.PlaceHold(context.Background(), 7, &hold, tt.expiresIn, now))
+		})
+	}
+}
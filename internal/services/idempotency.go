@@ -0,0 +1,87 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/repositories"
+	"context"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type idempotencyContextKey struct{}
+
+// IdempotencyKey is the client-supplied Idempotency-Key together with a hash
+// of the request it was sent with.
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+}
+
+func WithIdempotencyKey(ctx context.Context, key IdempotencyKey) context.Context {
+	return context.WithValue(ctx, idempotencyContextKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) (IdempotencyKey, bool) {
+	key, ok := ctx.Value(idempotencyContextKey{}).(IdempotencyKey)
+	return key, ok && key.Key != ""
+}
+
+// IdempotentReplay is returned instead of executing a request whose
+// Idempotency-Key has already been used with the same payload. It carries the
+// response that was stored for the first request.
+type IdempotentReplay struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (r *IdempotentReplay) Error() string {
+	return "idempotent replay"
+}
+
+// claimIdempotency reserves the request's idempotency key inside tx. Because
+// the claim is part of the same transaction as the ledger write, a failed
+// request releases its key and can be retried.
+func claimIdempotency(ctx context.Context, repo repositories.IIdempotencyRepository, tx *sqlx.Tx, userID int64) error {
+	key, ok := idempotencyKeyFrom(ctx)
+	if !ok {
+		return nil
+	}
+
+	record, err := repo.Claim(tx, userID, key.Key, key.RequestHash)
+	if err != nil {
+		if err == repositories.ErrIdempotencyInProgress {
+			return apperrors.ErrIdempotencyInProgress
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if record == nil {
+		return nil
+	}
+
+	if record.RequestHash != key.RequestHash {
+		return apperrors.ErrIdempotencyKeyReused
+	}
+	return &IdempotentReplay{StatusCode: record.StatusCode, Body: record.ResponseBody}
+}
+
+// completeIdempotency stores the successful response for the claimed key,
+// with the status code the handler answers it with.
+func completeIdempotency(ctx context.Context, repo repositories.IIdempotencyRepository, tx *sqlx.Tx, userID int64, statusCode int, result interface{}) error {
+	key, ok := idempotencyKeyFrom(ctx)
+	if !ok {
+		return nil
+	}
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return apperrors.ErrInternalServer
+	}
+	// Match the trailing newline json.Encoder writes on the first response.
+	body = append(body, '\n')
+
+	if err := repo.Complete(tx, userID, key.Key, statusCode, body); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
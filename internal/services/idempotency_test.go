@@ -0,0 +1,123 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdempotency keeps claimed keys in memory. claimErr, when set, is what
+// every claim fails with.
+type fakeIdempotency struct {
+	records  map[string]*models.IdempotencyRecord
+	claimErr error
+}
+
+func newFakeIdempotency() *fakeIdempotency {
+	return &fakeIdempotency{records: map[string]*models.IdempotencyRecord{}}
+}
+
+func (f *fakeIdempotency) Claim(tx *sqlx.Tx, userID int64, key string, requestHash string) (*models.IdempotencyRecord, error) {
+	if f.claimErr != nil {
+		return nil, f.claimErr
+	}
+	id := fmt.Sprintf("%d:%s", userID, key)
+	if record, ok := f.records[id]; ok {
+		stored := *record
+		return &stored, nil
+	}
+	f.records[id] = &models.IdempotencyRecord{UserID: userID, Key: key, RequestHash: requestHash}
+	return nil, nil
+}
+
+func (f *fakeIdempotency) Complete(tx *sqlx.Tx, userID int64, key string, statusCode int, body []byte) error {
+	record := f.records[fmt.Sprintf("%d:%s", userID, key)]
+	record.StatusCode = statusCode
+	record.ResponseBody = body
+	return nil
+}
+
+func withKey(hash string) context.Context {
+	return WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "key-1", RequestHash: hash})
+}
+
+func TestIdempotency_ReplaysTheStoredResponse(t *testing.T) {
+	repo := newFakeIdempotency()
+	ctx := withKey("hash-1")
+
+	require.NoError(t, claimIdempotency(ctx, repo, nil, 7))
+	result := map[string]int{"id": 42}
+	require.NoError(t, completeIdempotency(ctx, repo, nil, 7, http.StatusOK, result))
+
+	err := claimIdempotency(ctx, repo, nil, 7)
+
+	replay, ok := err.(*IdempotentReplay)
+	require.True(t, ok, "got %v", err)
+	assert.Equal(t, http.StatusOK, replay.StatusCode)
+	assert.Equal(t, "{\"id\":42}\n", string(replay.Body), "the body json.Encoder wrote the first time")
+}
+
+func TestIdempotency_KeysAreScopedToTheUser(t *testing.T) {
+	repo := newFakeIdempotency()
+	ctx := withKey("hash-1")
+
+	require.NoError(t, claimIdempotency(ctx, repo, nil, 7))
+	require.NoError(t, completeIdempotency(ctx, repo, nil, 7, http.StatusOK, map[string]int{"id": 42}))
+
+	assert.NoError(t, claimIdempotency(ctx, repo, nil, 8))
+}
+
+func TestIdempotency_RejectsAKeyReusedForAnotherRequest(t *testing.T) {
+	repo := newFakeIdempotency()
+
+	require.NoError(t, claimIdempotency(withKey("hash-1"), repo, nil, 7))
+	require.NoError(t, completeIdempotency(withKey("hash-1"), repo, nil, 7, http.StatusOK, map[string]int{"id": 42}))
+
+	assert.Equal(t, apperrors.ErrIdempotencyKeyReused, claimIdempotency(withKey("hash-2"), repo, nil, 7))
+}
+
+// A request racing another with the same key loses on the unique index,
+// which the repository reports as ErrIdempotencyInProgress.
+func TestIdempotency_ConcurrentClaimIsInProgress(t *testing.T) {
+	repo := newFakeIdempotency()
+	repo.claimErr = repositories.ErrIdempotencyInProgress
+	assert.Equal(t, apperrors.ErrIdempotencyInProgress, claimIdempotency(withKey("hash-1"), repo, nil, 7))
+
+	repo.claimErr = errors.New("connection reset")
+	assert.Equal(t, apperrors.ErrDatabaseError, claimIdempotency(withKey("hash-1"), repo, nil, 7))
+}
+
+func TestIdempotency_NothingStoredWithoutAKey(t *testing.T) {
+	repo := newFakeIdempotency()
+	ctx := WithIdempotencyKey(context.Background(), IdempotencyKey{})
+
+	assert.NoError(t, claimIdempotency(ctx, repo, nil, 7))
+	assert.NoError(t, completeIdempotency(ctx, repo, nil, 7, http.StatusOK, map[string]int{"id": 42}))
+	assert.NoError(t, claimIdempotency(context.Background(), repo, nil, 7))
+	assert.Empty(t, repo.records)
+}
+
+// A replayed deposit answers from the stored response before touching any
+// account.
+func TestDepositMoney_ReplayMovesNoMoney(t *testing.T) {
+	repo := newFakeIdempotency()
+	ctx := withKey("hash-1")
+	require.NoError(t, claimIdempotency(ctx, repo, nil, 7))
+	require.NoError(t, completeIdempotency(ctx, repo, nil, 7, http.StatusOK, map[string]int{"id": 42}))
+
+	service := NewTransactionService(nil, nil, nil, repo, nil, nil, nil, nil, nil, txOnlyDB())
+	transaction, err := service.DepositMoney(ctx, 7, 1, money.New(100, money.USD))
+
+	assert.Nil(t, transaction)
+	assert.IsType(t, &IdempotentReplay{}, err)
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const daysPerYear = 365
+
+// accrualPrecision is the number of decimal places of a minor unit accrued
+// interest is stored to, matching its NUMERIC(30, 12) columns.
+const accrualPrecision = 12
+
+// dailyInterest returns one day's interest at a yearly rate of bps basis
+// points on amount, rounded up to the next minor unit.
+func dailyInterest(amount int64, bps int) int64 {
+	interest := new(big.Int).Mul(big.NewInt(amount), big.NewInt(int64(bps)))
+	divisor := big.NewInt(bpsPerUnit * daysPerYear)
+	interest.Add(interest, new(big.Int).Sub(divisor, big.NewInt(1)))
+	interest.Div(interest, divisor)
+	return interest.Int64()
+}
+
+// dayFraction returns the share of a year that day earns under a day count
+// convention, as days over days in the year.
+func dayFraction(convention models.DayCount, day time.Time) (int64, int64) {
+	switch convention {
+	case models.DayCountActual360:
+		return 1, 360
+	case models.DayCountActualActual:
+		newYear := time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return 1, int64(newYear.AddDate(1, 0, 0).Sub(newYear).Hours() / 24)
+	case models.DayCount30360:
+		return days30E360(day, day.AddDate(0, 0, 1)), 360
+	}
+	return 1, daysPerYear
+}
+
+// days30E360 counts the days between two dates as if every month had 30
+// days (the 30E/360 convention). The 31st counts as the 30th, so the 30th
+// of a long month earns nothing, and the last day of February earns up to
+// the 30th.
+func days30E360(from time.Time, to time.Time) int64 {
+	d1, d2 := min(from.Day(), 30), min(to.Day(), 30)
+	return int64(360*(to.Year()-from.Year()) + 30*(int(to.Month())-int(from.Month())) + d2 - d1)
+}
+
+// dailyAccrual returns what base earns in one day at a yearly rate of bps
+// basis points, with days/yearDays of a year to the day. It is exact.
+func dailyAccrual(base *big.Rat, bps int, days int64, yearDays int64) *big.Rat {
+	return new(big.Rat).Mul(base, big.NewRat(int64(bps)*days, bpsPerUnit*yearDays))
+}
+
+// endsMonth reports whether day is the last of its month.
+func endsMonth(day time.Time) bool {
+	return day.AddDate(0, 0, 1).Day() == 1
+}
+
+// compoundsAfter reports whether interest accrued up to the end of day starts
+// earning interest itself.
+func compoundsAfter(compounding models.Compounding, day time.Time) bool {
+	switch compounding {
+	case models.CompoundingDaily:
+		return true
+	case models.CompoundingMonthly:
+		return endsMonth(day)
+	}
+	return false
+}
+
+// paysAfter reports whether accrued interest is paid out at the end of day.
+func paysAfter(payout models.PayoutFrequency, day time.Time) bool {
+	if !endsMonth(day) {
+		return false
+	}
+	switch payout {
+	case models.PayoutQuarterly:
+		return day.Month()%3 == 0
+	case models.PayoutAnnually:
+		return day.Month() == time.December
+	}
+	return true
+}
+
+// nextPayout returns the first day on or after day whose end pays out.
+func nextPayout(payout models.PayoutFrequency, day time.Time) time.Time {
+	day = startOfDay(day)
+	monthEnd := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	for !paysAfter(payout, monthEnd) {
+		monthEnd = time.Date(monthEnd.Year(), monthEnd.Month()+2, 0, 0, 0, 0, 0, time.UTC)
+	}
+	return monthEnd
+}
+
+// parseDecimal reads an exact decimal stored in a NUMERIC column.
+func parseDecimal(value string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", value)
+	}
+	return r, nil
+}
+
+// floorMinor rounds an amount in minor units down to a whole minor unit.
+func floorMinor(r *big.Rat) int64 {
+	return new(big.Int).Div(r.Num(), r.Denom()).Int64()
+}
+
+type InterestService struct {
+	interestRepo       repositories.IInterestRepository
+	overdraftRepo      repositories.IOverdraftRepository
+	ledgerRepo         repositories.ILedgerRepository
+	accountRepo        repositories.IAccountRepository
+	transactionService *TransactionService
+	database           *sqlx.DB
+}
+
+func NewInterestService(irepo repositories.IInterestRepository, orepo repositories.IOverdraftRepository, lrepo repositories.ILedgerRepository, arepo repositories.IAccountRepository, transactionService *TransactionService, db *sqlx.DB) *InterestService {
+	return &InterestService{interestRepo: irepo, overdraftRepo: orepo, ledgerRepo: lrepo, accountRepo: arepo, transactionService: transactionService, database: db}
+}
+
+// ChargeOverdraftInterest charges a day's interest on every overdrawn
+// account that has not been charged for the UTC day of now. It is the body
+// of the overdraft-interest background job. A day the job does not run is
+// not charged later. One account failing does not stop the others.
+func (s *InterestService) ChargeOverdraftInterest(ctx context.Context, now time.Time) error {
+	day := startOfDay(now)
+	accountIDs, err := s.overdraftRepo.GetAccountsDueInterest(day)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, accountID := range accountIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.chargeOverdraft(ctx, accountID, day); err != nil {
+			log.Printf("overdraft interest: account %d: %v", accountID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d overdraft interest charges failed", failed, len(accountIDs))
+	}
+	return nil
+}
+
+// chargeOverdraft charges one account's interest for day. The account row
+// lock makes a second worker wait and then find the day already charged.
+func (s *InterestService) chargeOverdraft(ctx context.Context, accountID int64, day time.Time) error {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	account, err := s.transactionService.lockAccount(tx, accountID, "", apperrors.ErrAccountNotFound)
+	if err != nil {
+		return err
+	}
+	if !account.Balance.IsNegative() {
+		return nil
+	}
+
+	charged, err := s.overdraftRepo.InterestCharged(tx, account.ID, day)
+	if err != nil || charged {
+		return err
+	}
+
+	overdraft, err := s.overdraftRepo.GetOverdraft(tx, account.UserID, account.Currency)
+	if err != nil {
+		return err
+	}
+	interest := money.New(dailyInterest(-account.Balance.Amount, overdraft.InterestBps), account.Currency)
+	if interest.IsZero() {
+		return nil
+	}
+
+	description := fmt.Sprintf("overdraft interest on account %s for %s", account.Number, day.Format("2006-01-02"))
+	transaction, err := s.transactionService.chargeInterest(tx, account, interest, description)
+	if err != nil {
+		return err
+	}
+	if err := s.overdraftRepo.RecordInterest(tx, account.ID, day, interest, transaction.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func validateInterestProduct(product *models.InterestProduct) error {
+	if product.Name == "" || !product.Currency.Valid() || !product.AccountType.Valid() {
+		return apperrors.ErrInvalidInterestProduct
+	}
+	if product.RateBps < 0 || product.RateBps > bpsPerUnit {
+		return apperrors.ErrInvalidInterestProduct
+	}
+	if !product.DayCount.Valid() || !product.Compounding.Valid() || !product.Payout.Valid() {
+		return apperrors.ErrInvalidInterestProduct
+	}
+	return nil
+}
+
+func (s *InterestService) ListProducts() ([]models.InterestProduct, error) {
+	products, err := s.interestRepo.GetProducts()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if products == nil {
+		products = []models.InterestProduct{}
+	}
+	return products, nil
+}
+
+func (s *InterestService) CreateProduct(product *models.InterestProduct) error {
+	if err := validateInterestProduct(product); err != nil {
+		return err
+	}
+	if err := s.interestRepo.CreateProduct(product); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// UpdateProduct changes a product's name, rate and conventions from the next
+// day accrued on. Its currency and account type cannot change; left empty
+// they keep their values.
+func (s *InterestService) UpdateProduct(product *models.InterestProduct) error {
+	existing, err := s.interestRepo.GetProduct(product.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrInterestProductNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if product.Currency == "" {
+		product.Currency = existing.Currency
+	}
+	if product.AccountType == "" {
+		product.AccountType = existing.AccountType
+	}
+	if product.Currency != existing.Currency || product.AccountType != existing.AccountType {
+		return apperrors.ErrInterestProductMismatch
+	}
+	if err := validateInterestProduct(product); err != nil {
+		return err
+	}
+
+	if err := s.interestRepo.UpdateProduct(product); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrInterestProductNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *InterestService) ListAttachments() ([]models.InterestAttachment, error) {
+	attachments, err := s.interestRepo.GetAttachments()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if attachments == nil {
+		attachments = []models.InterestAttachment{}
+	}
+	return attachments, nil
+}
+
+// Attach attaches a product to an account, whose currency and type must be
+// the product's, or to a user, for all of their accounts in the product's
+// currency and type. It replaces what was attached there before, and accrual
+// under the new product starts today.
+func (s *InterestService) Attach(attachment *models.InterestAttachment) error {
+	if (attachment.UserID == nil) == (attachment.AccountID == nil) {
+		return apperrors.ErrInvalidInterestAttachment
+	}
+
+	product, err := s.interestRepo.GetProduct(attachment.ProductID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrInterestProductNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	attachment.Currency = product.Currency
+	attachment.AccountType = product.AccountType
+
+	if attachment.AccountID != nil {
+		account, err := s.accountRepo.GetAccountByID(*attachment.AccountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return apperrors.ErrAccountNotFound
+			}
+			return apperrors.ErrDatabaseError
+		}
+		if account.Currency != product.Currency || account.Type != product.AccountType {
+			return apperrors.ErrInterestProductMismatch
+		}
+	}
+
+	if err := s.interestRepo.UpsertAttachment(attachment); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// Detach stops an attachment. Interest already accrued under it is paid at
+// the account's next payout if another product applies then.
+func (s *InterestService) Detach(attachmentID int64) error {
+	if err := s.interestRepo.DeleteAttachment(attachmentID); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrInterestAttachmentNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// AccountInterest shows the product one of the user's accounts earns under
+// and what it has accrued but not yet been paid.
+func (s *InterestService) AccountInterest(userID int64, accountID int64, now time.Time) (*models.AccountInterest, error) {
+	account, err := s.accountRepo.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrAccountNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if account.UserID != userID {
+		return nil, apperrors.ErrAccountNotFound
+	}
+
+	earner, err := s.interestRepo.GetEarner(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrNoInterest
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	product, err := s.interestRepo.GetProduct(earner.ProductID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	summary := &models.AccountInterest{
+		AccountID:    account.ID,
+		Product:      *product,
+		Accrued:      money.New(0, account.Currency),
+		AccruedExact: "0",
+		NextPayout:   nextPayout(product.Payout, now),
+	}
+
+	accrual, err := s.interestRepo.GetAccrual(accountID)
+	if err == sql.ErrNoRows {
+		return summary, nil
+	}
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	accrued, err := parseDecimal(accrual.Accrued)
+	if err != nil {
+		return nil, apperrors.ErrInternalServer
+	}
+	summary.Accrued = money.New(floorMinor(accrued), account.Currency)
+	summary.AccruedExact = accrued.FloatString(accrualPrecision)
+	summary.AccruedThrough = accrual.AccruedThrough
+	return summary, nil
+}
+
+// AccrueInterest accrues every earning account's interest for each whole
+// UTC day since it was last accrued, up to yesterday, and pays out at the
+// end of each payout period. It is the body of the interest-accrual
+// background job, so days missed while the job was not running are caught
+// up on the next run. One account failing does not stop the others.
+func (s *InterestService) AccrueInterest(ctx context.Context, now time.Time) error {
+	earners, err := s.interestRepo.GetEarners()
+	if err != nil {
+		return err
+	}
+
+	today := startOfDay(now)
+	failed := 0
+	for _, earner := range earners {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.accrueAccount(ctx, earner, today); err != nil {
+			log.Printf("interest accrual: account %d: %v", earner.AccountID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d interest accruals failed", failed, len(earners))
+	}
+	return nil
+}
+
+// accrueAccount catches one account up to the day before today. Each day
+// earns on the account's ledger balance at the end of that day, so a day
+// accrued late earns what it would have on time. The account and accrual
+// rows stay locked throughout, so a second worker finds the days done.
+func (s *InterestService) accrueAccount(ctx context.Context, earner models.InterestEarner, today time.Time) error {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	account, err := s.transactionService.lockAccount(tx, earner.AccountID, "", apperrors.ErrAccountNotFound)
+	if err != nil {
+		return err
+	}
+	accrual, err := s.interestRepo.LockAccrual(tx, account.ID)
+	if err != nil {
+		return err
+	}
+	product, err := s.interestRepo.GetProduct(earner.ProductID)
+	if err != nil {
+		return err
+	}
+
+	first := startOfDay(earner.AttachedAt)
+	if opened := startOfDay(account.CreatedAt); opened.After(first) {
+		first = opened
+	}
+	if accrual.AccruedThrough != nil {
+		if next := startOfDay(*accrual.AccruedThrough).AddDate(0, 0, 1); next.After(first) {
+			first = next
+		}
+	}
+	last := today.AddDate(0, 0, -1)
+	if first.After(last) {
+		return nil
+	}
+
+	accrued, err := parseDecimal(accrual.Accrued)
+	if err != nil {
+		return err
+	}
+	compounded, err := parseDecimal(accrual.Compounded)
+	if err != nil {
+		return err
+	}
+	ledger, err := s.transactionService.accountLedger(tx, account)
+	if err != nil {
+		return err
+	}
+
+	// Payouts made by this run are posted now, after the days they belong
+	// to, so the ledger balance of later days leaves them out.
+	var paid int64
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		ledgerBalance, err := s.ledgerRepo.GetAccountBalanceBefore(tx, ledger, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		// Customer accounts are liabilities, so their balance is the credit side.
+		base := new(big.Rat).SetInt64(paid - ledgerBalance.Amount)
+		if product.Compounding != models.CompoundingNone {
+			base.Add(base, compounded)
+		}
+
+		earned := new(big.Rat)
+		if base.Sign() > 0 {
+			days, yearDays := dayFraction(product.DayCount, day)
+			earned = dailyAccrual(base, product.RateBps, days, yearDays)
+		}
+		accrued.Add(accrued, earned)
+		if compoundsAfter(product.Compounding, day) {
+			compounded.Set(accrued)
+		}
+
+		record := &models.InterestAccrualDay{
+			AccountID: account.ID,
+			Day:       day,
+			ProductID: product.ID,
+			Base:      base.FloatString(accrualPrecision),
+			RateBps:   product.RateBps,
+			Amount:    earned.FloatString(accrualPrecision),
+		}
+
+		if payout := floorMinor(accrued); paysAfter(product.Payout, day) && payout > 0 {
+			description := fmt.Sprintf("interest on account %s through %s", account.Number, day.Format("2006-01-02"))
+			transaction, err := s.transactionService.payInterest(tx, account, money.New(payout, account.Currency), description)
+			if err != nil {
+				return err
+			}
+			record.TransactionID = &transaction.ID
+			paid += payout
+
+			whole := new(big.Rat).SetInt64(payout)
+			accrued.Sub(accrued, whole)
+			compounded.Sub(compounded, whole)
+			if compounded.Sign() < 0 {
+				compounded.SetInt64(0)
+			}
+		}
+
+		if err := s.interestRepo.RecordAccrualDay(tx, record); err != nil {
+			return err
+		}
+	}
+
+	accrual.Accrued = accrued.FloatString(accrualPrecision)
+	accrual.Compounded = compounded.FloatString(accrualPrecision)
+	accrual.AccruedThrough = &last
+	if err := s.interestRepo.SaveAccrual(tx, accrual); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
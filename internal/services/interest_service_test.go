@@ -0,0 +1,78 @@
+package services
+
+import (
+	"MockBankGo/internal/models"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyInterest_RoundsUp(t *testing.T) {
+	// 20% a year on 1000.00 is 200.00, or 0.5479... a day
+	assert.Equal(t, int64(55), dailyInterest(100000, 2000))
+	assert.Equal(t, int64(1), dailyInterest(1, 1))
+	assert.Equal(t, int64(0), dailyInterest(100000, 0))
+	assert.Equal(t, int64(10000), dailyInterest(365*10000, 10000))
+}
+
+func TestDayFraction_Conventions(t *testing.T) {
+	days, yearDays := dayFraction(models.DayCountActual360, date(2025, time.March, 3))
+	assert.Equal(t, [2]int64{1, 360}, [2]int64{days, yearDays})
+
+	days, yearDays = dayFraction(models.DayCountActualActual, date(2024, time.December, 31))
+	assert.Equal(t, [2]int64{1, 366}, [2]int64{days, yearDays})
+
+	days, yearDays = dayFraction(models.DayCountActualActual, date(2025, time.December, 31))
+	assert.Equal(t, [2]int64{1, 365}, [2]int64{days, yearDays})
+
+	// 30E/360: the 30th of a long month earns nothing and the end of
+	// February makes up the month
+	days, _ = dayFraction(models.DayCount30360, date(2025, time.January, 30))
+	assert.Equal(t, int64(0), days)
+	days, _ = dayFraction(models.DayCount30360, date(2025, time.February, 28))
+	assert.Equal(t, int64(3), days)
+	days, _ = dayFraction(models.DayCount30360, date(2025, time.June, 30))
+	assert.Equal(t, int64(1), days)
+}
+
+func TestDays30E360_FullYearIs360(t *testing.T) {
+	total := int64(0)
+	for day := date(2025, time.January, 1); day.Year() == 2025; day = day.AddDate(0, 0, 1) {
+		total += days30E360(day, day.AddDate(0, 0, 1))
+	}
+	assert.Equal(t, int64(360), total)
+}
+
+func TestDailyAccrual_IsExact(t *testing.T) {
+	// 3.65% a year on 1000.00 under act/365 is exactly 0.10 a day
+	accrual := dailyAccrual(big.NewRat(100000, 1), 365, 1, 365)
+	assert.Equal(t, "10.000000000000", accrual.FloatString(accrualPrecision))
+
+	// 1% a year on 0.01 under act/360 keeps the fractions
+	accrual = dailyAccrual(big.NewRat(1, 1), 100, 1, 360)
+	assert.Equal(t, "0.000027777778", accrual.FloatString(accrualPrecision))
+	assert.Equal(t, int64(0), floorMinor(accrual))
+}
+
+func TestCompoundsAndPaysAfter(t *testing.T) {
+	assert.True(t, compoundsAfter(models.CompoundingDaily, date(2025, time.May, 14)))
+	assert.False(t, compoundsAfter(models.CompoundingMonthly, date(2025, time.May, 14)))
+	assert.True(t, compoundsAfter(models.CompoundingMonthly, date(2025, time.May, 31)))
+	assert.False(t, compoundsAfter(models.CompoundingNone, date(2025, time.May, 31)))
+
+	assert.True(t, paysAfter(models.PayoutMonthly, date(2024, time.February, 29)))
+	assert.False(t, paysAfter(models.PayoutMonthly, date(2024, time.February, 28)))
+	assert.False(t, paysAfter(models.PayoutQuarterly, date(2025, time.May, 31)))
+	assert.True(t, paysAfter(models.PayoutQuarterly, date(2025, time.June, 30)))
+	assert.False(t, paysAfter(models.PayoutAnnually, date(2025, time.June, 30)))
+	assert.True(t, paysAfter(models.PayoutAnnually, date(2025, time.December, 31)))
+}
+
+func TestNextPayout(t *testing.T) {
+	assert.Equal(t, date(2025, time.May, 31), nextPayout(models.PayoutMonthly, date(2025, time.May, 31)))
+	assert.Equal(t, date(2025, time.June, 30), nextPayout(models.PayoutQuarterly, date(2025, time.April, 2)))
+	assert.Equal(t, date(2025, time.September, 30), nextPayout(models.PayoutQuarterly, date(2025, time.July, 1)))
+	assert.Equal(t, date(2025, time.December, 31), nextPayout(models.PayoutAnnually, date(2025, time.January, 1)))
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LimitExceeded is returned when a transaction would break a configured
+// limit. ResetsAt is nil for per-transaction limits.
+type LimitExceeded struct {
+	*apperrors.AppError
+	Limit    models.TransactionLimit
+	ResetsAt *time.Time
+}
+
+func newLimitExceeded(limit models.TransactionLimit, resetsAt *time.Time) *LimitExceeded {
+	value := fmt.Sprintf("%d", limit.Value)
+	if limit.Amount != nil {
+		value = limit.Amount.String() + " " + string(limit.Currency)
+	}
+
+	message := fmt.Sprintf("%s %s limit of %s exceeded",
+		strings.ReplaceAll(string(limit.Kind), "_", " "), limit.Operation, value)
+	if limit.Kind.IsCount() {
+		message = fmt.Sprintf("daily %s count limit of %s %s transactions exceeded", limit.Operation, value, limit.Currency)
+	}
+	if resetsAt != nil {
+		message += "; resets at " + resetsAt.Format(time.RFC3339)
+	}
+
+	return &LimitExceeded{
+		AppError: apperrors.NewAppError(message, http.StatusUnprocessableEntity),
+		Limit:    limit,
+		ResetsAt: resetsAt,
+	}
+}
+
+// effectiveLimits keeps, for each kind, the limit from the most specific
+// scope: user over role over global.
+func effectiveLimits(limits []models.TransactionLimit) map[models.LimitKind]models.TransactionLimit {
+	effective := make(map[models.LimitKind]models.TransactionLimit)
+	for _, limit := range limits {
+		current, ok := effective[limit.Kind]
+		if !ok || limit.Scope.Specificity() > current.Scope.Specificity() {
+			effective[limit.Kind] = limit
+		}
+	}
+	return effective
+}
+
+func startOfDay(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// limitResetsAt is when the period a limit counts over ends. Days and
+// months are UTC.
+func limitResetsAt(kind models.LimitKind, now time.Time) *time.Time {
+	var reset time.Time
+	switch kind {
+	case models.LimitDailyAmount, models.LimitDailyCount:
+		reset = startOfDay(now).AddDate(0, 0, 1)
+	case models.LimitMonthlyAmount:
+		reset = startOfMonth(now).AddDate(0, 1, 0)
+	default:
+		return nil
+	}
+	return &reset
+}
+
+type LimitService struct {
+	limitRepo repositories.ILimitRepository
+	database  *sqlx.DB
+}
+
+func NewLimitService(lrepo repositories.ILimitRepository, db *sqlx.DB) *LimitService {
+	return &LimitService{limitRepo: lrepo, database: db}
+}
+
+// Check records the user's usage of the operation and fails if that breaks
+// any limit. It must run inside the transaction that moves the money: the
+// usage row it updates stays locked until commit, which serialises
+// concurrent requests, and a failed check rolls the usage back with
+// everything else.
+func (s *LimitService) Check(tx *sqlx.Tx, userID int64, operation models.TransactionType, amount money.Money, now time.Time) error {
+	limits, err := s.limitRepo.GetLimitsFor(tx, userID, operation, amount.Currency)
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	effective := effectiveLimits(limits)
+
+	if limit, ok := effective[models.LimitPerTransaction]; ok && amount.Amount > limit.Value {
+		return newLimitExceeded(limit, nil)
+	}
+
+	usage, err := s.limitRepo.AddUsage(tx, userID, operation, amount, startOfDay(now))
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	if limit, ok := effective[models.LimitDailyAmount]; ok && usage.Amount > limit.Value {
+		return newLimitExceeded(limit, limitResetsAt(limit.Kind, now))
+	}
+	if limit, ok := effective[models.LimitDailyCount]; ok && usage.Count > limit.Value {
+		return newLimitExceeded(limit, limitResetsAt(limit.Kind, now))
+	}
+	if limit, ok := effective[models.LimitMonthlyAmount]; ok {
+		monthly, err := s.limitRepo.GetUsageSince(tx, userID, operation, amount.Currency, startOfMonth(now))
+		if err != nil {
+			return apperrors.ErrDatabaseError
+		}
+		if monthly > limit.Value {
+			return newLimitExceeded(limit, limitResetsAt(limit.Kind, now))
+		}
+	}
+	return nil
+}
+
+func (s *LimitService) ListLimits() ([]models.TransactionLimit, error) {
+	limits, err := s.limitRepo.GetLimits()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if limits == nil {
+		limits = []models.TransactionLimit{}
+	}
+	return limits, nil
+}
+
+// SetLimit creates or replaces a limit. Amount limits take their value and
+// currency from Amount, count limits from Count and Currency.
+func (s *LimitService) SetLimit(limit *models.TransactionLimit) error {
+	if !limit.Scope.Valid() {
+		return apperrors.ErrInvalidLimitScope
+	}
+	switch limit.Scope {
+	case models.LimitGlobal:
+		limit.Role, limit.UserID = nil, nil
+	case models.LimitRole:
+		if limit.Role == nil || !limit.Role.Valid() {
+			return apperrors.ErrInvalidRole
+		}
+		limit.UserID = nil
+	case models.LimitUser:
+		if limit.UserID == nil {
+			return apperrors.ErrUserNotFound
+		}
+		limit.Role = nil
+	}
+
+	if !operationLimitable(limit.Operation) {
+		return apperrors.ErrInvalidLimitOperation
+	}
+	if !limit.Kind.Valid() {
+		return apperrors.ErrInvalidLimitKind
+	}
+
+	if limit.Kind.IsCount() {
+		if limit.Count == nil || *limit.Count <= 0 {
+			return apperrors.ErrInvalidLimitValue
+		}
+		limit.Value = *limit.Count
+		if limit.Currency == "" {
+			limit.Currency = money.DefaultCurrency
+		}
+	} else {
+		if limit.Amount == nil || !limit.Amount.IsPositive() {
+			return apperrors.ErrInvalidLimitValue
+		}
+		limit.Value = limit.Amount.Amount
+		limit.Currency = limit.Amount.Currency
+	}
+	if !limit.Currency.Valid() {
+		return apperrors.ErrUnsupportedCurrency
+	}
+
+	if err := s.limitRepo.UpsertLimit(limit); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *LimitService) DeleteLimit(limitID int64) error {
+	if err := s.limitRepo.DeleteLimit(limitID); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrLimitNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func operationLimitable(operation models.TransactionType) bool {
+	for _, op := range models.LimitOperations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveLimits_MostSpecificScopeWins(t *testing.T) {
+	limits := []models.TransactionLimit{
+		{ID: 1, Scope: models.LimitUser, Kind: models.LimitDailyAmount, Value: 500},
+		{ID: 2, Scope: models.LimitGlobal, Kind: models.LimitDailyAmount, Value: 1000},
+		{ID: 3, Scope: models.LimitRole, Kind: models.LimitDailyAmount, Value: 2000},
+		{ID: 4, Scope: models.LimitGlobal, Kind: models.LimitPerTransaction, Value: 300},
+		{ID: 5, Scope: models.LimitRole, Kind: models.LimitPerTransaction, Value: 400},
+	}
+
+	effective := effectiveLimits(limits)
+
+	assert.Equal(t, int64(1), effective[models.LimitDailyAmount].ID)
+	assert.Equal(t, int64(5), effective[models.LimitPerTransaction].ID)
+	_, ok := effective[models.LimitMonthlyAmount]
+	assert.False(t, ok)
+}
+
+func TestLimitResetsAt(t *testing.T) {
+	now := time.Date(2025, 12, 31, 18, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *limitResetsAt(models.LimitDailyAmount, now))
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *limitResetsAt(models.LimitMonthlyAmount, now))
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *limitResetsAt(models.LimitDailyCount, now))
+	assert.Nil(t, limitResetsAt(models.LimitPerTransaction, now))
+
+	mid := time.Date(2025, 6, 15, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), *limitResetsAt(models.LimitMonthlyAmount, mid))
+}
+
+func TestNewLimitExceeded_Message(t *testing.T) {
+	resetsAt := time.Date(2025, 6, 25, 0, 0, 0, 0, time.UTC)
+	amountLimit := models.TransactionLimit{
+		Operation: models.Transfer,
+		Kind:      models.LimitDailyAmount,
+		Currency:  money.USD,
+		Value:     100000,
+		Amount:    &money.Money{Amount: 100000, Currency: money.USD},
+	}
+	countLimit := models.TransactionLimit{
+		Operation: models.Transfer,
+		Kind:      models.LimitDailyCount,
+		Currency:  money.USD,
+		Value:     5,
+	}
+
+	assert.Equal(t, "daily amount transfer limit of 1000.00 USD exceeded; resets at 2025-06-25T00:00:00Z",
+		newLimitExceeded(amountLimit, &resetsAt).Message)
+	assert.Equal(t, "daily transfer count limit of 5 USD transactions exceeded; resets at 2025-06-25T00:00:00Z",
+		newLimitExceeded(countLimit, &resetsAt).Message)
+	assert.Equal(t, 422, newLimitExceeded(countLimit, nil).StatusCode)
+}
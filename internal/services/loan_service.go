@@ -0,0 +1,568 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	monthsPerYear     = 12
+	maxLoanTermMonths = 360
+
+	// loanGraceDays is how many days after its due date an unpaid
+	// installment is charged the loan's late fee.
+	loanGraceDays = 3
+)
+
+// monthlyRate returns a yearly rate in basis points as a monthly fraction.
+func monthlyRate(bps int) *big.Rat {
+	return big.NewRat(int64(bps), bpsPerUnit*monthsPerYear)
+}
+
+// roundMinor rounds a non-negative amount to the nearest minor unit, halves
+// up.
+func roundMinor(r *big.Rat) int64 {
+	return floorMinor(new(big.Rat).Add(r, big.NewRat(1, 2)))
+}
+
+// annuityPayment returns the fixed monthly payment that repays principal
+// with interest at rate a month over n months, rounded to the minor unit.
+func annuityPayment(principal int64, rate *big.Rat, n int) int64 {
+	p := new(big.Rat).SetInt64(principal)
+	if rate.Sign() == 0 {
+		return roundMinor(p.Quo(p, big.NewRat(int64(n), 1)))
+	}
+
+	// principal * rate * (1+rate)^n / ((1+rate)^n - 1)
+	growth := big.NewRat(1, 1)
+	factor := new(big.Rat).Add(big.NewRat(1, 1), rate)
+	for i := 0; i < n; i++ {
+		growth.Mul(growth, factor)
+	}
+	payment := new(big.Rat).Mul(p, rate)
+	payment.Mul(payment, growth)
+	payment.Quo(payment, new(big.Rat).Sub(growth, big.NewRat(1, 1)))
+	return roundMinor(payment)
+}
+
+// loanSchedule splits outstanding into one installment per due date,
+// numbered from firstSeq. Each installment pays a month's interest on what
+// is still outstanding, rounded to the minor unit. The last installment
+// repays whatever principal rounding has left over.
+func loanSchedule(method models.LoanMethod, outstanding money.Money, rateBps int, dueDates []time.Time, firstSeq int) []models.LoanInstallment {
+	n := len(dueDates)
+	if n == 0 {
+		return nil
+	}
+	rate := monthlyRate(rateBps)
+	remaining := outstanding.Amount
+	payment := annuityPayment(remaining, rate, n)
+	equalPrincipal := remaining / int64(n)
+
+	installments := make([]models.LoanInstallment, n)
+	for k, due := range dueDates {
+		interest := roundMinor(new(big.Rat).Mul(new(big.Rat).SetInt64(remaining), rate))
+
+		principal := equalPrincipal
+		if method == models.LoanAnnuity {
+			principal = max(payment-interest, 0)
+		}
+		if k == n-1 || principal > remaining {
+			principal = remaining
+		}
+		remaining -= principal
+
+		installments[k] = models.LoanInstallment{
+			Seq:       firstSeq + k,
+			DueDate:   due,
+			Principal: money.New(principal, outstanding.Currency),
+			Interest:  money.New(interest, outstanding.Currency),
+			LateFee:   money.New(0, outstanding.Currency),
+			Status:    models.InstallmentDue,
+			Currency:  outstanding.Currency,
+		}
+	}
+	return installments
+}
+
+// installmentDate returns when installment seq of a loan disbursed on
+// disbursed falls due: the same day of the month, seq months later, or the
+// end of shorter months. Installment 0 is the disbursement itself.
+func installmentDate(disbursed time.Time, seq int) time.Time {
+	return addMonthsClamped(today(disbursed), seq)
+}
+
+// earlyRepaymentInterest is the interest on an amount repaid before the
+// installment due next, for the share of the month since the last one.
+func earlyRepaymentInterest(amount money.Money, rateBps int, lastDue time.Time, nextDue time.Time, on time.Time) money.Money {
+	elapsed := int64(on.Sub(lastDue).Hours() / 24)
+	period := int64(nextDue.Sub(lastDue).Hours() / 24)
+	if elapsed <= 0 || period <= 0 {
+		return money.New(0, amount.Currency)
+	}
+
+	interest := new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Amount), monthlyRate(rateBps))
+	interest.Mul(interest, big.NewRat(elapsed, period))
+	return money.New(roundMinor(interest), amount.Currency)
+}
+
+type LoanService struct {
+	loanRepo            repositories.ILoanRepository
+	accountRepo         repositories.IAccountRepository
+	transactionService  *TransactionService
+	notificationService *NotificationService
+	auditService        *AuditService
+	database            *sqlx.DB
+}
+
+func NewLoanService(lrepo repositories.ILoanRepository, arepo repositories.IAccountRepository, transactionService *TransactionService, notificationService *NotificationService, auditService *AuditService, db *sqlx.DB) *LoanService {
+	return &LoanService{loanRepo: lrepo, accountRepo: arepo, transactionService: transactionService, notificationService: notificationService, auditService: auditService, database: db}
+}
+
+// Apply records the user's application for a loan of loan.Principal, to be
+// paid into and repaid from one of their open accounts in its currency.
+func (s *LoanService) Apply(userID int64, loan *models.Loan) error {
+	if err := s.transactionService.validateAmount(loan.Principal); err != nil {
+		return err
+	}
+	if loan.TermMonths < 1 || loan.TermMonths > maxLoanTermMonths || !loan.Method.Valid() {
+		return apperrors.ErrInvalidLoan
+	}
+
+	account, err := s.accountRepo.GetAccountByID(loan.AccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrAccountNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if account.UserID != userID {
+		return apperrors.ErrAccountNotFound
+	}
+	if account.Status != models.AccountOpen {
+		return apperrors.ErrAccountClosed
+	}
+	if loan.Principal.Currency != account.Currency {
+		return apperrors.ErrCurrencyMismatch
+	}
+
+	loan.UserID = userID
+	loan.Currency = account.Currency
+	loan.Outstanding = loan.Principal
+	loan.Status = models.LoanPending
+	if err := s.loanRepo.CreateLoan(loan); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	loan.LateFee.Currency = loan.Currency
+	return nil
+}
+
+func (s *LoanService) ListLoans(userID int64) ([]models.Loan, error) {
+	loans, err := s.loanRepo.GetLoansByUser(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if loans == nil {
+		loans = []models.Loan{}
+	}
+	return loans, nil
+}
+
+// ListAllLoans returns every loan in a status, or all loans when status is
+// empty.
+func (s *LoanService) ListAllLoans(status models.LoanStatus) ([]models.Loan, error) {
+	loans, err := s.loanRepo.GetLoans(status)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if loans == nil {
+		loans = []models.Loan{}
+	}
+	return loans, nil
+}
+
+// details loads a loan's schedule.
+func (s *LoanService) details(loan *models.Loan) (*models.LoanDetails, error) {
+	schedule, err := s.loanRepo.GetSchedule(loan.ID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if schedule == nil {
+		schedule = []models.LoanInstallment{}
+	}
+	return &models.LoanDetails{Loan: loan, Schedule: schedule}, nil
+}
+
+// GetLoan returns one of the user's loans with its repayment schedule.
+func (s *LoanService) GetLoan(userID int64, loanID int64) (*models.LoanDetails, error) {
+	loan, err := s.loanRepo.GetLoan(loanID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrLoanNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if loan.UserID != userID {
+		return nil, apperrors.ErrLoanNotFound
+	}
+	return s.details(loan)
+}
+
+// lockPendingLoan locks a loan that has not been decided yet.
+func (s *LoanService) lockPendingLoan(tx *sqlx.Tx, loanID int64) (*models.Loan, error) {
+	loan, err := s.loanRepo.GetLoanForUpdate(tx, loanID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrLoanNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if loan.Status != models.LoanPending {
+		return nil, apperrors.ErrLoanNotPending
+	}
+	return loan, nil
+}
+
+// Approve sets a pending loan's yearly rate and late fee and disburses it
+// into the borrower's account. Its first installment falls due a month
+// later.
+func (s *LoanService) Approve(ctx context.Context, adminID int64, loanID int64, rateBps int, lateFee money.Money, now time.Time) (*models.LoanDetails, error) {
+	if rateBps < 0 || rateBps > bpsPerUnit || lateFee.IsNegative() {
+		return nil, apperrors.ErrInvalidLoanTerms
+	}
+
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	loan, err := s.lockPendingLoan(tx, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if lateFee.IsZero() {
+		lateFee.Currency = loan.Currency
+	}
+	if lateFee.Currency != loan.Currency {
+		return nil, apperrors.ErrInvalidLoanTerms
+	}
+
+	account, err := s.transactionService.lockAccount(tx, loan.AccountID, loan.Currency, apperrors.ErrAccountNotFound)
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := s.transactionService.disburseLoan(tx, account, loan.Principal, fmt.Sprintf("loan %d disbursed to account %s", loan.ID, account.Number))
+	if err != nil {
+		return nil, err
+	}
+
+	loan.RateBps = &rateBps
+	loan.LateFee = lateFee
+	loan.Status = models.LoanActive
+	loan.DecidedBy = &adminID
+	loan.DecidedAt = &now
+	loan.DisbursedAt = &now
+	loan.DisbursementTransactionID = &transaction.ID
+
+	dueDates := make([]time.Time, loan.TermMonths)
+	for k := range dueDates {
+		dueDates[k] = installmentDate(now, k+1)
+	}
+	schedule := loanSchedule(loan.Method, loan.Outstanding, rateBps, dueDates, 1)
+	if err := s.loanRepo.ReplaceInstallments(tx, loan.ID, 1, schedule); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if err := s.loanRepo.UpdateLoan(tx, loan); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.Amount = &loan.Principal
+		entry.Details = withAuditDetail(entry.Details, "transaction_id", transaction.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &models.LoanDetails{Loan: loan, Schedule: schedule}, nil
+}
+
+// Reject turns down a pending loan.
+func (s *LoanService) Reject(ctx context.Context, adminID int64, loanID int64, now time.Time) (*models.Loan, error) {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	loan, err := s.lockPendingLoan(tx, loanID)
+	if err != nil {
+		return nil, err
+	}
+
+	loan.Status = models.LoanRejected
+	loan.DecidedBy = &adminID
+	loan.DecidedAt = &now
+	loan.ClosedAt = &now
+	if err := s.loanRepo.UpdateLoan(tx, loan); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if err := s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return loan, nil
+}
+
+// RepayEarly repays amount of a loan's principal ahead of schedule, or all
+// of it when amount is nil, plus interest on it since the last installment.
+// The installments left are recalculated on what is still outstanding, over
+// the same due dates, so each of them gets smaller. Installments already due
+// must be collected first.
+func (s *LoanService) RepayEarly(ctx context.Context, userID int64, loanID int64, amount *money.Money, now time.Time) (_ *models.LoanDetails, _ *models.TransactionInfo, err error) {
+	// A repayment refused for lack of funds is recorded like a withdrawal.
+	// Deferred before the rollback below, so it runs after it.
+	var attempt *models.TransactionInfo
+	defer func() {
+		if attempt != nil {
+			s.transactionService.recordFailure(*attempt, err)
+		}
+	}()
+
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	loan, err := s.loanRepo.GetLoanForUpdate(tx, loanID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, apperrors.ErrLoanNotFound
+		}
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	if loan.UserID != userID {
+		return nil, nil, apperrors.ErrLoanNotFound
+	}
+	if loan.Status != models.LoanActive {
+		return nil, nil, apperrors.ErrLoanNotActive
+	}
+
+	repay := loan.Outstanding
+	if amount != nil {
+		repay = *amount
+		if !repay.IsPositive() || repay.Currency != loan.Currency || repay.Cmp(loan.Outstanding) > 0 {
+			return nil, nil, apperrors.ErrInvalidLoanRepayment
+		}
+	}
+
+	unpaid, err := s.loanRepo.GetUnpaidInstallments(tx, loan.ID)
+	if err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	if len(unpaid) == 0 {
+		return nil, nil, apperrors.ErrLoanNotActive
+	}
+	next := unpaid[0]
+	if !next.DueDate.After(today(now)) {
+		return nil, nil, apperrors.ErrLoanInArrears
+	}
+
+	account, err := s.transactionService.lockAccount(tx, loan.AccountID, loan.Currency, apperrors.ErrAccountNotFound)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lastDue := installmentDate(*loan.DisbursedAt, next.Seq-1)
+	interest := earlyRepaymentInterest(repay, *loan.RateBps, lastDue, next.DueDate, today(now))
+	attempt = &models.TransactionInfo{
+		SenderID:      userID,
+		ReceiverID:    userID,
+		FromAccountID: &account.ID,
+		Amount:        repay.Add(interest),
+		Type:          models.LoanRepayment,
+	}
+	transaction, err := s.transactionService.collectLoan(tx, account, repay, interest, money.New(0, loan.Currency),
+		fmt.Sprintf("early repayment of loan %d from account %s", loan.ID, account.Number))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loan.Outstanding = loan.Outstanding.Sub(repay)
+	var schedule []models.LoanInstallment
+	if loan.Outstanding.IsZero() {
+		loan.Status = models.LoanRepaid
+		loan.ClosedAt = &now
+	} else {
+		dueDates := make([]time.Time, len(unpaid))
+		for k, installment := range unpaid {
+			dueDates[k] = installment.DueDate
+		}
+		schedule = loanSchedule(loan.Method, loan.Outstanding, *loan.RateBps, dueDates, next.Seq)
+	}
+	if err := s.loanRepo.ReplaceInstallments(tx, loan.ID, next.Seq, schedule); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	if err := s.loanRepo.UpdateLoan(tx, loan); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.Amount = &transaction.Amount
+		entry.Details = withAuditDetail(entry.Details, "transaction_id", transaction.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+
+	details, err := s.details(loan)
+	if err != nil {
+		return nil, nil, err
+	}
+	return details, transaction, nil
+}
+
+// CollectInstallments collects every installment due by now from its loan's
+// account. It is the body of the loan-installments background job. An
+// installment the account cannot pay is tried again the next day, and is
+// charged the loan's late fee once it is more than loanGraceDays overdue.
+// One loan failing does not stop the others.
+func (s *LoanService) CollectInstallments(ctx context.Context, now time.Time) error {
+	loanIDs, err := s.loanRepo.GetLoansDue(today(now))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, loanID := range loanIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.collectDue(ctx, loanID, now); err != nil {
+			log.Printf("loan installments: loan %d: %v", loanID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d loan collections failed", failed, len(loanIDs))
+	}
+	return nil
+}
+
+// collectDue collects a loan's due installments in order, stopping at the
+// first one the account cannot pay. The loan stays locked throughout, so a
+// second worker waits and then finds the installments paid or tried today.
+func (s *LoanService) collectDue(ctx context.Context, loanID int64, now time.Time) (err error) {
+	// Deferred before the rollback below, so it runs after it.
+	var attempt *models.TransactionInfo
+	defer func() {
+		if attempt != nil {
+			s.transactionService.recordFailure(*attempt, apperrors.ErrInsufficientFunds)
+		}
+	}()
+
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	loan, err := s.loanRepo.GetLoanForUpdate(tx, loanID)
+	if err != nil {
+		return err
+	}
+	if loan.Status != models.LoanActive {
+		return nil
+	}
+	unpaid, err := s.loanRepo.GetUnpaidInstallments(tx, loan.ID)
+	if err != nil {
+		return err
+	}
+	account, err := s.transactionService.lockAccount(tx, loan.AccountID, loan.Currency, apperrors.ErrAccountNotFound)
+	if err != nil {
+		return err
+	}
+
+	day := today(now)
+	var refused *models.TransactionInfo
+	for i := range unpaid {
+		installment := &unpaid[i]
+		if installment.DueDate.After(day) {
+			break
+		}
+		if installment.LastAttemptOn != nil && !installment.LastAttemptOn.Before(day) {
+			break
+		}
+		installment.LastAttemptOn = &day
+
+		overdue := day.Sub(installment.DueDate) > loanGraceDays*24*time.Hour
+		if overdue && installment.LateFee.IsZero() && loan.LateFee.IsPositive() {
+			installment.LateFee = loan.LateFee
+			message := fmt.Sprintf("Installment %d of loan %d, due %s, is overdue. A late fee of %s %s was added to it.",
+				installment.Seq, loan.ID, installment.DueDate.Format("2006-01-02"), loan.LateFee, loan.Currency)
+			if err := s.notificationService.Notify(tx, loan.UserID, models.NotificationLoanOverdue, &account.ID, message); err != nil {
+				return err
+			}
+		}
+
+		transaction, err := s.transactionService.collectLoan(tx, account, installment.Principal, installment.Interest, installment.LateFee,
+			fmt.Sprintf("installment %d of loan %d from account %s", installment.Seq, loan.ID, account.Number))
+		if err == apperrors.ErrInsufficientFunds {
+			refused = &models.TransactionInfo{
+				SenderID:      loan.UserID,
+				ReceiverID:    loan.UserID,
+				FromAccountID: &account.ID,
+				Amount:        installment.Principal.Add(installment.Interest).Add(installment.LateFee),
+				Type:          models.LoanRepayment,
+			}
+			if err := s.loanRepo.UpdateInstallment(tx, installment); err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		installment.Status = models.InstallmentPaid
+		installment.TransactionID = &transaction.ID
+		installment.PaidAt = &now
+		if err := s.loanRepo.UpdateInstallment(tx, installment); err != nil {
+			return err
+		}
+		loan.Outstanding = loan.Outstanding.Sub(installment.Principal)
+		if i == len(unpaid)-1 {
+			loan.Status = models.LoanRepaid
+			loan.ClosedAt = &now
+		}
+	}
+
+	if err := s.loanRepo.UpdateLoan(tx, loan); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	attempt = refused
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func monthlyDueDates(disbursed time.Time, n int) []time.Time {
+	dates := make([]time.Time, n)
+	for k := range dates {
+		dates[k] = installmentDate(disbursed, k+1)
+	}
+	return dates
+}
+
+func TestAnnuityPayment(t *testing.T) {
+	// 10,000.00 at 12% a year over 12 months is 888.4879 a month
+	assert.Equal(t, int64(88849), annuityPayment(1000000, monthlyRate(1200), 12))
+	assert.Equal(t, int64(83333), annuityPayment(1000000, monthlyRate(0), 12))
+}
+
+func TestLoanSchedule_AnnuityRepaysPrincipal(t *testing.T) {
+	principal := money.New(1000000, money.USD)
+	schedule := loanSchedule(models.LoanAnnuity, principal, 1200, monthlyDueDates(date(2025, time.January, 15), 12), 1)
+
+	assert.Len(t, schedule, 12)
+	assert.Equal(t, money.New(10000, money.USD), schedule[0].Interest)
+	assert.Equal(t, money.New(78849, money.USD), schedule[0].Principal)
+
+	var repaid int64
+	for k, installment := range schedule {
+		assert.Equal(t, k+1, installment.Seq)
+		repaid += installment.Principal.Amount
+		if k < len(schedule)-1 {
+			assert.Equal(t, int64(88849), installment.Principal.Amount+installment.Interest.Amount)
+		}
+	}
+	assert.Equal(t, principal.Amount, repaid)
+}
+
+func TestLoanSchedule_EqualPrincipalShrinks(t *testing.T) {
+	principal := money.New(120000, money.USD)
+	schedule := loanSchedule(models.LoanEqualPrincipal, principal, 1200, monthlyDueDates(date(2025, time.January, 15), 12), 1)
+
+	assert.Equal(t, money.New(10000, money.USD), schedule[0].Principal)
+	assert.Equal(t, money.New(1200, money.USD), schedule[0].Interest)
+	assert.Equal(t, money.New(1100, money.USD), schedule[1].Interest)
+	assert.Equal(t, money.New(100, money.USD), schedule[11].Interest)
+
+	// A remainder that does not divide evenly goes to the last installment.
+	schedule = loanSchedule(models.LoanEqualPrincipal, money.New(1000, money.USD), 0, monthlyDueDates(date(2025, time.January, 15), 3), 4)
+	assert.Equal(t, 4, schedule[0].Seq)
+	assert.Equal(t, int64(333), schedule[0].Principal.Amount)
+	assert.Equal(t, int64(334), schedule[2].Principal.Amount)
+}
+
+func TestInstallmentDate_ClampsToMonthEnd(t *testing.T) {
+	disbursed := time.Date(2025, time.January, 31, 15, 4, 0, 0, time.UTC)
+	assert.Equal(t, date(2025, time.February, 28), installmentDate(disbursed, 1))
+	assert.Equal(t, date(2025, time.March, 31), installmentDate(disbursed, 2))
+	assert.Equal(t, date(2025, time.January, 31), installmentDate(disbursed, 0))
+}
+
+func TestEarlyRepaymentInterest_ProRata(t *testing.T) {
+	amount := money.New(100000, money.USD)
+
+	// Half of a 1% month on 1,000.00
+	interest := earlyRepaymentInterest(amount, 1200, date(2025, time.April, 15), date(2025, time.May, 15), date(2025, time.April, 30))
+	assert.Equal(t, money.New(500, money.USD), interest)
+
+	interest = earlyRepaymentInterest(amount, 1200, date(2025, time.April, 15), date(2025, time.May, 15), date(2025, time.April, 15))
+	assert.True(t, interest.IsZero())
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationService struct {
+	notificationRepo repositories.INotificationRepository
+	database         *sqlx.DB
+}
+
+func NewNotificationService(nrepo repositories.INotificationRepository, db *sqlx.DB) *NotificationService {
+	return &NotificationService{notificationRepo: nrepo, database: db}
+}
+
+// Notify leaves a message for the user as part of tx.
+func (s *NotificationService) Notify(tx *sqlx.Tx, userID int64, kind models.NotificationKind, accountID *int64, message string) error {
+	notification := &models.Notification{
+		UserID:    userID,
+		Kind:      kind,
+		Message:   message,
+		AccountID: accountID,
+	}
+	if err := s.notificationRepo.CreateNotification(tx, notification); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *NotificationService) ListNotifications(userID int64, unreadOnly bool) ([]models.Notification, error) {
+	notifications, err := s.notificationRepo.GetNotifications(userID, unreadOnly)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if notifications == nil {
+		notifications = []models.Notification{}
+	}
+	return notifications, nil
+}
+
+func (s *NotificationService) MarkRead(userID int64, notificationID int64, now time.Time) (*models.Notification, error) {
+	notification, err := s.notificationRepo.MarkRead(userID, notificationID, now)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrNotificationNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	return notification, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type OverdraftService struct {
+	overdraftRepo       repositories.IOverdraftRepository
+	notificationService *NotificationService
+	database            *sqlx.DB
+}
+
+func NewOverdraftService(orepo repositories.IOverdraftRepository, notificationService *NotificationService, db *sqlx.DB) *OverdraftService {
+	return &OverdraftService{overdraftRepo: orepo, notificationService: notificationService, database: db}
+}
+
+// formatBps formats basis points as a percentage, e.g. 1990 as "19.90%".
+func formatBps(bps int) string {
+	return fmt.Sprintf("%d.%02d%%", bps/100, bps%100)
+}
+
+// Cover checks that account, locked in tx, can pay debit. What the available
+// balance cannot pay must fit in what remains of the owner's overdraft,
+// counting what their other accounts already use. Only checking accounts can
+// be overdrawn. An account going below zero has its owner notified.
+func (s *OverdraftService) Cover(tx *sqlx.Tx, account *models.Account, debit money.Money) error {
+	if account.Available.Cmp(debit) >= 0 {
+		return nil
+	}
+	if account.Type != models.Checking {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	overdraft, err := s.overdraftRepo.DrawOverdraft(tx, account.UserID, account.Currency, time.Now())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrInsufficientFunds
+		}
+		return apperrors.ErrDatabaseError
+	}
+
+	usedElsewhere, err := s.overdraftRepo.GetUsedElsewhere(tx, account.UserID, account.Currency, account.ID)
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	shortfall := debit.Sub(account.Available)
+	if shortfall.Add(usedElsewhere).Cmp(overdraft.Limit) > 0 {
+		return apperrors.ErrInsufficientFunds
+	}
+
+	if account.Available.IsNegative() {
+		return nil
+	}
+	after := account.Available.Sub(debit)
+	message := fmt.Sprintf("Account %s is overdrawn: its available balance is %s %s. Interest of %s a year is charged daily while the balance is below zero.",
+		account.Number, after, account.Currency, formatBps(overdraft.InterestBps))
+	return s.notificationService.Notify(tx, account.UserID, models.NotificationOverdraftEntered, &account.ID, message)
+}
+
+// ListOverdrafts returns the user's overdrafts with what is used and what
+// remains of each.
+func (s *OverdraftService) ListOverdrafts(userID int64) ([]models.Overdraft, error) {
+	overdrafts, err := s.overdraftRepo.GetOverdrafts(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if overdrafts == nil {
+		overdrafts = []models.Overdraft{}
+	}
+	return overdrafts, nil
+}
+
+// SetOverdraft creates or replaces the user's overdraft in the limit's
+// currency. A zero limit stops new borrowing; interest is still charged on
+// what is already owed.
+func (s *OverdraftService) SetOverdraft(overdraft *models.Overdraft) error {
+	if !overdraft.Limit.Currency.Valid() || overdraft.Limit.IsNegative() {
+		return apperrors.ErrInvalidOverdraft
+	}
+	if overdraft.InterestBps < 0 || overdraft.InterestBps > bpsPerUnit {
+		return apperrors.ErrInvalidOverdraft
+	}
+	overdraft.Currency = overdraft.Limit.Currency
+
+	if err := s.overdraftRepo.UpsertOverdraft(overdraft); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+
+	overdrafts, err := s.ListOverdrafts(overdraft.UserID)
+	if err != nil {
+		return err
+	}
+	for _, saved := range overdrafts {
+		if saved.Currency == overdraft.Currency {
+			*overdraft = saved
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOverdrafts serves one overdraft, or none when overdraft is nil.
+type fakeOverdrafts struct {
+	repositories.IOverdraftRepository
+	overdraft     *models.Overdraft
+	usedElsewhere int64
+}
+
+func (f *fakeOverdrafts) DrawOverdraft(tx *sqlx.Tx, userID int64, currency money.Currency, now time.Time) (*models.Overdraft, error) {
+	if f.overdraft == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.overdraft, nil
+}
+
+func (f *fakeOverdrafts) GetUsedElsewhere(tx *sqlx.Tx, userID int64, currency money.Currency, accountID int64) (money.Money, error) {
+	return money.New(f.usedElsewhere, currency), nil
+}
+
+type fakeNotifications struct {
+	repositories.INotificationRepository
+	sent []models.Notification
+}
+
+func (f *fakeNotifications) CreateNotification(tx *sqlx.Tx, notification *models.Notification) error {
+	f.sent = append(f.sent, *notification)
+	return nil
+}
+
+func checking(balance int64) *models.Account {
+	return &models.Account{ID: 1, UserID: 7, Number: "000000000001", Type: models.Checking, Currency: money.USD,
+		Balance: money.New(balance, money.USD), Available: money.New(balance, money.USD)}
+}
+
+func TestCover_AvailableBalanceNeedsNoOverdraft(t *testing.T) {
+	service := NewOverdraftService(nil, nil, nil)
+	assert.NoError(t, service.Cover(nil, checking(1000), money.New(1000, money.USD)))
+}
+
+func TestCover_WithoutOverdraftIsInsufficientFunds(t *testing.T) {
+	service := NewOverdraftService(&fakeOverdrafts{}, nil, nil)
+	assert.Equal(t, apperrors.ErrInsufficientFunds, service.Cover(nil, checking(1000), money.New(1001, money.USD)))
+
+	savings := checking(1000)
+	savings.Type = models.Savings
+	assert.Equal(t, apperrors.ErrInsufficientFunds, service.Cover(nil, savings, money.New(1001, money.USD)))
+}
+
+func TestCover_LimitIsSharedAcrossAccounts(t *testing.T) {
+	overdraft := &models.Overdraft{Limit: money.New(5000, money.USD), InterestBps: 1990}
+	service := NewOverdraftService(&fakeOverdrafts{overdraft: overdraft, usedElsewhere: 2000}, NewNotificationService(&fakeNotifications{}, nil), nil)
+
+	assert.NoError(t, service.Cover(nil, checking(1000), money.New(4000, money.USD)))
+	assert.Equal(t, apperrors.ErrInsufficientFunds, service.Cover(nil, checking(1000), money.New(4001, money.USD)))
+}
+
+func TestCover_NotifiesOnlyWhenEnteringOverdraft(t *testing.T) {
+	overdraft := &models.Overdraft{Limit: money.New(5000, money.USD), InterestBps: 1990}
+	notifications := &fakeNotifications{}
+	service := NewOverdraftService(&fakeOverdrafts{overdraft: overdraft}, NewNotificationService(notifications, nil), nil)
+
+	assert.NoError(t, service.Cover(nil, checking(1000), money.New(1500, money.USD)))
+	assert.NoError(t, service.Cover(nil, checking(-500), money.New(1000, money.USD)))
+
+	if assert.Len(t, notifications.sent, 1) {
+		sent := notifications.sent[0]
+		assert.Equal(t, models.NotificationOverdraftEntered, sent.Kind)
+		assert.Equal(t, int64(7), sent.UserID)
+		assert.Contains(t, sent.Message, "-5.00 USD")
+		assert.Contains(t, sent.Message, "19.90%")
+	}
+}
+
+func TestSetOverdraft_RejectsInvalidInput(t *testing.T) {
+	service := NewOverdraftService(nil, nil, nil)
+
+	assert.Equal(t, apperrors.ErrInvalidOverdraft, service.SetOverdraft(&models.Overdraft{Limit: money.New(-1, money.USD)}))
+	assert.Equal(t, apperrors.ErrInvalidOverdraft, service.SetOverdraft(&models.Overdraft{Limit: money.New(100, "XXX")}))
+	assert.Equal(t, apperrors.ErrInvalidOverdraft, service.SetOverdraft(&models.Overdraft{Limit: money.New(100, money.USD), InterestBps: 10001}))
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/iban"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"database/sql"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxPayeeNameLength = 100
+
+	// visibleAccountDigits is how much of an account number a masked one
+	// still shows.
+	visibleAccountDigits = 4
+)
+
+// maskName keeps the first letter of each word of a name and hides the
+// rest, so "Jane Doe" becomes "J*** D**".
+func maskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(first) + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+	}
+	return strings.Join(words, " ")
+}
+
+// maskAccountNumber hides all but the last visibleAccountDigits of an
+// account number.
+func maskAccountNumber(number string) string {
+	if len(number) <= visibleAccountDigits {
+		return number
+	}
+	return strings.Repeat("*", len(number)-visibleAccountDigits) + number[len(number)-visibleAccountDigits:]
+}
+
+// receivingAccount picks which of a user's accounts money sent to them by
+// username or email goes to: an open one in currency if they have one,
+// checking before savings, oldest first. accounts are ordered by ID.
+func receivingAccount(accounts []models.Account, currency money.Currency) *models.Account {
+	var best *models.Account
+	bestRank := 0
+	for i := range accounts {
+		account := &accounts[i]
+		if account.Status != models.AccountOpen {
+			continue
+		}
+		rank := 1
+		if account.Currency == currency {
+			rank += 2
+		}
+		if account.Type == models.Checking {
+			rank++
+		}
+		if rank > bestRank {
+			best, bestRank = account, rank
+		}
+	}
+	return best
+}
+
+type PayeeService struct {
+	payeeRepo   repositories.IPayeeRepository
+	userRepo    repositories.IUserRepository
+	accountRepo repositories.IAccountRepository
+	database    *sqlx.DB
+}
+
+func NewPayeeService(prepo repositories.IPayeeRepository, urepo repositories.IUserRepository, arepo repositories.IAccountRepository, db *sqlx.DB) *PayeeService {
+	return &PayeeService{payeeRepo: prepo, userRepo: urepo, accountRepo: arepo, database: db}
+}
+
+// masked hides a payee's account number and its owner's name.
+func masked(payee *models.Payee) *models.Payee {
+	payee.AccountNumber = maskAccountNumber(payee.AccountNumber)
+	payee.DisplayName = maskName(payee.DisplayName)
+	return payee
+}
+
+// recipientAccount finds the account a query names. Money for a user goes
+// to the account receivingAccount picks for currency.
+func (s *PayeeService) recipientAccount(userID int64, query models.RecipientQuery, currency money.Currency) (*models.Account, error) {
+	given := 0
+	for _, set := range []bool{query.AccountID != nil, query.AccountNumber != "", query.IBAN != "", query.Username != "", query.Email != "", query.PayeeID != nil} {
+		if set {
+			given++
+		}
+	}
+	if given == 0 {
+		return nil, apperrors.ErrReceiverNotProvided
+	}
+	if given > 1 {
+		return nil, apperrors.ErrInvalidRecipient
+	}
+
+	var account *models.Account
+	var err error
+	switch {
+	case query.AccountID != nil:
+		account, err = s.accountRepo.GetAccountByID(*query.AccountID)
+	case query.AccountNumber != "":
+		account, err = s.accountRepo.GetAccountByNumber(query.AccountNumber)
+	case query.IBAN != "":
+		normalized, ibanErr := iban.Parse(query.IBAN)
+		if ibanErr != nil {
+			return nil, apperrors.ErrInvalidIBAN
+		}
+		account, err = s.accountRepo.GetAccountByIBAN(normalized)
+	case query.PayeeID != nil:
+		payee, payeeErr := s.payeeRepo.GetPayee(*query.PayeeID)
+		if payeeErr != nil {
+			if payeeErr == sql.ErrNoRows {
+				return nil, apperrors.ErrPayeeNotFound
+			}
+			return nil, apperrors.ErrDatabaseError
+		}
+		if payee.UserID != userID {
+			return nil, apperrors.ErrPayeeNotFound
+		}
+		account, err = s.accountRepo.GetAccountByID(payee.AccountID)
+	default:
+		var user *models.User
+		if query.Username != "" {
+			user, err = s.userRepo.GetUserByUsername(query.Username)
+		} else {
+			user, err = s.userRepo.GetUserByEmail(query.Email)
+		}
+		if err != nil {
+			break
+		}
+		accounts, accountsErr := s.accountRepo.GetAccountsByUser(user.ID)
+		if accountsErr != nil {
+			return nil, apperrors.ErrDatabaseError
+		}
+		if account = receivingAccount(accounts, currency); account == nil {
+			return nil, apperrors.ErrReceiverNotFound
+		}
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrReceiverNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if account.Status != models.AccountOpen {
+		return nil, apperrors.ErrAccountClosed
+	}
+	return account, nil
+}
+
+// Resolve finds the account a query names, for money in currency, and what
+// the sender may see of it. Nothing about the owner but their masked name
+// is returned.
+func (s *PayeeService) Resolve(userID int64, query models.RecipientQuery, currency money.Currency) (*models.Recipient, error) {
+	account, err := s.recipientAccount(userID, query, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.userRepo.GetUserById(account.UserID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return &models.Recipient{
+		AccountID:     account.ID,
+		AccountNumber: maskAccountNumber(account.Number),
+		DisplayName:   maskName(owner.Name),
+		Currency:      account.Currency,
+	}, nil
+}
+
+func (s *PayeeService) ListPayees(userID int64) ([]models.Payee, error) {
+	payees, err := s.payeeRepo.GetPayeesByUser(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if payees == nil {
+		payees = []models.Payee{}
+	}
+	for i := range payees {
+		masked(&payees[i])
+	}
+	return payees, nil
+}
+
+// CreatePayee saves the recipient a query names under name. A recipient
+// given by username or email is saved as the account it resolves to now,
+// for money in currency.
+func (s *PayeeService) CreatePayee(userID int64, name string, query models.RecipientQuery, currency money.Currency) (*models.Payee, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxPayeeNameLength || query.PayeeID != nil {
+		return nil, apperrors.ErrInvalidPayee
+	}
+
+	account, err := s.recipientAccount(userID, query, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	payee := &models.Payee{UserID: userID, Name: name, AccountID: account.ID}
+	if err := s.payeeRepo.CreatePayee(payee); err != nil {
+		if err == repositories.ErrPayeeExists {
+			return nil, apperrors.ErrPayeeExists
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	saved, err := s.payeeRepo.GetPayee(payee.ID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return masked(saved), nil
+}
+
+func (s *PayeeService) DeletePayee(userID int64, payeeID int64) error {
+	if err := s.payeeRepo.DeletePayee(userID, payeeID); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrPayeeNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
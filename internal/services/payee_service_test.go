@@ -0,0 +1,37 @@
+package services
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "J*** D**", maskName("Jane Doe"))
+	assert.Equal(t, "Z** Ö*********", maskName("  Zoë   Österreich "))
+	assert.Equal(t, "A", maskName("A"))
+	assert.Equal(t, "", maskName(""))
+}
+
+func TestMaskAccountNumber(t *testing.T) {
+	assert.Equal(t, "********0042", maskAccountNumber("000000000042"))
+	assert.Equal(t, "0042", maskAccountNumber("0042"))
+}
+
+func TestReceivingAccount(t *testing.T) {
+	accounts := []models.Account{
+		{ID: 1, Type: models.Checking, Currency: money.USD, Status: models.AccountClosed},
+		{ID: 2, Type: models.Savings, Currency: money.USD, Status: models.AccountOpen},
+		{ID: 3, Type: models.Checking, Currency: money.EUR, Status: models.AccountOpen},
+		{ID: 4, Type: models.Checking, Currency: money.USD, Status: models.AccountOpen},
+		{ID: 5, Type: models.Checking, Currency: money.USD, Status: models.AccountOpen},
+	}
+
+	assert.Equal(t, int64(4), receivingAccount(accounts, money.USD).ID)
+	assert.Equal(t, int64(3), receivingAccount(accounts, money.EUR).ID)
+	// nothing in GBP, so the oldest open checking account
+	assert.Equal(t, int64(3), receivingAccount(accounts, money.GBP).ID)
+	assert.Nil(t, receivingAccount(accounts[:1], money.USD))
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SessionService issues access/refresh token pairs and revokes them.
+type SessionService struct {
+	userRepo  repositories.IUserRepository
+	tokenRepo repositories.ITokenRepository
+	database  *sqlx.DB
+}
+
+func NewSessionService(urepo repositories.IUserRepository, trepo repositories.ITokenRepository, db *sqlx.DB) *SessionService {
+	return &SessionService{userRepo: urepo, tokenRepo: trepo, database: db}
+}
+
+// issue creates an access token and the refresh token that can replace it,
+// both belonging to familyID.
+func (s *SessionService) issue(tx *sqlx.Tx, user *models.User, familyID string, now time.Time) (*models.TokenPair, error) {
+	jti, err := auth.NewTokenID()
+	if err != nil {
+		return nil, apperrors.ErrGenerateToken
+	}
+	accessToken, err := auth.GenerateJWT(user.ID, jti, string(user.Role))
+	if err != nil {
+		return nil, apperrors.ErrGenerateToken
+	}
+	refreshToken, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, apperrors.ErrGenerateToken
+	}
+
+	record := &models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       auth.HashToken(refreshToken),
+		AccessTokenID:   jti,
+		AccessExpiresAt: now.Add(auth.AccessTokenTTL),
+		ExpiresAt:       now.Add(auth.RefreshTokenTTL),
+	}
+	if err := s.tokenRepo.CreateRefreshToken(tx, record); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(auth.AccessTokenTTL / time.Second),
+	}, nil
+}
+
+// StartSession begins a new token family for an authenticated user.
+func (s *SessionService) StartSession(user *models.User) (*models.TokenPair, error) {
+	familyID, err := auth.NewTokenID()
+	if err != nil {
+		return nil, apperrors.ErrGenerateToken
+	}
+
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	pair, err := s.issue(tx, user, familyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new pair in the same family. A
+// refresh token can be used once; presenting it again means it was copied,
+// so the whole family is revoked, including live access tokens.
+func (s *SessionService) Refresh(refreshToken string) (*models.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	record, err := s.tokenRepo.GetRefreshTokenForUpdate(tx, auth.HashToken(refreshToken))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrInvalidRefreshToken
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if record.RevokedAt != nil || !now.Before(record.ExpiresAt) {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
+
+	if record.UsedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(tx, record.FamilyID, now); err != nil {
+			return nil, apperrors.ErrDatabaseError
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, apperrors.ErrDatabaseError
+		}
+		return nil, apperrors.ErrRefreshTokenReused
+	}
+
+	if err := s.tokenRepo.MarkRefreshTokenUsed(tx, record.ID, now); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	// Re-read the user so a role change applies from the next refresh.
+	user, err := s.userRepo.GetUserById(record.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrInvalidRefreshToken
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	pair, err := s.issue(tx, user, record.FamilyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return pair, nil
+}
+
+// Logout revokes the session the access token belongs to: the token itself
+// and its refresh token family.
+func (s *SessionService) Logout(userID int64, token *auth.Claims) error {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	if err := s.tokenRepo.RevokeAccessToken(tx, token.ID, userID, token.ExpiresAt); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	familyID, err := s.tokenRepo.GetFamilyByAccessToken(tx, token.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return apperrors.ErrDatabaseError
+	}
+	if err == nil {
+		if err := s.tokenRepo.RevokeFamily(tx, familyID, now); err != nil {
+			return apperrors.ErrDatabaseError
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// LogoutAll revokes every session the user has.
+func (s *SessionService) LogoutAll(userID int64, token *auth.Claims) error {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	if err := s.tokenRepo.RevokeAccessToken(tx, token.ID, userID, token.ExpiresAt); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	if err := s.tokenRepo.RevokeUserTokens(tx, userID, time.Now()); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// IsRevoked implements middleware.RevocationChecker.
+func (s *SessionService) IsRevoked(jti string) (bool, error) {
+	return s.tokenRepo.IsRevoked(jti)
+}
+
+// DeleteExpired is the scheduler job that prunes expired tokens.
+func (s *SessionService) DeleteExpired(ctx context.Context, now time.Time) error {
+	return s.tokenRepo.DeleteExpired(now)
+}
@@ -0,0 +1,486 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// standingOrderMaxAttempts is how often an occurrence is tried when the
+	// sender lacks funds before it is marked failed.
+	standingOrderMaxAttempts = 3
+	standingOrderRetryDelay  = time.Hour
+)
+
+type StandingOrderService struct {
+	orderRepo          repositories.IStandingOrderRepository
+	accountRepo        repositories.IAccountRepository
+	transactionService *TransactionService
+	auditService       *AuditService
+	database           *sqlx.DB
+}
+
+func NewStandingOrderService(orepo repositories.IStandingOrderRepository, arepo repositories.IAccountRepository, transactionService *TransactionService, auditService *AuditService, db *sqlx.DB) *StandingOrderService {
+	return &StandingOrderService{orderRepo: orepo, accountRepo: arepo, transactionService: transactionService, auditService: auditService, database: db}
+}
+
+// StandingOrderUpdate holds the fields an owner may change; nil means unchanged.
+type StandingOrderUpdate struct {
+	Amount         *money.Money
+	Description    *string
+	EndDate        *time.Time
+	MaxOccurrences *int
+	Status         *models.StandingOrderStatus
+}
+
+func today(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// addMonthsClamped moves t by months, clamping to the last day of the target
+// month so an order starting on the 31st still runs at the end of February.
+func addMonthsClamped(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, 0, 0, 0, 0, time.UTC)
+}
+
+// occurrenceDate returns when occurrence k of the order falls due, or false
+// once the schedule is exhausted.
+func occurrenceDate(order *models.StandingOrder, k int) (time.Time, bool) {
+	start := today(order.StartDate)
+
+	var at time.Time
+	switch order.Frequency {
+	case models.Once:
+		if k > 0 {
+			return time.Time{}, false
+		}
+		return start, true
+	case models.Daily:
+		at = start.AddDate(0, 0, k)
+	case models.Weekly:
+		at = start.AddDate(0, 0, 7*k)
+	case models.Monthly:
+		at = addMonthsClamped(start, k)
+	default:
+		return time.Time{}, false
+	}
+
+	if order.MaxOccurrences != nil && k >= *order.MaxOccurrences {
+		return time.Time{}, false
+	}
+	if order.EndDate != nil && at.After(today(*order.EndDate)) {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+func (s *StandingOrderService) CreateStandingOrder(userID int64, order *models.StandingOrder, now time.Time) error {
+	if !order.Frequency.Valid() {
+		return apperrors.ErrInvalidFrequency
+	}
+	if !order.Amount.IsPositive() {
+		return apperrors.ErrInvalidAmount
+	}
+	if order.StartDate.Before(today(now)) {
+		return apperrors.ErrInvalidStartDate
+	}
+	if order.Frequency == models.Once {
+		order.EndDate, order.MaxOccurrences = nil, nil
+	}
+	if order.EndDate != nil && order.EndDate.Before(order.StartDate) {
+		return apperrors.ErrInvalidEndDate
+	}
+	if order.MaxOccurrences != nil && *order.MaxOccurrences < 1 {
+		return apperrors.ErrInvalidMaxOccurrences
+	}
+	if order.FromAccountID == order.ToAccountID {
+		return apperrors.ErrSelfTransfer
+	}
+
+	from, err := s.accountRepo.GetAccountByID(order.FromAccountID)
+	if err != nil || from.UserID != userID {
+		if err != nil && err != sql.ErrNoRows {
+			return apperrors.ErrDatabaseError
+		}
+		return apperrors.ErrAccountNotFound
+	}
+	to, err := s.accountRepo.GetAccountByID(order.ToAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrReceiverNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if from.Status != models.AccountOpen || to.Status != models.AccountOpen {
+		return apperrors.ErrAccountClosed
+	}
+	// The receiving account may hold another currency; each run converts at
+	// the rate current at the time.
+	if order.Amount.Currency != from.Currency {
+		return apperrors.ErrCurrencyMismatch
+	}
+
+	order.UserID = userID
+	order.Currency = from.Currency
+	order.Status = models.StandingOrderActive
+	order.Occurrence = 0
+	next, _ := occurrenceDate(order, 0)
+	order.NextRunAt = &next
+
+	if err := s.orderRepo.CreateStandingOrder(order); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *StandingOrderService) ListStandingOrders(userID int64) ([]models.StandingOrder, error) {
+	orders, err := s.orderRepo.GetStandingOrdersByUser(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if orders == nil {
+		orders = []models.StandingOrder{}
+	}
+	return orders, nil
+}
+
+// GetStandingOrder returns an order owned by the user along with every
+// execution attempt recorded for it.
+func (s *StandingOrderService) GetStandingOrder(userID int64, orderID int64) (*models.StandingOrder, []models.StandingOrderExecution, error) {
+	order, err := s.orderRepo.GetStandingOrder(orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, apperrors.ErrStandingOrderNotFound
+		}
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	if order.UserID != userID {
+		return nil, nil, apperrors.ErrStandingOrderNotFound
+	}
+
+	executions, err := s.orderRepo.GetExecutions(orderID)
+	if err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	if executions == nil {
+		executions = []models.StandingOrderExecution{}
+	}
+	return order, executions, nil
+}
+
+// lockOwnOrder locks an order the user owns that has not reached a final state.
+func (s *StandingOrderService) lockOwnOrder(tx *sqlx.Tx, userID int64, orderID int64) (*models.StandingOrder, error) {
+	order, err := s.orderRepo.GetStandingOrderForUpdate(tx, orderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrStandingOrderNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if order.UserID != userID {
+		return nil, apperrors.ErrStandingOrderNotFound
+	}
+	if order.Status != models.StandingOrderActive && order.Status != models.StandingOrderPaused {
+		return nil, apperrors.ErrStandingOrderFinished
+	}
+	return order, nil
+}
+
+// UpdateStandingOrder changes the amount, description, end of schedule or
+// pauses/resumes an order. Resuming skips occurrences missed while paused.
+func (s *StandingOrderService) UpdateStandingOrder(userID int64, orderID int64, update StandingOrderUpdate, now time.Time) (*models.StandingOrder, error) {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	order, err := s.lockOwnOrder(tx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Amount != nil {
+		amount := *update.Amount
+		if !amount.IsPositive() {
+			return nil, apperrors.ErrInvalidAmount
+		}
+		if amount.Currency != order.Currency {
+			return nil, apperrors.ErrCurrencyMismatch
+		}
+		order.Amount = amount
+	}
+	if update.Description != nil {
+		order.Description = *update.Description
+	}
+	if order.Frequency != models.Once {
+		if update.EndDate != nil {
+			if update.EndDate.Before(order.StartDate) {
+				return nil, apperrors.ErrInvalidEndDate
+			}
+			order.EndDate = update.EndDate
+		}
+		if update.MaxOccurrences != nil {
+			if *update.MaxOccurrences < 1 {
+				return nil, apperrors.ErrInvalidMaxOccurrences
+			}
+			order.MaxOccurrences = update.MaxOccurrences
+		}
+	}
+
+	if update.Status != nil {
+		switch *update.Status {
+		case models.StandingOrderPaused:
+			order.Status = models.StandingOrderPaused
+		case models.StandingOrderActive:
+			if order.Status == models.StandingOrderPaused {
+				order.Status = models.StandingOrderActive
+				order.RetryCount = 0
+				for {
+					next, ok := occurrenceDate(order, order.Occurrence)
+					if !ok || !next.Before(today(now)) {
+						break
+					}
+					order.Occurrence++
+				}
+			}
+		default:
+			return nil, apperrors.ErrInvalidStandingOrderStatus
+		}
+	}
+
+	s.reschedule(order)
+
+	if err := s.orderRepo.UpdateStandingOrder(tx, order); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return order, nil
+}
+
+func (s *StandingOrderService) CancelStandingOrder(userID int64, orderID int64) error {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	order, err := s.lockOwnOrder(tx, userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	order.Status = models.StandingOrderCancelled
+	order.NextRunAt = nil
+
+	if err := s.orderRepo.UpdateStandingOrder(tx, order); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// reschedule points next_run_at at the current occurrence, completing the
+// order once the schedule is exhausted. Pending retries keep their time.
+func (s *StandingOrderService) reschedule(order *models.StandingOrder) {
+	if order.RetryCount > 0 && order.Status == models.StandingOrderActive {
+		return
+	}
+
+	next, ok := occurrenceDate(order, order.Occurrence)
+	if !ok {
+		order.Status = models.StandingOrderCompleted
+		order.NextRunAt = nil
+		return
+	}
+	order.NextRunAt = &next
+}
+
+// ExecuteDue runs every occurrence that is due at now. It is the body of the
+// standing-orders background job.
+func (s *StandingOrderService) ExecuteDue(ctx context.Context, now time.Time) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		found, err := s.executeNext(ctx, now)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+	}
+}
+
+// executeNext locks one due order and runs its current occurrence through
+// TransferMoney. The order row stays locked until the outcome is recorded, so
+// other workers skip it. The transfer carries an idempotency key derived from
+// the order and occurrence, so if the process dies after the transfer commits
+// but before the outcome is saved, the retry replays the stored transfer
+// instead of paying twice.
+func (s *StandingOrderService) executeNext(ctx context.Context, now time.Time) (bool, error) {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	order, err := s.orderRepo.LockNextDue(tx, now)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	execution := &models.StandingOrderExecution{
+		StandingOrderID: order.ID,
+		Occurrence:      order.Occurrence,
+		Attempt:         order.RetryCount + 1,
+		ScheduledFor:    *order.NextRunAt,
+	}
+
+	key := standingOrderKey(order)
+	entry := standingOrderAudit(order, execution, key)
+	transaction, err := s.transactionService.TransferMoney(WithAudit(WithIdempotencyKey(ctx, key), entry), order.UserID, order.FromAccountID, order.ToAccountID, order.Amount, "")
+	transferErr := err
+	if replay, ok := err.(*IdempotentReplay); ok {
+		transaction = &models.TransactionInfo{}
+		if jsonErr := json.Unmarshal(replay.Body, transaction); jsonErr != nil {
+			return false, jsonErr
+		}
+		err = nil
+	}
+
+	switch {
+	case err == nil:
+		execution.Status = models.ExecutionSucceeded
+		execution.TransactionID = &transaction.ID
+		order.Occurrence++
+		order.RetryCount = 0
+		s.reschedule(order)
+
+	case retryable(err) && execution.Attempt < standingOrderMaxAttempts:
+		execution.Status = models.ExecutionRetrying
+		execution.Error = errorMessage(err)
+		order.RetryCount++
+		retryAt := now.Add(standingOrderRetryDelay)
+		order.NextRunAt = &retryAt
+
+	case retryable(err):
+		execution.Status = models.ExecutionFailed
+		execution.Error = errorMessage(err)
+		order.Occurrence++
+		order.RetryCount = 0
+		s.reschedule(order)
+		if order.Frequency == models.Once {
+			order.Status = models.StandingOrderFailed
+		}
+
+	default:
+		if _, ok := err.(*apperrors.AppError); !ok || err == apperrors.ErrDatabaseError {
+			// Infrastructure trouble: leave the order untouched for the next run.
+			return false, err
+		}
+		// Closed or missing accounts and similar problems will not fix themselves.
+		execution.Status = models.ExecutionFailed
+		execution.Error = errorMessage(err)
+		order.Status = models.StandingOrderFailed
+		order.NextRunAt = nil
+	}
+
+	if err := s.orderRepo.RecordExecution(tx, execution); err != nil {
+		return false, err
+	}
+	if err := s.orderRepo.UpdateStandingOrder(tx, order); err != nil {
+		return false, err
+	}
+	// A transfer that went through queued its own entry; a refused one is
+	// queued with the execution that records it.
+	if _, ok := transferErr.(*IdempotentReplay); !ok && transferErr != nil {
+		entry.Outcome = models.AuditFailure
+		entry.Error = transferErr.Error()
+		if err := s.auditService.Queue(tx, entry); err != nil {
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	if err := s.auditService.AppendQueued(ctx); err != nil {
+		log.Printf("audit: failed to append standing order %d occurrence %d, left for the audit-queue job: %v", order.ID, execution.Occurrence, err)
+	}
+	return true, nil
+}
+
+// standingOrderAudit starts the audit entry of a standing order transfer
+// attempt. The scheduler has no client, so the request ID is the
+// occurrence's idempotency key.
+func standingOrderAudit(order *models.StandingOrder, execution *models.StandingOrderExecution, key IdempotencyKey) *models.AuditEntry {
+	details, _ := json.Marshal(map[string]interface{}{
+		"standing_order_id": order.ID,
+		"occurrence":        execution.Occurrence,
+		"attempt":           execution.Attempt,
+		"from_account_id":   order.FromAccountID,
+		"to_account_id":     order.ToAccountID,
+	})
+	amount := order.Amount
+	return &models.AuditEntry{
+		Action:     models.AuditTransfer,
+		ActorID:    &order.UserID,
+		TargetType: models.AuditTargetTransaction,
+		Amount:     &amount,
+		Details:    details,
+		RequestID:  key.Key,
+	}
+}
+
+// retryable reports whether a failed transfer may succeed later without
+// anyone changing the order: the sender may receive money, a limit may reset
+// or a fresh exchange rate may be loaded.
+func retryable(err error) bool {
+	if _, ok := err.(*LimitExceeded); ok {
+		return true
+	}
+	return err == apperrors.ErrInsufficientFunds || err == apperrors.ErrFXRateUnavailable
+}
+
+func standingOrderKey(order *models.StandingOrder) IdempotencyKey {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%d|%s",
+		order.FromAccountID, order.ToAccountID, order.Amount.Amount, order.Currency)))
+	return IdempotencyKey{
+		Key:         fmt.Sprintf("standing-order:%d:%d", order.ID, order.Occurrence),
+		RequestHash: hex.EncodeToString(sum[:]),
+	}
+}
+
+func errorMessage(err error) *string {
+	message := err.Error()
+	return &message
+}
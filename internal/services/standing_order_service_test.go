@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"MockBankGo/internal/models"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrenceDate_MonthlyClampsToMonthEnd(t *testing.T) {
+	order := &models.StandingOrder{Frequency: models.Monthly, StartDate: date(2025, time.January, 31)}
+
+	first, _ := occurrenceDate(order, 0)
+	second, _ := occurrenceDate(order, 1)
+	third, _ := occurrenceDate(order, 2)
+
+	assert.Equal(t, date(2025, time.January, 31), first)
+	assert.Equal(t, date(2025, time.February, 28), second)
+	assert.Equal(t, date(2025, time.March, 31), third)
+}
+
+func TestOccurrenceDate_OnceRunsOnlyOnce(t *testing.T) {
+	order := &models.StandingOrder{Frequency: models.Once, StartDate: date(2025, time.July, 1)}
+
+	first, ok := occurrenceDate(order, 0)
+	assert.True(t, ok)
+	assert.Equal(t, date(2025, time.July, 1), first)
+
+	_, ok = occurrenceDate(order, 1)
+	assert.False(t, ok)
+}
+
+func TestOccurrenceDate_StopsAtEndDateAndCount(t *testing.T) {
+	end := date(2025, time.July, 15)
+	weekly := &models.StandingOrder{Frequency: models.Weekly, StartDate: date(2025, time.July, 1), EndDate: &end}
+
+	_, ok := occurrenceDate(weekly, 2) // July 15
+	assert.True(t, ok)
+	_, ok = occurrenceDate(weekly, 3) // July 22
+	assert.False(t, ok)
+
+	count := 2
+	daily := &models.StandingOrder{Frequency: models.Daily, StartDate: date(2025, time.July, 1), MaxOccurrences: &count}
+
+	_, ok = occurrenceDate(daily, 1)
+	assert.True(t, ok)
+	_, ok = occurrenceDate(daily, 2)
+	assert.False(t, ok)
+}
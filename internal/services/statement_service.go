@@ -0,0 +1,253 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxStatementPeriod is the longest period one statement may cover.
+	maxStatementPeriod = 366 * 24 * time.Hour
+
+	// statementSettleTime is how long after a month ends its statements are
+	// taken. Ledger entries carry the time their database transaction
+	// started, so one that began just before midnight can still commit
+	// after it.
+	statementSettleTime = time.Hour
+)
+
+// monthStart returns midnight UTC on the first of t's month.
+func monthStart(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// lastStatementPeriod returns the latest calendar month whose statements
+// can be taken at now.
+func lastStatementPeriod(now time.Time) (time.Time, time.Time) {
+	to := monthStart(now.Add(-statementSettleTime))
+	return to.AddDate(0, -1, 0), to
+}
+
+// buildStatement works out an account's statement from the balance it had
+// at from and its movements up to to, each in minor units: the balance
+// after every movement, the totals in and out and the closing balance.
+func buildStatement(account *models.Account, from time.Time, to time.Time, opening money.Money, lines []models.StatementLine) *models.Statement {
+	currency := account.Currency
+	statement := &models.Statement{
+		StatementSummary: models.StatementSummary{
+			AccountID:      account.ID,
+			AccountNumber:  account.Number,
+			IBAN:           account.IBAN,
+			Currency:       currency,
+			From:           from,
+			To:             to,
+			OpeningBalance: money.New(opening.Amount, currency),
+			TotalIn:        money.New(0, currency),
+			TotalOut:       money.New(0, currency),
+		},
+		Lines: lines,
+	}
+	if statement.Lines == nil {
+		statement.Lines = []models.StatementLine{}
+	}
+
+	balance := statement.OpeningBalance
+	for i := range statement.Lines {
+		line := &statement.Lines[i]
+		line.Amount.Currency = currency
+		if line.Amount.IsNegative() {
+			statement.TotalOut = statement.TotalOut.Sub(line.Amount)
+		} else {
+			statement.TotalIn = statement.TotalIn.Add(line.Amount)
+		}
+		balance = balance.Add(line.Amount)
+		line.Balance = balance
+	}
+	statement.ClosingBalance = balance
+	return statement
+}
+
+type StatementService struct {
+	statementRepo repositories.IStatementRepository
+	accountRepo   repositories.IAccountRepository
+	database      *sqlx.DB
+}
+
+func NewStatementService(srepo repositories.IStatementRepository, arepo repositories.IAccountRepository, db *sqlx.DB) *StatementService {
+	return &StatementService{statementRepo: srepo, accountRepo: arepo, database: db}
+}
+
+// ownAccount returns the user's account, or ErrAccountNotFound when it is
+// someone else's.
+func (s *StatementService) ownAccount(userID int64, accountID int64) (*models.Account, error) {
+	account, err := s.accountRepo.GetAccountByID(accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrAccountNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if account.UserID != userID {
+		return nil, apperrors.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// generate works out an account's statement from its ledger. Run in a
+// repeatable read transaction, the opening balance and the movements come
+// from the same snapshot, so they agree however many transactions commit
+// meanwhile.
+func (s *StatementService) generate(tx *sqlx.Tx, account *models.Account, from time.Time, to time.Time) (*models.Statement, error) {
+	opening, err := s.statementRepo.GetBalanceBefore(tx, account.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.statementRepo.GetMovements(tx, account.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return buildStatement(account, from, to, opening, lines), nil
+}
+
+// Statement returns the statement of one of the user's accounts from from
+// up to to, which is cut off at now. A period the monthly statement job has
+// already covered comes from its stored statement, so it reads the same
+// every time; any other is worked out from the ledger.
+func (s *StatementService) Statement(ctx context.Context, userID int64, accountID int64, from time.Time, to time.Time, now time.Time) (*models.Statement, error) {
+	if to.After(now) {
+		to = now
+	}
+	if !from.Before(to) || to.Sub(from) > maxStatementPeriod {
+		return nil, apperrors.ErrInvalidStatementPeriod
+	}
+
+	account, err := s.ownAccount(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.statementRepo.GetStatementForPeriod(accountID, from, to)
+	if err == nil {
+		return stored, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	tx, err := s.database.BeginTxx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	statement, err := s.generate(tx, account, from, to)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	statement.GeneratedAt = now
+	return statement, nil
+}
+
+// ListStatements returns the stored statements of one of the user's
+// accounts, newest first, without their lines.
+func (s *StatementService) ListStatements(userID int64, accountID int64) ([]models.StatementSummary, error) {
+	if _, err := s.ownAccount(userID, accountID); err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.statementRepo.GetStatementsByAccount(accountID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if summaries == nil {
+		summaries = []models.StatementSummary{}
+	}
+	return summaries, nil
+}
+
+// GetStatement returns one of the stored statements of the user's accounts.
+func (s *StatementService) GetStatement(userID int64, statementID int64) (*models.Statement, error) {
+	statement, err := s.statementRepo.GetStatement(statementID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrStatementNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if _, err := s.ownAccount(userID, statement.AccountID); err != nil {
+		if err == apperrors.ErrAccountNotFound {
+			return nil, apperrors.ErrStatementNotFound
+		}
+		return nil, err
+	}
+	return statement, nil
+}
+
+// TakeMonthlyStatements stores last month's statement for every account
+// open during it that does not have one yet. Stored statements are never
+// changed, so a month's statement reads the same however late a
+// transaction touching it is reversed or refunded.
+func (s *StatementService) TakeMonthlyStatements(ctx context.Context, now time.Time) error {
+	from, to := lastStatementPeriod(now)
+	accountIDs, err := s.statementRepo.GetAccountsWithoutStatement(from, to)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, accountID := range accountIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.takeStatement(ctx, accountID, from, to); err != nil {
+			log.Printf("monthly statements: account %d: %v", accountID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d monthly statements failed", failed, len(accountIDs))
+	}
+	return nil
+}
+
+// takeStatement stores an account's statement from from up to to, unless
+// another run already has.
+func (s *StatementService) takeStatement(ctx context.Context, accountID int64, from time.Time, to time.Time) error {
+	account, err := s.accountRepo.GetAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.database.BeginTxx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statement, err := s.generate(tx, account, from, to)
+	if err != nil {
+		return err
+	}
+	if err := s.statementRepo.CreateStatement(tx, statement); err != nil {
+		if err == repositories.ErrStatementExists {
+			return nil
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildStatement(t *testing.T) {
+	account := &models.Account{ID: 7, Number: "000001000007", Currency: money.EUR}
+	lines := []models.StatementLine{
+		{JournalID: 1, Amount: money.Money{Amount: 5000}},
+		{JournalID: 2, Amount: money.Money{Amount: -3000}},
+		{JournalID: 3, Amount: money.Money{Amount: -500}},
+	}
+
+	statement := buildStatement(account, date(2025, time.June, 1), date(2025, time.July, 1), money.Money{Amount: 10000}, lines)
+
+	assert.Equal(t, money.New(10000, money.EUR), statement.OpeningBalance)
+	assert.Equal(t, money.New(5000, money.EUR), statement.TotalIn)
+	assert.Equal(t, money.New(3500, money.EUR), statement.TotalOut)
+	assert.Equal(t, money.New(11500, money.EUR), statement.ClosingBalance)
+	assert.Equal(t, money.New(-3000, money.EUR), statement.Lines[1].Amount)
+	assert.Equal(t, money.New(15000, money.EUR), statement.Lines[0].Balance)
+	assert.Equal(t, money.New(12000, money.EUR), statement.Lines[1].Balance)
+	assert.Equal(t, money.New(11500, money.EUR), statement.Lines[2].Balance)
+	assert.Equal(t, "000001000007", statement.AccountNumber)
+}
+
+func TestBuildStatement_NoMovements(t *testing.T) {
+	account := &models.Account{ID: 7, Currency: money.USD}
+
+	statement := buildStatement(account, date(2025, time.June, 1), date(2025, time.July, 1), money.Money{Amount: -2500}, nil)
+
+	assert.Equal(t, []models.StatementLine{}, statement.Lines)
+	assert.True(t, statement.TotalIn.IsZero())
+	assert.True(t, statement.TotalOut.IsZero())
+	assert.Equal(t, money.New(-2500, money.USD), statement.ClosingBalance)
+}
+
+func TestLastStatementPeriod(t *testing.T) {
+	// June's statements wait until an hour into July
+	from, to := lastStatementPeriod(time.Date(2025, time.July, 1, 0, 30, 0, 0, time.UTC))
+	assert.Equal(t, date(2025, time.May, 1), from)
+	assert.Equal(t, date(2025, time.June, 1), to)
+
+	from, to = lastStatementPeriod(time.Date(2025, time.July, 1, 1, 0, 0, 0, time.UTC))
+	assert.Equal(t, date(2025, time.June, 1), from)
+	assert.Equal(t, date(2025, time.July, 1), to)
+
+	from, to = lastStatementPeriod(time.Date(2026, time.January, 20, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, date(2025, time.December, 1), from)
+	assert.Equal(t, date(2026, time.January, 1), to)
+}
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	maxTermDepositMonths = 120
+
+	// termDepositYearDays is the day count term deposit interest is
+	// worked out on: actual days held over a 365-day year.
+	termDepositYearDays = 365
+)
+
+// termDepositInterest is simple interest at rateBps a year on principal
+// for the days from startsOn to maturesOn, rounded down to the minor unit.
+func termDepositInterest(principal money.Money, rateBps int, startsOn time.Time, maturesOn time.Time) money.Money {
+	days := int64(maturesOn.Sub(startsOn).Hours() / 24)
+	if days <= 0 {
+		return money.New(0, principal.Currency)
+	}
+
+	interest := new(big.Rat).SetInt64(principal.Amount)
+	interest.Mul(interest, big.NewRat(int64(rateBps)*days, bpsPerUnit*termDepositYearDays))
+	return money.New(floorMinor(interest), principal.Currency)
+}
+
+// earlyBreakPenalty is what breaking a deposit early costs: penaltyBps of
+// its principal, rounded up, but never more than the principal.
+func earlyBreakPenalty(principal money.Money, penaltyBps int) money.Money {
+	return money.New(min(percentOf(principal.Amount, penaltyBps), principal.Amount), principal.Currency)
+}
+
+type TermDepositService struct {
+	termDepositRepo    repositories.ITermDepositRepository
+	accountRepo        repositories.IAccountRepository
+	transactionService *TransactionService
+	auditService       *AuditService
+	database           *sqlx.DB
+}
+
+func NewTermDepositService(drepo repositories.ITermDepositRepository, arepo repositories.IAccountRepository, transactionService *TransactionService, auditService *AuditService, db *sqlx.DB) *TermDepositService {
+	return &TermDepositService{termDepositRepo: drepo, accountRepo: arepo, transactionService: transactionService, auditService: auditService, database: db}
+}
+
+func validateTermDepositProduct(product *models.TermDepositProduct) error {
+	if product.Name == "" || !product.Currency.Valid() {
+		return apperrors.ErrInvalidTermDepositProduct
+	}
+	if product.TermMonths < 1 || product.TermMonths > maxTermDepositMonths {
+		return apperrors.ErrInvalidTermDepositProduct
+	}
+	if product.RateBps < 0 || product.RateBps > bpsPerUnit || product.PenaltyBps < 0 || product.PenaltyBps > bpsPerUnit {
+		return apperrors.ErrInvalidTermDepositProduct
+	}
+	if product.MinAmount.IsZero() {
+		product.MinAmount.Currency = product.Currency
+	}
+	if product.MinAmount.IsNegative() || product.MinAmount.Currency != product.Currency {
+		return apperrors.ErrInvalidTermDepositProduct
+	}
+	return nil
+}
+
+// ListProducts returns the term deposit products, or only those taking new
+// deposits when activeOnly is set.
+func (s *TermDepositService) ListProducts(activeOnly bool) ([]models.TermDepositProduct, error) {
+	products, err := s.termDepositRepo.GetProducts(activeOnly)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if products == nil {
+		products = []models.TermDepositProduct{}
+	}
+	return products, nil
+}
+
+func (s *TermDepositService) CreateProduct(product *models.TermDepositProduct) error {
+	if err := validateTermDepositProduct(product); err != nil {
+		return err
+	}
+	if err := s.termDepositRepo.CreateProduct(product); err != nil {
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// UpdateProduct changes a product's name, terms and whether it takes new
+// deposits. Deposits already open keep the terms they opened on; new terms
+// apply to them only when they roll over. Its currency cannot change; left
+// empty it keeps its value.
+func (s *TermDepositService) UpdateProduct(product *models.TermDepositProduct) error {
+	existing, err := s.termDepositRepo.GetProduct(product.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrTermDepositProductNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	if product.Currency == "" {
+		product.Currency = existing.Currency
+	}
+	if product.Currency != existing.Currency {
+		return apperrors.ErrTermDepositProductMismatch
+	}
+	if err := validateTermDepositProduct(product); err != nil {
+		return err
+	}
+
+	if err := s.termDepositRepo.UpdateProduct(product); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrTermDepositProductNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
+
+// Open locks amount away from one of the user's accounts on the terms of an
+// active product, until the product's term has passed. The amount must be
+// available without the account's overdraft.
+func (s *TermDepositService) Open(ctx context.Context, userID int64, productID int64, accountID int64, amount money.Money, rollover bool, now time.Time) (_ *models.TermDeposit, err error) {
+	// A deposit refused for lack of funds is recorded like a withdrawal.
+	// Deferred before the rollback below, so it runs after it.
+	var attempt *models.TransactionInfo
+	defer func() {
+		if attempt != nil {
+			s.transactionService.recordFailure(*attempt, err)
+		}
+	}()
+
+	if err := s.transactionService.validateAmount(amount); err != nil {
+		return nil, err
+	}
+
+	product, err := s.termDepositRepo.GetProduct(productID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrTermDepositProductNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if !product.Active {
+		return nil, apperrors.ErrTermDepositProductInactive
+	}
+	if amount.Currency != product.Currency {
+		return nil, apperrors.ErrCurrencyMismatch
+	}
+	if amount.Cmp(product.MinAmount) < 0 {
+		return nil, apperrors.ErrTermDepositBelowMinimum
+	}
+
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	account, err := s.transactionService.lockOwnAccount(tx, userID, accountID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	startsOn := today(now)
+	deposit := &models.TermDeposit{
+		UserID:     userID,
+		AccountID:  account.ID,
+		ProductID:  product.ID,
+		Currency:   product.Currency,
+		Principal:  amount,
+		RateBps:    product.RateBps,
+		PenaltyBps: product.PenaltyBps,
+		TermMonths: product.TermMonths,
+		StartsOn:   startsOn,
+		MaturesOn:  addMonthsClamped(startsOn, product.TermMonths),
+		Rollover:   rollover,
+		Status:     models.TermDepositActive,
+	}
+	if err := s.termDepositRepo.CreateTermDeposit(tx, deposit); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	attempt = &models.TransactionInfo{
+		SenderID:      userID,
+		ReceiverID:    userID,
+		FromAccountID: &account.ID,
+		Amount:        amount,
+		Type:          models.TermDepositOpen,
+	}
+	transaction, err := s.transactionService.openTermDeposit(tx, account, deposit,
+		fmt.Sprintf("term deposit %d opened from account %s", deposit.ID, account.Number))
+	if err != nil {
+		return nil, err
+	}
+
+	deposit.OpenTransactionID = &transaction.ID
+	if err := s.termDepositRepo.UpdateTermDeposit(tx, deposit); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.TargetID = &deposit.ID
+		entry.Details = withAuditDetail(entry.Details, "transaction_id", transaction.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return deposit, nil
+}
+
+func (s *TermDepositService) List(userID int64) ([]models.TermDeposit, error) {
+	deposits, err := s.termDepositRepo.GetTermDepositsByUser(userID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	if deposits == nil {
+		deposits = []models.TermDeposit{}
+	}
+	return deposits, nil
+}
+
+func (s *TermDepositService) Get(userID int64, depositID int64) (*models.TermDeposit, error) {
+	deposit, err := s.termDepositRepo.GetTermDeposit(depositID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrTermDepositNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if deposit.UserID != userID {
+		return nil, apperrors.ErrTermDepositNotFound
+	}
+	return deposit, nil
+}
+
+// lockActiveDeposit locks one of the user's deposits that is still running.
+func (s *TermDepositService) lockActiveDeposit(tx *sqlx.Tx, userID int64, depositID int64) (*models.TermDeposit, error) {
+	deposit, err := s.termDepositRepo.GetTermDepositForUpdate(tx, depositID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperrors.ErrTermDepositNotFound
+		}
+		return nil, apperrors.ErrDatabaseError
+	}
+	if deposit.UserID != userID {
+		return nil, apperrors.ErrTermDepositNotFound
+	}
+	if deposit.Status != models.TermDepositActive {
+		return nil, apperrors.ErrTermDepositNotActive
+	}
+	return deposit, nil
+}
+
+// SetRollover changes whether a running deposit is rolled over into a new
+// one when it matures instead of being paid out.
+func (s *TermDepositService) SetRollover(userID int64, depositID int64, rollover bool) (*models.TermDeposit, error) {
+	tx, err := s.database.Beginx()
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.lockActiveDeposit(tx, userID, depositID)
+	if err != nil {
+		return nil, err
+	}
+
+	deposit.Rollover = rollover
+	if err := s.termDepositRepo.UpdateTermDeposit(tx, deposit); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	return deposit, nil
+}
+
+// Break ends a deposit before it matures. Its principal is paid back into
+// the account it came from less the early-break penalty, and it earns no
+// interest. From its maturity date on a deposit can no longer be broken;
+// the maturity job pays it out with its interest.
+func (s *TermDepositService) Break(ctx context.Context, userID int64, depositID int64, now time.Time) (*models.TermDeposit, *models.TransactionInfo, error) {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.lockActiveDeposit(tx, userID, depositID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !deposit.MaturesOn.After(today(now)) {
+		return nil, nil, apperrors.ErrTermDepositMatured
+	}
+	account, err := s.transactionService.lockAccount(tx, deposit.AccountID, deposit.Currency, apperrors.ErrAccountNotFound)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	interest := money.New(0, deposit.Currency)
+	penalty := earlyBreakPenalty(deposit.Principal, deposit.PenaltyBps)
+	transaction, err := s.transactionService.payOutTermDeposit(tx, account, deposit, interest, penalty,
+		fmt.Sprintf("term deposit %d broken early into account %s", deposit.ID, account.Number))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deposit.Status = models.TermDepositBroken
+	deposit.Interest = &interest
+	deposit.Penalty = &penalty
+	deposit.CloseTransactionID = &transaction.ID
+	deposit.ClosedAt = &now
+	if err := s.termDepositRepo.UpdateTermDeposit(tx, deposit); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	err = s.auditService.queueAudit(ctx, tx, func(entry *models.AuditEntry) {
+		entry.Amount = &deposit.Principal
+		entry.Details = withAuditDetail(entry.Details, "penalty", penalty)
+		entry.Details = withAuditDetail(entry.Details, "transaction_id", transaction.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, apperrors.ErrDatabaseError
+	}
+	return deposit, transaction, nil
+}
+
+// MatureTermDeposits settles every deposit that has matured by now. It is
+// the body of the term-deposit-maturity background job. One deposit failing
+// does not stop the others.
+func (s *TermDepositService) MatureTermDeposits(ctx context.Context, now time.Time) error {
+	depositIDs, err := s.termDepositRepo.GetMaturedTermDeposits(today(now))
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, depositID := range depositIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.mature(ctx, depositID, now); err != nil {
+			log.Printf("term deposit maturity: deposit %d: %v", depositID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d term deposits failed to mature", failed, len(depositIDs))
+	}
+	return nil
+}
+
+// mature settles a matured deposit with the interest it earned. A deposit
+// set to roll over is replaced by a new one for principal and interest on
+// its product's current terms, as long as the product still takes new
+// deposits; any other deposit is paid out into its account.
+func (s *TermDepositService) mature(ctx context.Context, depositID int64, now time.Time) error {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	deposit, err := s.termDepositRepo.GetTermDepositForUpdate(tx, depositID)
+	if err != nil {
+		return err
+	}
+	if deposit.Status != models.TermDepositActive || deposit.MaturesOn.After(today(now)) {
+		return nil
+	}
+	interest := termDepositInterest(deposit.Principal, deposit.RateBps, deposit.StartsOn, deposit.MaturesOn)
+
+	var product *models.TermDepositProduct
+	if deposit.Rollover {
+		product, err = s.termDepositRepo.GetProduct(deposit.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+
+	var transaction *models.TransactionInfo
+	if product != nil && product.Active {
+		renewed := &models.TermDeposit{
+			UserID:       deposit.UserID,
+			AccountID:    deposit.AccountID,
+			ProductID:    product.ID,
+			Currency:     deposit.Currency,
+			Principal:    deposit.Principal.Add(interest),
+			RateBps:      product.RateBps,
+			PenaltyBps:   product.PenaltyBps,
+			TermMonths:   product.TermMonths,
+			StartsOn:     deposit.MaturesOn,
+			MaturesOn:    addMonthsClamped(deposit.MaturesOn, product.TermMonths),
+			Rollover:     true,
+			Status:       models.TermDepositActive,
+			RolledFromID: &deposit.ID,
+		}
+		if err := s.termDepositRepo.CreateTermDeposit(tx, renewed); err != nil {
+			return err
+		}
+		transaction, err = s.transactionService.rollOverTermDeposit(tx, deposit, renewed, interest,
+			fmt.Sprintf("term deposit %d rolled over into term deposit %d", deposit.ID, renewed.ID))
+		if err != nil {
+			return err
+		}
+		renewed.OpenTransactionID = &transaction.ID
+		if err := s.termDepositRepo.UpdateTermDeposit(tx, renewed); err != nil {
+			return err
+		}
+		deposit.Status = models.TermDepositRolledOver
+	} else {
+		account, err := s.transactionService.lockAccount(tx, deposit.AccountID, deposit.Currency, apperrors.ErrAccountNotFound)
+		if err != nil {
+			return err
+		}
+		transaction, err = s.transactionService.payOutTermDeposit(tx, account, deposit, interest, money.New(0, deposit.Currency),
+			fmt.Sprintf("term deposit %d matured into account %s", deposit.ID, account.Number))
+		if err != nil {
+			return err
+		}
+		deposit.Status = models.TermDepositMatured
+	}
+
+	deposit.Interest = &interest
+	deposit.CloseTransactionID = &transaction.ID
+	deposit.ClosedAt = &now
+	if err := s.termDepositRepo.UpdateTermDeposit(tx, deposit); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTermDepositInterest(t *testing.T) {
+	principal := money.New(100000, money.USD)
+
+	// 1,000.00 at 3.5% for a 365-day year is 35.00
+	assert.Equal(t, money.New(3500, money.USD),
+		termDepositInterest(principal, 350, date(2025, time.January, 15), date(2026, time.January, 15)))
+	// a leap year earns its extra day: 35.0958 rounds down
+	assert.Equal(t, money.New(3509, money.USD),
+		termDepositInterest(principal, 350, date(2024, time.January, 15), date(2025, time.January, 15)))
+	// 31 days at 5% is 4.2466
+	assert.Equal(t, money.New(424, money.USD),
+		termDepositInterest(principal, 500, date(2025, time.January, 15), date(2025, time.February, 15)))
+	assert.True(t, termDepositInterest(principal, 500, date(2025, time.January, 15), date(2025, time.January, 15)).IsZero())
+}
+
+func TestEarlyBreakPenalty(t *testing.T) {
+	assert.Equal(t, money.New(1000, money.USD), earlyBreakPenalty(money.New(100000, money.USD), 100))
+	// 0.075 rounds up to the next cent
+	assert.Equal(t, money.New(1, money.USD), earlyBreakPenalty(money.New(5, money.USD), 150))
+	assert.Equal(t, money.New(5, money.USD), earlyBreakPenalty(money.New(5, money.USD), 10000))
+	assert.True(t, earlyBreakPenalty(money.New(100000, money.USD), 0).IsZero())
+}
+
+// fakeTermDeposits keeps term deposits in memory.
+type fakeTermDeposits struct {
+	repositories.ITermDepositRepository
+	deposits map[int64]*models.TermDeposit
+}
+
+func (f *fakeTermDeposits) GetTermDepositForUpdate(tx *sqlx.Tx, depositID int64) (*models.TermDeposit, error) {
+	deposit, ok := f.deposits[depositID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *deposit
+	return &copied, nil
+}
+
+func (f *fakeTermDeposits) UpdateTermDeposit(tx *sqlx.Tx, deposit *models.TermDeposit) error {
+	f.deposits[deposit.ID] = deposit
+	return nil
+}
+
+// fakeDepositBook gives each deposit its own ledger account, numbered 500
+// plus its ID.
+type fakeDepositBook struct {
+	*fakeBook
+}
+
+func (f fakeDepositBook) GetOrCreateTermDepositLedger(tx *sqlx.Tx, deposit *models.TermDeposit) (*models.LedgerAccount, error) {
+	return &models.LedgerAccount{ID: 500 + deposit.ID, Currency: deposit.Currency}, nil
+}
+
+func TestBreak_RefusedFromTheMaturityDate(t *testing.T) {
+	maturesOn := time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		now  time.Time
+		err  error
+	}{
+		{name: "last minute of the day before", now: maturesOn.Add(-time.Minute)},
+		{name: "maturity date", now: maturesOn, err: apperrors.ErrTermDepositMatured},
+		{name: "late on the maturity date", now: maturesOn.Add(23 * time.Hour), err: apperrors.ErrTermDepositMatured},
+		{name: "after maturity", now: maturesOn.AddDate(0, 0, 3), err: apperrors.ErrTermDepositMatured},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			account := &models.Account{ID: 1, UserID: 7, Currency: money.USD, Status: models.AccountOpen,
+				Balance: money.New(0, money.USD), Available: money.New(0, money.USD)}
+			deposits := &fakeTermDeposits{deposits: map[int64]*models.TermDeposit{
+				3: {ID: 3, UserID: 7, AccountID: 1, Currency: money.USD, Principal: money.New(50000, money.USD),
+					StartsOn: maturesOn.AddDate(0, -6, 0), MaturesOn: maturesOn, Status: models.TermDepositActive},
+			}}
+			fake := &fakeCompensation{accounts: map[int64]*models.Account{1: account}}
+			book := fakeDepositBook{newFakeBook(account)}
+			book.ledger[503] = -50000
+			transactions := NewTransactionService(fake, book, fake, nil, nil, nil, nil, nil, nil, txOnlyDB())
+			service := NewTermDepositService(deposits, fake, transactions, nil, txOnlyDB())
+
+			deposit, _, err := service.Break(context.Background(), 7, 3, tc.now)
+
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				assert.Equal(t, models.TermDepositActive, deposits.deposits[3].Status)
+				assert.Empty(t, fake.written, "no money moved")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.TermDepositBroken, deposit.Status)
+			assert.Equal(t, money.New(50000, money.USD), account.Balance)
+		})
+	}
+}
@@ -1,155 +1,1288 @@
 package services
 
 import (
+	"MockBankGo/auth"
 	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/fx"
 	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
 	"MockBankGo/internal/repositories"
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type TransactionService struct {
-	transactionRepo repositories.ITransationRepository
-	userRepo        *repositories.UserRepository
-	database        *sqlx.DB
+	transactionRepo  repositories.ITransationRepository
+	ledgerRepo       repositories.ILedgerRepository
+	accountRepo      repositories.IAccountRepository
+	idempotencyRepo  repositories.IIdempotencyRepository
+	limitService     *LimitService
+	fxService        *FXService
+	feeService       *FeeService
+	overdraftService *OverdraftService
+	auditService     *AuditService
+	database         *sqlx.DB
 }
 
-func NewTransactionService(trepo repositories.ITransationRepository, urepo *repositories.UserRepository, db *sqlx.DB) *TransactionService {
-	return &TransactionService{transactionRepo: trepo, userRepo: urepo, database: db}
+func NewTransactionService(trepo repositories.ITransationRepository, lrepo repositories.ILedgerRepository, arepo repositories.IAccountRepository, irepo repositories.IIdempotencyRepository, limitService *LimitService, fxService *FXService, feeService *FeeService, overdraftService *OverdraftService, auditService *AuditService, db *sqlx.DB) *TransactionService {
+	return &TransactionService{transactionRepo: trepo, ledgerRepo: lrepo, accountRepo: arepo, idempotencyRepo: irepo, limitService: limitService, fxService: fxService, feeService: feeService, overdraftService: overdraftService, auditService: auditService, database: db}
 }
 
-func (s *TransactionService) validateAmount(amount float64) error {
-	if amount <= 0 {
+func (s *TransactionService) validateAmount(amount money.Money) error {
+	if !amount.IsPositive() {
 		return apperrors.ErrInvalidAmount
 	}
+	if !amount.Currency.Valid() {
+		return apperrors.ErrUnsupportedCurrency
+	}
 	return nil
 }
 
-func (s *TransactionService) WithdrawMoney(userID int64, amount float64) error {
-	if err := s.validateAmount(amount); err != nil {
-		return err
+// lockAccount locks an open account for the rest of the transaction and
+// checks that it holds the given currency. An empty currency accepts any.
+func (s *TransactionService) lockAccount(tx *sqlx.Tx, accountID int64, currency money.Currency, notFound error) (*models.Account, error) {
+	account, err := s.accountRepo.GetAccountForUpdate(tx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound
+		}
+		return nil, apperrors.ErrDatabaseError
 	}
 
-	tx, err := s.database.Beginx()
+	if account.Status != models.AccountOpen {
+		return nil, apperrors.ErrAccountClosed
+	}
+	if currency != "" && account.Currency != currency {
+		return nil, apperrors.ErrCurrencyMismatch
+	}
+	return account, nil
+}
+
+// lockOwnAccount is lockAccount for an account the caller must own.
+func (s *TransactionService) lockOwnAccount(tx *sqlx.Tx, userID int64, accountID int64, amount money.Money) (*models.Account, error) {
+	account, err := s.lockAccount(tx, accountID, amount.Currency, apperrors.ErrAccountNotFound)
 	if err != nil {
+		return nil, err
+	}
+	if account.UserID != userID {
+		return nil, apperrors.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// postJournal records a balanced set of ledger entries for a transaction and
+// then checks that the cached balance of every affected account still
+// agrees with its ledger account.
+func (s *TransactionService) postJournal(tx *sqlx.Tx, transactionID int64, description string, accounts []*models.Account, entries ...models.LedgerEntry) error {
+	if _, err := s.ledgerRepo.PostJournal(tx, transactionID, description, entries); err != nil {
 		return apperrors.ErrDatabaseError
 	}
-	defer tx.Rollback()
 
-	balance, err := s.userRepo.GetBalance(tx, userID)
+	for _, account := range accounts {
+		if err := s.verifyAccountBalance(tx, account.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TransactionService) verifyAccountBalance(tx *sqlx.Tx, accountID int64) error {
+	account, err := s.accountRepo.GetAccountForUpdate(tx, accountID)
 	if err != nil {
 		return apperrors.ErrDatabaseError
 	}
 
-	if balance < amount {
-		return apperrors.ErrInsufficientFunds
+	ledgerAccount, err := s.ledgerRepo.GetOrCreateAccountLedger(tx, account)
+	if err != nil {
+		return apperrors.ErrDatabaseError
 	}
 
-	if err := s.transactionRepo.DecreaseBalance(tx, userID, amount); err != nil {
+	ledgerBalance, err := s.ledgerRepo.GetAccountBalance(tx, ledgerAccount.ID)
+	if err != nil {
 		return apperrors.ErrDatabaseError
 	}
 
-	if err := s.transactionRepo.WriteTransaction(tx, userID, userID, amount, models.Withdraw, time.Now()); err != nil {
-		return apperrors.ErrDatabaseError
+	// Customer accounts are liabilities, so their credit balance is what the customer owns.
+	if account.Balance.Amount != -ledgerBalance.Amount {
+		return apperrors.ErrLedgerMismatch
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
+func (s *TransactionService) accountLedger(tx *sqlx.Tx, account *models.Account) (int64, error) {
+	ledgerAccount, err := s.ledgerRepo.GetOrCreateAccountLedger(tx, account)
+	if err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	return ledgerAccount.ID, nil
+}
+
+func (s *TransactionService) systemLedgerAccount(tx *sqlx.Tx, code string, currency money.Currency) (int64, error) {
+	ledgerAccount, err := s.ledgerRepo.GetOrCreateSystemAccount(tx, code, currency)
+	if err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	return ledgerAccount.ID, nil
+}
+
+// movementEntries returns the journal legs moving debit out of one customer
+// ledger account and credit into another. When the currencies differ the
+// money passes through the FX position account of each currency, so the
+// journal balances within every currency.
+func (s *TransactionService) movementEntries(tx *sqlx.Tx, fromLedger int64, debit money.Money, toLedger int64, credit money.Money) ([]models.LedgerEntry, error) {
+	if debit.Currency == credit.Currency {
+		return []models.LedgerEntry{
+			models.Debit(fromLedger, debit),
+			models.Credit(toLedger, credit),
+		}, nil
+	}
+
+	fromPosition, err := s.systemLedgerAccount(tx, models.LedgerFXPositionCode, debit.Currency)
+	if err != nil {
+		return nil, err
+	}
+	toPosition, err := s.systemLedgerAccount(tx, models.LedgerFXPositionCode, credit.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return []models.LedgerEntry{
+		models.Debit(fromLedger, debit),
+		models.Credit(fromPosition, debit),
+		models.Debit(toPosition, credit),
+		models.Credit(toLedger, credit),
+	}, nil
+}
+
+// feeEntries returns the journal legs moving a fee from a customer ledger
+// account to fee income, or nothing when there is no fee.
+func (s *TransactionService) feeEntries(tx *sqlx.Tx, ledger int64, fee money.Money) ([]models.LedgerEntry, error) {
+	if fee.IsZero() {
+		return nil, nil
+	}
+	feeIncome, err := s.systemLedgerAccount(tx, models.LedgerFeeIncomeCode, fee.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return []models.LedgerEntry{
+		models.Debit(ledger, fee),
+		models.Credit(feeIncome, fee),
+	}, nil
+}
+
+// withConversion records an exchange on the transaction.
+func withConversion(transaction *models.TransactionInfo, conversion *Conversion) {
+	rate := conversion.Rate.String()
+	transaction.ToAmount = &conversion.To
+	transaction.FXRate = &rate
+	transaction.FXQuoteID = conversion.QuoteID
+}
+
+// auditTransaction completes the audit entry of a money movement with the
+// transaction it produced.
+func auditTransaction(transaction *models.TransactionInfo) func(entry *models.AuditEntry) {
+	return func(entry *models.AuditEntry) {
+		amount := transaction.Amount
+		entry.TargetID = &transaction.ID
+		entry.Amount = &amount
+		if len(transaction.Fees) > 0 {
+			entry.Details = withAuditDetail(entry.Details, "fees", transaction.Fees)
+		}
+		if transaction.ToAmount != nil {
+			entry.Details = withAuditDetail(entry.Details, "to_amount", transaction.ToAmount)
+			entry.Details = withAuditDetail(entry.Details, "fx_rate", transaction.FXRate)
+		}
+	}
+}
+
+// transition moves a transaction to its next status.
+func (s *TransactionService) transition(tx *sqlx.Tx, transaction *models.TransactionInfo, to models.TransactionStatus, reason *models.FailureReason) error {
+	if !transaction.Status.CanTransitionTo(to) {
+		return apperrors.ErrInvalidStatusTransition
+	}
+
+	now := time.Now()
+	if err := s.transactionRepo.UpdateStatus(tx, transaction.ID, transaction.Status, to, reason, now); err != nil {
+		if err == repositories.ErrStatusChanged {
+			return apperrors.ErrInvalidStatusTransition
+		}
 		return apperrors.ErrDatabaseError
 	}
 
+	transaction.Status = to
+	transaction.FailureReason = reason
+	transaction.StatusChangedAt = now
 	return nil
 }
 
-func (s *TransactionService) DepositMoney(userID int64, amount float64) error {
+// failureReason returns why a refused money movement failed, for refusals
+// worth keeping on record.
+func failureReason(err error) (models.FailureReason, bool) {
+	if _, ok := err.(*LimitExceeded); ok {
+		return models.FailureLimitExceeded, true
+	}
+	switch err {
+	case apperrors.ErrInsufficientFunds:
+		return models.FailureInsufficientFunds, true
+	case apperrors.ErrFXRateUnavailable:
+		return models.FailureFXRateUnavailable, true
+	}
+	return "", false
+}
+
+// recordFailure keeps a refused deposit, withdrawal or transfer as a failed
+// transaction, so support can see why it did not go through. It must run
+// after the attempt's own database transaction has rolled back, since the
+// insert needs the account rows that transaction locked. A failure to record
+// is logged and never changes the caller's error.
+func (s *TransactionService) recordFailure(attempt models.TransactionInfo, err error) {
+	reason, ok := failureReason(err)
+	if !ok {
+		return
+	}
+
+	if attempt.ReceiverID == 0 && attempt.ToAccountID != nil {
+		to, lookupErr := s.accountRepo.GetAccountByID(*attempt.ToAccountID)
+		if lookupErr != nil {
+			log.Printf("transactions: failed to record refused %s: %v", attempt.Type, lookupErr)
+			return
+		}
+		attempt.ReceiverID = to.UserID
+	}
+
+	tx, txErr := s.database.Beginx()
+	if txErr != nil {
+		log.Printf("transactions: failed to record refused %s: %v", attempt.Type, txErr)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, writeErr := s.transactionRepo.WriteTransaction(tx, &attempt); writeErr != nil {
+		log.Printf("transactions: failed to record refused %s: %v", attempt.Type, writeErr)
+		return
+	}
+	if statusErr := s.transition(tx, &attempt, models.TransactionFailed, &reason); statusErr != nil {
+		log.Printf("transactions: failed to record refused %s: %v", attempt.Type, statusErr)
+		return
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		log.Printf("transactions: failed to record refused %s: %v", attempt.Type, commitErr)
+	}
+}
+
+func (s *TransactionService) WithdrawMoney(ctx context.Context, userID int64, accountID int64, amount money.Money) (transaction *models.TransactionInfo, err error) {
 	if err := s.validateAmount(amount); err != nil {
-		return err
+		return nil, err
 	}
 
-	tx, err := s.database.Beginx()
+	// Deferred before the rollback below, so it runs after it.
+	defer func() {
+		s.recordFailure(models.TransactionInfo{
+			SenderID:      userID,
+			ReceiverID:    userID,
+			FromAccountID: &accountID,
+			Amount:        amount,
+			Type:          models.Withdraw,
+		}, err)
+	}()
+
+	tx, err := s.database.BeginTxx(ctx, nil)
 	if err != nil {
-		return apperrors.ErrDatabaseError
+		return nil, apperrors.ErrDatabaseError
 	}
 	defer tx.Rollback()
 
-	if err := s.transactionRepo.IncreaseBalance(tx, userID, amount); err != nil {
-		return apperrors.ErrDatabaseError
+	if err := claimIdempotency(ctx, s.idempotencyRepo, tx, userID); err != nil {
+		return nil, err
 	}
 
-	if err := s.transactionRepo.WriteTransaction(tx, userID, userID, amount, models.Withdraw, time.Now()); err != nil {
-		return apperrors.ErrDatabaseError
+	account, err := s.lockOwnAccount(tx, userID, accountID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, fee, err := s.feeService.Assess(tx, userID, models.Withdraw, amount, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Money reserved by holds cannot be withdrawn; an overdraft may cover
+	// what the available balance does not.
+	if err := s.overdraftService.Cover(tx, account, amount.Add(fee)); err != nil {
+		return nil, err
+	}
+
+	if err := s.limitService.Check(tx, userID, models.Withdraw, amount, time.Now()); err != nil {
+		return nil, err
+	}
+
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+	cashAccount, err := s.systemLedgerAccount(tx, models.LedgerCashCode, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	feeLegs, err := s.feeEntries(tx, accountLedger, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionRepo.DecreaseBalance(tx, account.ID, amount.Add(fee)); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction = &models.TransactionInfo{
+		SenderID:      userID,
+		ReceiverID:    userID,
+		FromAccountID: &account.ID,
+		Amount:        amount,
+		Type:          models.Withdraw,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := s.feeService.RecordFees(tx, transaction, fees); err != nil {
+		return nil, err
+	}
+
+	entries := append([]models.LedgerEntry{
+		models.Debit(accountLedger, amount),
+		models.Credit(cashAccount, amount),
+	}, feeLegs...)
+	err = s.postJournal(tx, transactionID, fmt.Sprintf("withdrawal from account %s", account.Number), []*models.Account{account}, entries...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+
+	if err := completeIdempotency(ctx, s.idempotencyRepo, tx, userID, http.StatusOK, transaction); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.queueAudit(ctx, tx, auditTransaction(transaction)); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return apperrors.ErrDatabaseError
+		return nil, apperrors.ErrDatabaseError
 	}
 
-	return nil
+	return transaction, nil
+}
+
+func (s *TransactionService) DepositMoney(ctx context.Context, userID int64, accountID int64, amount money.Money) (transaction *models.TransactionInfo, err error) {
+	if err := s.validateAmount(amount); err != nil {
+		return nil, err
+	}
+
+	// Deferred before the rollback below, so it runs after it.
+	defer func() {
+		s.recordFailure(models.TransactionInfo{
+			SenderID:    userID,
+			ReceiverID:  userID,
+			ToAccountID: &accountID,
+			Amount:      amount,
+			Type:        models.Deposit,
+		}, err)
+	}()
+
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
+
+	if err := claimIdempotency(ctx, s.idempotencyRepo, tx, userID); err != nil {
+		return nil, err
+	}
+
+	account, err := s.lockOwnAccount(tx, userID, accountID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.limitService.Check(tx, userID, models.Deposit, amount, time.Now()); err != nil {
+		return nil, err
+	}
+
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+	cashAccount, err := s.systemLedgerAccount(tx, models.LedgerCashCode, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionRepo.IncreaseBalance(tx, account.ID, amount); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction = &models.TransactionInfo{
+		SenderID:    userID,
+		ReceiverID:  userID,
+		ToAccountID: &account.ID,
+		Amount:      amount,
+		Type:        models.Deposit,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	err = s.postJournal(tx, transactionID, fmt.Sprintf("deposit to account %s", account.Number), []*models.Account{account},
+		models.Debit(cashAccount, amount),
+		models.Credit(accountLedger, amount),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+
+	if err := completeIdempotency(ctx, s.idempotencyRepo, tx, userID, http.StatusOK, transaction); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.queueAudit(ctx, tx, auditTransaction(transaction)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return transaction, nil
 }
 
-func (s *TransactionService) TransferMoney(ctx context.Context, senderID int64, receiverID int64, amount float64) error {
+// TransferMoney moves amount, in the sending account's currency, to another
+// account. If the receiving account holds a different currency the amount
+// is converted, at the rate of quoteID when given or else at the current
+// rate.
+func (s *TransactionService) TransferMoney(ctx context.Context, senderID int64, fromAccountID int64, toAccountID int64, amount money.Money, quoteID string) (transaction *models.TransactionInfo, err error) {
 	if err := s.validateAmount(amount); err != nil {
-		return err
+		return nil, err
 	}
 
-	if receiverID == senderID {
-		return apperrors.ErrSelfTransfer
+	if fromAccountID == toAccountID {
+		return nil, apperrors.ErrSelfTransfer
 	}
 
+	// Deferred before the rollback below, so it runs after it.
+	defer func() {
+		s.recordFailure(models.TransactionInfo{
+			SenderID:      senderID,
+			FromAccountID: &fromAccountID,
+			ToAccountID:   &toAccountID,
+			Amount:        amount,
+			Type:          models.Transfer,
+		}, err)
+	}()
+
 	tx, err := s.database.BeginTxx(ctx, &sql.TxOptions{
 		Isolation: sql.LevelRepeatableRead,
 	})
 
 	if err != nil {
-		return apperrors.ErrDatabaseError
+		return nil, apperrors.ErrDatabaseError
 	}
 	defer tx.Rollback()
 
-	sender, err := s.userRepo.GetUserByIdForUpdate(tx, senderID)
+	if err := claimIdempotency(ctx, s.idempotencyRepo, tx, senderID); err != nil {
+		return nil, err
+	}
+
+	transaction, err = s.transfer(tx, senderID, fromAccountID, toAccountID, amount, quoteID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return apperrors.ErrSenderNotFound
+		return nil, err
+	}
+
+	if err := completeIdempotency(ctx, s.idempotencyRepo, tx, senderID, http.StatusOK, transaction); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.queueAudit(ctx, tx, auditTransaction(transaction)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return transaction, nil
+}
+
+// transfer is TransferMoney inside an existing transaction. The sender pays
+// from the available balance and any overdraft, so money reserved by holds
+// stays put.
+func (s *TransactionService) transfer(tx *sqlx.Tx, senderID int64, fromAccountID int64, toAccountID int64, amount money.Money, quoteID string) (*models.TransactionInfo, error) {
+	// Lock both accounts in ID order so concurrent transfers in opposite
+	// directions cannot deadlock.
+	var from, to *models.Account
+	var err error
+	if fromAccountID < toAccountID {
+		if from, err = s.lockOwnAccount(tx, senderID, fromAccountID, amount); err != nil {
+			return nil, err
 		}
-		return apperrors.ErrDatabaseError
+		if to, err = s.lockAccount(tx, toAccountID, "", apperrors.ErrReceiverNotFound); err != nil {
+			return nil, err
+		}
+	} else {
+		if to, err = s.lockAccount(tx, toAccountID, "", apperrors.ErrReceiverNotFound); err != nil {
+			return nil, err
+		}
+		if from, err = s.lockOwnAccount(tx, senderID, fromAccountID, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	converted := quoteID != "" || to.Currency != from.Currency
+	fees, fee, err := s.feeService.Assess(tx, senderID, models.Transfer, amount, converted)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.overdraftService.Cover(tx, from, amount.Add(fee)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.limitService.Check(tx, senderID, models.Transfer, amount, now); err != nil {
+		return nil, err
+	}
+
+	var conversion *Conversion
+	switch {
+	case quoteID != "":
+		conversion, err = s.fxService.RedeemQuote(tx, senderID, quoteID, amount, to.Currency, now)
+	case to.Currency != from.Currency:
+		conversion, err = s.fxService.Convert(amount, to.Currency, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	credit := amount
+	if conversion != nil {
+		credit = conversion.To
+	}
+
+	fromLedger, err := s.accountLedger(tx, from)
+	if err != nil {
+		return nil, err
+	}
+	toLedger, err := s.accountLedger(tx, to)
+	if err != nil {
+		return nil, err
+	}
+	feeLegs, err := s.feeEntries(tx, fromLedger, fee)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionRepo.DecreaseBalance(tx, from.ID, amount.Add(fee)); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := s.transactionRepo.IncreaseBalance(tx, to.ID, credit); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:      from.UserID,
+		ReceiverID:    to.UserID,
+		FromAccountID: &from.ID,
+		ToAccountID:   &to.ID,
+		Amount:        amount,
+		Type:          models.Transfer,
+	}
+	if conversion != nil {
+		withConversion(transaction, conversion)
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := s.feeService.RecordFees(tx, transaction, fees); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.movementEntries(tx, fromLedger, amount, toLedger, credit)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, feeLegs...)
+	err = s.postJournal(tx, transactionID, fmt.Sprintf("transfer from account %s to account %s", from.Number, to.Number), []*models.Account{from, to}, entries...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// chargeInterest takes interest from an account, locked in tx, to the bank's
+// interest income. Interest is charged even if it takes the account past its
+// overdraft limit.
+func (s *TransactionService) chargeInterest(tx *sqlx.Tx, account *models.Account, interest money.Money, description string) (*models.TransactionInfo, error) {
+	return s.postInterest(tx, account, interest, true, description)
+}
+
+// payInterest pays interest into an account, locked in tx, from the bank's
+// interest expense.
+func (s *TransactionService) payInterest(tx *sqlx.Tx, account *models.Account, interest money.Money, description string) (*models.TransactionInfo, error) {
+	return s.postInterest(tx, account, interest, false, description)
+}
+
+// postInterest records an interest transaction between an account and the
+// bank: charged from the account to interest income, or paid into it from
+// interest expense.
+func (s *TransactionService) postInterest(tx *sqlx.Tx, account *models.Account, interest money.Money, charged bool, description string) (*models.TransactionInfo, error) {
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:   account.UserID,
+		ReceiverID: account.UserID,
+		Amount:     interest,
+		Type:       models.Interest,
+	}
+
+	bankCode := models.LedgerInterestExpenseCode
+	if charged {
+		bankCode = models.LedgerInterestIncomeCode
+	}
+	bankLedger, err := s.systemLedgerAccount(tx, bankCode, interest.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.LedgerEntry
+	if charged {
+		transaction.FromAccountID = &account.ID
+		entries = []models.LedgerEntry{models.Debit(accountLedger, interest), models.Credit(bankLedger, interest)}
+		err = s.transactionRepo.DecreaseBalance(tx, account.ID, interest)
+	} else {
+		transaction.ToAccountID = &account.ID
+		entries = []models.LedgerEntry{models.Debit(bankLedger, interest), models.Credit(accountLedger, interest)}
+		err = s.transactionRepo.IncreaseBalance(tx, account.ID, interest)
+	}
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := s.postJournal(tx, transactionID, description, []*models.Account{account}, entries...); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// disburseLoan pays a loan into an account, locked in tx, from the bank's
+// loans receivable.
+func (s *TransactionService) disburseLoan(tx *sqlx.Tx, account *models.Account, amount money.Money, description string) (*models.TransactionInfo, error) {
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+	receivable, err := s.systemLedgerAccount(tx, models.LedgerLoansReceivableCode, amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionRepo.IncreaseBalance(tx, account.ID, amount); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:    account.UserID,
+		ReceiverID:  account.UserID,
+		ToAccountID: &account.ID,
+		Amount:      amount,
+		Type:        models.LoanDisbursement,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	err = s.postJournal(tx, transactionID, description, []*models.Account{account},
+		models.Debit(receivable, amount), models.Credit(accountLedger, amount))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// collectLoan takes a loan repayment from an account, locked in tx: principal
+// back to loans receivable, interest to interest income and any late fee to
+// fee income. The account pays from its available balance and any
+// overdraft, like a withdrawal.
+func (s *TransactionService) collectLoan(tx *sqlx.Tx, account *models.Account, principal money.Money, interest money.Money, lateFee money.Money, description string) (*models.TransactionInfo, error) {
+	amount := principal.Add(interest)
+	if err := s.overdraftService.Cover(tx, account, amount.Add(lateFee)); err != nil {
+		return nil, err
+	}
+
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.LedgerEntry{models.Debit(accountLedger, amount)}
+	if principal.IsPositive() {
+		receivable, err := s.systemLedgerAccount(tx, models.LedgerLoansReceivableCode, principal.Currency)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.Credit(receivable, principal))
+	}
+	if interest.IsPositive() {
+		interestIncome, err := s.systemLedgerAccount(tx, models.LedgerInterestIncomeCode, interest.Currency)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.Credit(interestIncome, interest))
+	}
+	feeLegs, err := s.feeEntries(tx, accountLedger, lateFee)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, feeLegs...)
+
+	if err := s.transactionRepo.DecreaseBalance(tx, account.ID, amount.Add(lateFee)); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:      account.UserID,
+		ReceiverID:    account.UserID,
+		FromAccountID: &account.ID,
+		Amount:        amount,
+		Type:          models.LoanRepayment,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if lateFee.IsPositive() {
+		fees := []models.TransactionFee{{Type: models.FeeLatePayment, Amount: lateFee}}
+		if err := s.feeService.RecordFees(tx, transaction, fees); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.postJournal(tx, transactionID, description, []*models.Account{account}, entries...); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (s *TransactionService) termDepositLedger(tx *sqlx.Tx, deposit *models.TermDeposit) (int64, error) {
+	ledgerAccount, err := s.ledgerRepo.GetOrCreateTermDepositLedger(tx, deposit)
+	if err != nil {
+		return 0, apperrors.ErrDatabaseError
+	}
+	return ledgerAccount.ID, nil
+}
+
+// openTermDeposit moves a new deposit's principal from an account, locked in
+// tx, into the deposit's own ledger account. Only the available balance can
+// be locked away; an overdraft cannot fund a deposit.
+func (s *TransactionService) openTermDeposit(tx *sqlx.Tx, account *models.Account, deposit *models.TermDeposit, description string) (*models.TransactionInfo, error) {
+	if account.Available.Cmp(deposit.Principal) < 0 {
+		return nil, apperrors.ErrInsufficientFunds
+	}
+
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+	depositLedger, err := s.termDepositLedger(tx, deposit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionRepo.DecreaseBalance(tx, account.ID, deposit.Principal); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:      account.UserID,
+		ReceiverID:    account.UserID,
+		FromAccountID: &account.ID,
+		Amount:        deposit.Principal,
+		Type:          models.TermDepositOpen,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	err = s.postJournal(tx, transactionID, description, []*models.Account{account},
+		models.Debit(accountLedger, deposit.Principal), models.Credit(depositLedger, deposit.Principal))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// payOutTermDeposit pays a deposit's principal and interest back into an
+// account, locked in tx, less any penalty for breaking it early. Interest
+// comes from the bank's interest expense and the penalty goes to fee income.
+func (s *TransactionService) payOutTermDeposit(tx *sqlx.Tx, account *models.Account, deposit *models.TermDeposit, interest money.Money, penalty money.Money, description string) (*models.TransactionInfo, error) {
+	accountLedger, err := s.accountLedger(tx, account)
+	if err != nil {
+		return nil, err
+	}
+	depositLedger, err := s.termDepositLedger(tx, deposit)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := deposit.Principal.Add(interest)
+	credit := amount.Sub(penalty)
+	entries := []models.LedgerEntry{models.Debit(depositLedger, deposit.Principal)}
+	if interest.IsPositive() {
+		interestExpense, err := s.systemLedgerAccount(tx, models.LedgerInterestExpenseCode, interest.Currency)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.Debit(interestExpense, interest))
+	}
+	if credit.IsPositive() {
+		entries = append(entries, models.Credit(accountLedger, credit))
+	}
+	if penalty.IsPositive() {
+		feeIncome, err := s.systemLedgerAccount(tx, models.LedgerFeeIncomeCode, penalty.Currency)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.Credit(feeIncome, penalty))
+	}
+
+	if err := s.transactionRepo.IncreaseBalance(tx, account.ID, credit); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:    account.UserID,
+		ReceiverID:  account.UserID,
+		ToAccountID: &account.ID,
+		Amount:      amount,
+		Type:        models.TermDepositPayout,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if penalty.IsPositive() {
+		fees := []models.TransactionFee{{Type: models.FeeEarlyWithdrawal, Amount: penalty}}
+		if err := s.feeService.RecordFees(tx, transaction, fees); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.postJournal(tx, transactionID, description, []*models.Account{account}, entries...); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// rollOverTermDeposit moves a matured deposit's principal, and the interest
+// it earned, into the deposit that replaces it. No customer account changes.
+func (s *TransactionService) rollOverTermDeposit(tx *sqlx.Tx, from *models.TermDeposit, to *models.TermDeposit, interest money.Money, description string) (*models.TransactionInfo, error) {
+	fromLedger, err := s.termDepositLedger(tx, from)
+	if err != nil {
+		return nil, err
+	}
+	toLedger, err := s.termDepositLedger(tx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []models.LedgerEntry{models.Debit(fromLedger, from.Principal)}
+	if interest.IsPositive() {
+		interestExpense, err := s.systemLedgerAccount(tx, models.LedgerInterestExpenseCode, interest.Currency)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.Debit(interestExpense, interest))
+	}
+	entries = append(entries, models.Credit(toLedger, to.Principal))
+
+	transaction := &models.TransactionInfo{
+		SenderID:   from.UserID,
+		ReceiverID: from.UserID,
+		Amount:     to.Principal,
+		Type:       models.TermDepositRollover,
+	}
+	transactionID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := s.postJournal(tx, transactionID, description, nil, entries...); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
 	}
+	return transaction, nil
+}
+
+// ReverseTransaction lets an admin undo a transfer, fully or partially, by
+// moving the money back from the receiver to the sender.
+func (s *TransactionService) ReverseTransaction(ctx context.Context, actorID int64, transactionID int64, amount *money.Money) (*models.TransactionInfo, error) {
+	return s.compensate(ctx, actorID, transactionID, amount, models.Reversal)
+}
+
+// RefundTransaction lets the receiver of a transfer send all or part of it back.
+func (s *TransactionService) RefundTransaction(ctx context.Context, actorID int64, transactionID int64, amount *money.Money) (*models.TransactionInfo, error) {
+	return s.compensate(ctx, actorID, transactionID, amount, models.Refund)
+}
+
+// compensate posts a transaction linked to the original through reversal_of
+// that moves money back from the original receiver. The original row is
+// locked so concurrent reversals cannot together exceed its amount.
+func (s *TransactionService) compensate(ctx context.Context, actorID int64, transactionID int64, requested *money.Money, kind models.TransactionType) (*models.TransactionInfo, error) {
+	tx, err := s.database.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	defer tx.Rollback()
 
-	if sender.Balance < amount {
-		return apperrors.ErrInsufficientFunds
+	if err := claimIdempotency(ctx, s.idempotencyRepo, tx, actorID); err != nil {
+		return nil, err
 	}
 
-	_, err = s.userRepo.GetUserByIdForUpdate(tx, receiverID)
+	original, err := s.transactionRepo.GetTransactionForUpdate(tx, transactionID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return apperrors.ErrReceiverNotFound
+			return nil, apperrors.ErrTransactionNotFound
 		}
-		return apperrors.ErrDatabaseError
+		return nil, apperrors.ErrDatabaseError
 	}
 
-	if err := s.transactionRepo.DecreaseBalance(tx, senderID, amount); err != nil {
-		return apperrors.ErrDatabaseError
+	// Only the receiver may refund; anyone else is told the transaction does not exist.
+	if kind == models.Refund && original.ReceiverID != actorID {
+		return nil, apperrors.ErrTransactionNotFound
+	}
+	if original.Type != models.Transfer || original.FromAccountID == nil || original.ToAccountID == nil {
+		return nil, apperrors.ErrNotReversible
+	}
+	if original.Status == models.TransactionReversed {
+		return nil, apperrors.ErrAlreadyReversed
+	}
+	if original.Status != models.TransactionCompleted {
+		return nil, apperrors.ErrNotReversible
 	}
 
-	if err := s.transactionRepo.IncreaseBalance(tx, receiverID, amount); err != nil {
-		return apperrors.ErrDatabaseError
+	payer, payee, err := s.lockPair(tx, *original.ToAccountID, *original.FromAccountID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.transactionRepo.WriteTransaction(tx, senderID, receiverID, amount, models.Transfer, time.Now()); err != nil {
-		return apperrors.ErrDatabaseError
+	// What the original receiver got, in the payer's currency.
+	received := original.Amount
+	if original.ToAmount != nil {
+		received = *original.ToAmount
+	}
+
+	reversed, returned, err := s.transactionRepo.GetReversedTotals(tx, original.ID)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+	reversed.Currency = received.Currency
+	returned.Currency = original.Amount.Currency
+
+	remaining := received.Sub(reversed)
+	if !remaining.IsPositive() {
+		return nil, apperrors.ErrAlreadyReversed
+	}
+
+	amount := remaining
+	if requested != nil {
+		amount = *requested
+		if err := s.validateAmount(amount); err != nil {
+			return nil, err
+		}
+		if amount.Currency != payer.Currency {
+			return nil, apperrors.ErrCurrencyMismatch
+		}
+		if amount.Cmp(remaining) > 0 {
+			return nil, apperrors.ErrReversalExceedsOriginal
+		}
+	}
+
+	// Money the receiver has reserved with holds cannot be sent back; an
+	// overdraft may cover what the available balance does not.
+	if err := s.overdraftService.Cover(tx, payer, amount); err != nil {
+		return nil, err
+	}
+
+	// An exchange is undone at its original rate. The last compensation
+	// returns whatever is left, so rounding never keeps anything back.
+	back := amount
+	if original.ToAmount != nil {
+		if amount == remaining {
+			back = original.Amount.Sub(returned)
+		} else {
+			back = fx.Share(original.Amount, amount.Amount, received.Amount)
+		}
+		if !back.IsPositive() {
+			return nil, apperrors.ErrFXAmountTooSmall
+		}
+	}
+
+	payerLedger, err := s.accountLedger(tx, payer)
+	if err != nil {
+		return nil, err
+	}
+	payeeLedger, err := s.accountLedger(tx, payee)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transactionRepo.DecreaseBalance(tx, payer.ID, amount); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	if err := s.transactionRepo.IncreaseBalance(tx, payee.ID, back); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	transaction := &models.TransactionInfo{
+		SenderID:      payer.UserID,
+		ReceiverID:    payee.UserID,
+		FromAccountID: &payer.ID,
+		ToAccountID:   &payee.ID,
+		Amount:        amount,
+		Type:          kind,
+		ReversalOf:    &original.ID,
+	}
+	if original.ToAmount != nil {
+		rate, err := fx.ParseRate(original.Amount.Currency, received.Currency, *original.FXRate)
+		if err != nil {
+			return nil, apperrors.ErrInternalServer
+		}
+		withConversion(transaction, &Conversion{From: amount, To: back, Rate: rate.Invert()})
+	}
+	compensationID, err := s.transactionRepo.WriteTransaction(tx, transaction)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	entries, err := s.movementEntries(tx, payerLedger, amount, payeeLedger, back)
+	if err != nil {
+		return nil, err
+	}
+	err = s.postJournal(tx, compensationID, fmt.Sprintf("%s of transaction %d", kind, original.ID), []*models.Account{payer, payee}, entries...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(tx, transaction, models.TransactionCompleted, nil); err != nil {
+		return nil, err
+	}
+	if amount == remaining {
+		if err := s.transition(tx, original, models.TransactionReversed, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// The handler answers a compensation with 201 Created.
+	if err := completeIdempotency(ctx, s.idempotencyRepo, tx, actorID, http.StatusCreated, transaction); err != nil {
+		return nil, err
+	}
+	if err := s.auditService.queueAudit(ctx, tx, auditTransaction(transaction)); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	return transaction, nil
+}
+
+// lockPair locks two open accounts in ID order, so it cannot deadlock with a
+// concurrent operation on the same pair, and returns them in argument order.
+func (s *TransactionService) lockPair(tx *sqlx.Tx, firstID int64, secondID int64) (*models.Account, *models.Account, error) {
+	ids := []int64{firstID, secondID}
+	if secondID < firstID {
+		ids = []int64{secondID, firstID}
+	}
+
+	locked := make(map[int64]*models.Account, 2)
+	for _, id := range ids {
+		account, err := s.accountRepo.GetAccountForUpdate(tx, id)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, nil, apperrors.ErrAccountNotFound
+			}
+			return nil, nil, apperrors.ErrDatabaseError
+		}
+		if account.Status != models.AccountOpen {
+			return nil, nil, apperrors.ErrAccountClosed
+		}
+		locked[id] = account
+	}
+
+	return locked[firstID], locked[secondID], nil
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// GetTransactions returns one page of transactions visible to the caller.
+// Callers without PermReadAllTransactions only see transactions they sent or
+// received; the rest may query any user or, with no user filter, everyone.
+func (s *TransactionService) GetTransactions(callerID int64, callerRole auth.Role, filter models.TransactionFilter) (*models.TransactionPage, error) {
+	if !callerRole.Can(auth.PermReadAllTransactions) {
+		if filter.UserID != nil && *filter.UserID != callerID {
+			return nil, apperrors.ErrForbidden
+		}
+		filter.UserID = &callerID
+	}
+
+	if filter.Order == "" {
+		filter.Order = models.SortDesc
+	}
+	if filter.Order != models.SortDesc && filter.Order != models.SortAsc {
+		return nil, apperrors.NewAppError("order must be asc or desc", http.StatusBadRequest)
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	if filter.Cursor != "" {
+		after, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, apperrors.ErrInvalidCursor
+		}
+		filter.After = after
+	}
+
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1 // one extra row tells us whether another page exists
+
+	transactions, err := s.transactionRepo.GetTransactions(filter)
+	if err != nil {
+		return nil, apperrors.ErrDatabaseError
+	}
+
+	page := &models.TransactionPage{Transactions: transactions}
+	if len(transactions) > pageSize {
+		page.Transactions = transactions[:pageSize]
+		last := page.Transactions[pageSize-1]
+		page.NextCursor = encodeCursor(models.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if page.Transactions == nil {
+		page.Transactions = []models.TransactionInfo{}
+	}
+	if err := s.feeService.AttachFees(s.database, page.Transactions); err != nil {
+		return nil, err
+	}
+	if err := s.attachStatusHistory(page.Transactions); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// attachStatusHistory loads every status change of each transaction.
+func (s *TransactionService) attachStatusHistory(transactions []models.TransactionInfo) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+	changes, err := s.transactionRepo.GetStatusHistory(ids)
+	if err != nil {
 		return apperrors.ErrDatabaseError
 	}
 
+	byTransaction := make(map[int64][]models.TransactionStatusChange)
+	for _, change := range changes {
+		byTransaction[change.TransactionID] = append(byTransaction[change.TransactionID], change)
+	}
+	for i := range transactions {
+		transactions[i].StatusHistory = byTransaction[transactions[i].ID]
+	}
 	return nil
 }
 
-func (s *TransactionService) GetTransactions() ([]models.TransactionInfo, error) {
-	transactions, err := s.transactionRepo.GetTransactions()
+func encodeCursor(cursor models.TransactionCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*models.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
 	if err != nil {
-		return nil, apperrors.ErrDatabaseError
+		return nil, err
+	}
+	var cursor models.TransactionCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID <= 0 || cursor.CreatedAt.IsZero() {
+		return nil, errors.New("cursor does not name a transaction")
 	}
-	return transactions, nil
+	return &cursor, nil
 }
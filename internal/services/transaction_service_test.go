@@ -0,0 +1,412 @@
+package services
+
+import (
+	"MockBankGo/auth"
+	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txOnlyDriver is a database that can only begin, commit and roll back
+// transactions, for services whose queries all go through faked
+// repositories.
+type txOnlyDriver struct{}
+
+func (txOnlyDriver) Open(name string) (driver.Conn, error) { return txOnlyConn{}, nil }
+
+type txOnlyConn struct{}
+
+func (txOnlyConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("txOnlyConn: queries are not supported")
+}
+
+func (txOnlyConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return txOnlyConn{}, nil
+}
+
+func (txOnlyConn) Begin() (driver.Tx, error) { return txOnlyConn{}, nil }
+func (txOnlyConn) Close() error              { return nil }
+func (txOnlyConn) Commit() error             { return nil }
+func (txOnlyConn) Rollback() error           { return nil }
+
+func init() {
+	sql.Register("txonly", txOnlyDriver{})
+}
+
+func txOnlyDB() *sqlx.DB {
+	return sqlx.MustOpen("txonly", "")
+}
+
+// fakeCompensation serves one completed transfer and the two accounts it
+// moved money between, with nothing reversed yet, and keeps what a
+// compensation writes.
+type fakeCompensation struct {
+	repositories.ITransationRepository
+	repositories.IAccountRepository
+	original *models.TransactionInfo
+	accounts map[int64]*models.Account
+	written  []*models.TransactionInfo
+}
+
+func (f *fakeCompensation) GetTransactionForUpdate(tx *sqlx.Tx, transactionID int64) (*models.TransactionInfo, error) {
+	return f.original, nil
+}
+
+func (f *fakeCompensation) GetReversedTotals(tx *sqlx.Tx, transactionID int64) (money.Money, money.Money, error) {
+	return money.Money{}, money.Money{}, nil
+}
+
+func (f *fakeCompensation) GetAccountForUpdate(tx *sqlx.Tx, accountID int64) (*models.Account, error) {
+	return f.accounts[accountID], nil
+}
+
+func (f *fakeCompensation) WriteTransaction(tx *sqlx.Tx, transaction *models.TransactionInfo) (int64, error) {
+	f.written = append(f.written, transaction)
+	transaction.ID = int64(100 + len(f.written))
+	transaction.Status = models.TransactionPending
+	return transaction.ID, nil
+}
+
+func (f *fakeCompensation) UpdateStatus(tx *sqlx.Tx, transactionID int64, from models.TransactionStatus, to models.TransactionStatus, reason *models.FailureReason, at time.Time) error {
+	return nil
+}
+
+func (f *fakeCompensation) IncreaseBalance(tx *sqlx.Tx, accountID int64, amount money.Money) error {
+	account := f.accounts[accountID]
+	account.Balance = account.Balance.Add(amount)
+	account.Available = account.Available.Add(amount)
+	return nil
+}
+
+func (f *fakeCompensation) DecreaseBalance(tx *sqlx.Tx, accountID int64, amount money.Money) error {
+	return f.IncreaseBalance(tx, accountID, amount.Neg())
+}
+
+// fakeBook keeps customer accounts and the ledger in memory. An account's
+// ledger account is 100 plus its ID; system accounts are numbered from 900
+// in the order they are first asked for.
+type fakeBook struct {
+	repositories.ILedgerRepository
+	repositories.IAccountRepository
+	accounts map[int64]*models.Account
+	ledger   map[int64]int64
+	system   map[string]int64
+	journals [][]models.LedgerEntry
+}
+
+// newFakeBook starts a ledger that agrees with the accounts' balances.
+func newFakeBook(accounts ...*models.Account) *fakeBook {
+	f := &fakeBook{accounts: map[int64]*models.Account{}, ledger: map[int64]int64{}, system: map[string]int64{}}
+	for _, account := range accounts {
+		f.accounts[account.ID] = account
+		f.ledger[100+account.ID] = -account.Balance.Amount
+	}
+	return f
+}
+
+func (f *fakeBook) GetAccountForUpdate(tx *sqlx.Tx, accountID int64) (*models.Account, error) {
+	account, ok := f.accounts[accountID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+func (f *fakeBook) GetOrCreateAccountLedger(tx *sqlx.Tx, account *models.Account) (*models.LedgerAccount, error) {
+	return &models.LedgerAccount{ID: 100 + account.ID, Currency: account.Currency}, nil
+}
+
+func (f *fakeBook) GetOrCreateSystemAccount(tx *sqlx.Tx, code string, currency money.Currency) (*models.LedgerAccount, error) {
+	key := code + ":" + string(currency)
+	if _, ok := f.system[key]; !ok {
+		f.system[key] = int64(900 + len(f.system))
+	}
+	return &models.LedgerAccount{ID: f.system[key], Code: code, Currency: currency}, nil
+}
+
+func (f *fakeBook) PostJournal(tx *sqlx.Tx, transactionID int64, description string, entries []models.LedgerEntry) (int64, error) {
+	for _, e := range entries {
+		f.ledger[e.AccountID] += e.Amount.Amount
+	}
+	f.journals = append(f.journals, entries)
+	return int64(len(f.journals)), nil
+}
+
+func (f *fakeBook) GetAccountBalance(tx *sqlx.Tx, accountID int64) (money.Money, error) {
+	return money.Money{Amount: f.ledger[accountID]}, nil
+}
+
+// balanced reports whether entries sum to zero within every currency.
+func balanced(entries []models.LedgerEntry) bool {
+	sums := map[money.Currency]int64{}
+	for _, e := range entries {
+		sums[e.Amount.Currency] += e.Amount.Amount
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+	return len(entries) >= 2
+}
+
+func TestMovementEntries_SameCurrency(t *testing.T) {
+	book := newFakeBook()
+	service := NewTransactionService(nil, book, book, nil, nil, nil, nil, nil, nil, nil)
+
+	entries, err := service.movementEntries(nil, 101, money.New(500, money.USD), 102, money.New(500, money.USD))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LedgerEntry{
+		models.Debit(101, money.New(500, money.USD)),
+		models.Credit(102, money.New(500, money.USD)),
+	}, entries)
+	assert.True(t, balanced(entries))
+	assert.Empty(t, book.system, "no FX position is touched")
+}
+
+// An exchange passes through the FX position account of each currency, so
+// the journal balances within both.
+func TestMovementEntries_BalancesWithinEachCurrency(t *testing.T) {
+	book := newFakeBook()
+	service := NewTransactionService(nil, book, book, nil, nil, nil, nil, nil, nil, nil)
+
+	entries, err := service.movementEntries(nil, 101, money.New(1000, money.USD), 102, money.New(920, money.EUR))
+
+	assert.NoError(t, err)
+	usdPosition := book.system[models.LedgerFXPositionCode+":USD"]
+	eurPosition := book.system[models.LedgerFXPositionCode+":EUR"]
+	assert.NotEqual(t, usdPosition, eurPosition)
+	assert.Equal(t, []models.LedgerEntry{
+		models.Debit(101, money.New(1000, money.USD)),
+		models.Credit(usdPosition, money.New(1000, money.USD)),
+		models.Debit(eurPosition, money.New(920, money.EUR)),
+		models.Credit(102, money.New(920, money.EUR)),
+	}, entries)
+	assert.True(t, balanced(entries))
+}
+
+func TestPostJournal_ChecksEveryAccountAgainstItsLedger(t *testing.T) {
+	from := &models.Account{ID: 1, Currency: money.USD, Balance: money.New(1000, money.USD)}
+	to := &models.Account{ID: 2, Currency: money.USD, Balance: money.New(0, money.USD)}
+	book := newFakeBook(from, to)
+	service := NewTransactionService(nil, book, book, nil, nil, nil, nil, nil, nil, nil)
+
+	// The cached balances moved with the journal.
+	from.Balance = money.New(600, money.USD)
+	to.Balance = money.New(400, money.USD)
+	err := service.postJournal(nil, 1, "transfer", []*models.Account{from, to},
+		models.Debit(101, money.New(400, money.USD)),
+		models.Credit(102, money.New(400, money.USD)),
+	)
+	assert.NoError(t, err)
+	assert.Len(t, book.journals, 1)
+
+	// The receiver's cached balance was not updated this time.
+	from.Balance = money.New(500, money.USD)
+	err = service.postJournal(nil, 2, "transfer", []*models.Account{from, to},
+		models.Debit(101, money.New(100, money.USD)),
+		models.Credit(102, money.New(100, money.USD)),
+	)
+	assert.Equal(t, apperrors.ErrLedgerMismatch, err)
+}
+
+func TestLockOwnAccount_ChecksOwnerStatusAndCurrency(t *testing.T) {
+	book := newFakeBook(
+		&models.Account{ID: 1, UserID: 7, Type: models.Checking, Currency: money.USD, Status: models.AccountOpen},
+		&models.Account{ID: 2, UserID: 7, Type: models.Savings, Currency: money.EUR, Status: models.AccountOpen},
+		&models.Account{ID: 3, UserID: 7, Type: models.Savings, Currency: money.USD, Status: models.AccountClosed},
+		&models.Account{ID: 4, UserID: 8, Type: models.Checking, Currency: money.USD, Status: models.AccountOpen},
+	)
+	service := NewTransactionService(nil, book, book, nil, nil, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name      string
+		accountID int64
+		currency  money.Currency
+		want      error
+	}{
+		{"own checking account", 1, money.USD, nil},
+		{"own account in another currency", 2, money.EUR, nil},
+		{"amount in another currency", 2, money.USD, apperrors.ErrCurrencyMismatch},
+		{"closed account", 3, money.USD, apperrors.ErrAccountClosed},
+		{"someone else's account", 4, money.USD, apperrors.ErrAccountNotFound},
+		{"no such account", 5, money.USD, apperrors.ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, err := service.lockOwnAccount(nil, 7, tt.accountID, money.New(100, tt.currency))
+			assert.Equal(t, tt.want, err)
+			if tt.want == nil {
+				assert.Equal(t, tt.accountID, account.ID)
+			}
+		})
+	}
+}
+
+// Without a currency, lockAccount takes an account in any currency, and
+// reports a missing one with the caller's error.
+func TestLockAccount_AnyCurrency(t *testing.T) {
+	book := newFakeBook(&models.Account{ID: 2, UserID: 7, Currency: money.EUR, Status: models.AccountOpen})
+	service := NewTransactionService(nil, book, book, nil, nil, nil, nil, nil, nil, nil)
+
+	account, err := service.lockAccount(nil, 2, "", apperrors.ErrReceiverNotFound)
+	assert.NoError(t, err)
+	assert.Equal(t, money.EUR, account.Currency)
+
+	_, err = service.lockAccount(nil, 3, "", apperrors.ErrReceiverNotFound)
+	assert.Equal(t, apperrors.ErrReceiverNotFound, err)
+}
+
+func TestFailureReason_RecordsOnlyRefusals(t *testing.T) {
+	reason, ok := failureReason(apperrors.ErrInsufficientFunds)
+	assert.True(t, ok)
+	assert.Equal(t, models.FailureInsufficientFunds, reason)
+
+	reason, ok = failureReason(&LimitExceeded{AppError: &apperrors.AppError{Message: "limit"}})
+	assert.True(t, ok)
+	assert.Equal(t, models.FailureLimitExceeded, reason)
+
+	reason, ok = failureReason(apperrors.ErrFXRateUnavailable)
+	assert.True(t, ok)
+	assert.Equal(t, models.FailureFXRateUnavailable, reason)
+
+	for _, err := range []error{apperrors.ErrAccountNotFound, apperrors.ErrInvalidAmount, apperrors.ErrDatabaseError, &IdempotentReplay{}} {
+		_, ok = failureReason(err)
+		assert.False(t, ok, err.Error())
+	}
+}
+
+func TestTransactionStatus_Transitions(t *testing.T) {
+	assert.True(t, models.TransactionPending.CanTransitionTo(models.TransactionCompleted))
+	assert.True(t, models.TransactionPending.CanTransitionTo(models.TransactionFailed))
+	assert.True(t, models.TransactionCompleted.CanTransitionTo(models.TransactionReversed))
+
+	assert.False(t, models.TransactionCompleted.CanTransitionTo(models.TransactionFailed))
+	assert.False(t, models.TransactionFailed.CanTransitionTo(models.TransactionCompleted))
+	assert.False(t, models.TransactionReversed.CanTransitionTo(models.TransactionCompleted))
+	assert.False(t, models.TransactionPending.CanTransitionTo(models.TransactionReversed))
+}
+
+// transition refuses a disallowed move before touching the database.
+func TestTransition_RejectsDisallowedMove(t *testing.T) {
+	service := &TransactionService{}
+	transaction := &models.TransactionInfo{ID: 1, Status: models.TransactionFailed}
+
+	err := service.transition(nil, transaction, models.TransactionCompleted, nil)
+
+	assert.Equal(t, apperrors.ErrInvalidStatusTransition, err)
+	assert.Equal(t, models.TransactionFailed, transaction.Status)
+}
+
+// A refund cannot send back money the receiver has reserved with a hold.
+func TestRefundTransaction_RespectsHolds(t *testing.T) {
+	sender, receiver := int64(1), int64(2)
+	payee := &models.Account{ID: receiver, UserID: 8, Type: models.Savings, Currency: money.USD, Status: models.AccountOpen,
+		Balance: money.New(1000, money.USD), Held: money.New(600, money.USD)}
+	payee.Available = payee.Balance.Sub(payee.Held)
+	fake := &fakeCompensation{
+		original: &models.TransactionInfo{ID: 5, SenderID: 7, ReceiverID: 8, FromAccountID: &sender, ToAccountID: &receiver,
+			Amount: money.New(1000, money.USD), Type: models.Transfer, Status: models.TransactionCompleted},
+		accounts: map[int64]*models.Account{
+			sender:   {ID: sender, UserID: 7, Type: models.Checking, Currency: money.USD, Status: models.AccountOpen},
+			receiver: payee,
+		},
+	}
+	service := NewTransactionService(fake, nil, fake, nil, nil, nil, nil, NewOverdraftService(&fakeOverdrafts{}, nil, nil), nil, txOnlyDB())
+
+	amount := money.New(500, money.USD)
+	_, err := service.RefundTransaction(context.Background(), 8, 5, &amount)
+
+	assert.Equal(t, apperrors.ErrInsufficientFunds, err)
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	cursor := models.TransactionCursor{CreatedAt: time.Date(2025, 6, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := decodeCursor(encodeCursor(cursor))
+
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestCursor_RejectsInvalidValues(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"not base64", "not a cursor!"},
+		{"not JSON", encode("42")},
+		{"wrong types", encode(`{"t":42,"id":"42"}`)},
+		{"no transaction", encode(`{}`)},
+		{"no time", encode(`{"id":42}`)},
+		{"no ID", encode(`{"t":"2025-06-01T12:00:00Z"}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// A bad cursor is refused before the repository is asked for anything.
+func TestGetTransactions_InvalidCursor(t *testing.T) {
+	service := NewTransactionService(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, err := service.GetTransactions(7, auth.User, models.TransactionFilter{Cursor: "not a cursor!"})
+
+	assert.Equal(t, apperrors.ErrInvalidCursor, err)
+}
+
+// A retried refund gets back the response the first one was answered with,
+// 201 Created included.
+func TestRefundTransaction_ReplaysTheOriginalResponse(t *testing.T) {
+	sender, receiver := int64(1), int64(2)
+	from := &models.Account{ID: sender, UserID: 7, Currency: money.USD, Status: models.AccountOpen,
+		Balance: money.New(0, money.USD), Available: money.New(0, money.USD)}
+	to := &models.Account{ID: receiver, UserID: 8, Currency: money.USD, Status: models.AccountOpen,
+		Balance: money.New(1000, money.USD), Available: money.New(1000, money.USD)}
+	fake := &fakeCompensation{
+		original: &models.TransactionInfo{ID: 5, SenderID: 7, ReceiverID: 8, FromAccountID: &sender, ToAccountID: &receiver,
+			Amount: money.New(1000, money.USD), Type: models.Transfer, Status: models.TransactionCompleted},
+		accounts: map[int64]*models.Account{sender: from, receiver: to},
+	}
+	book := newFakeBook(from, to)
+	idempotency := newFakeIdempotency()
+	service := NewTransactionService(fake, book, fake, idempotency, nil, nil, nil, NewOverdraftService(&fakeOverdrafts{}, nil, nil), nil, txOnlyDB())
+	ctx := WithIdempotencyKey(context.Background(), IdempotencyKey{Key: "refund-1", RequestHash: "hash-1"})
+
+	amount := money.New(400, money.USD)
+	refund, err := service.RefundTransaction(ctx, 8, 5, &amount)
+	require.NoError(t, err)
+	assert.Equal(t, money.New(600, money.USD), to.Balance)
+	assert.Equal(t, money.New(400, money.USD), from.Balance)
+
+	_, err = service.RefundTransaction(ctx, 8, 5, &amount)
+
+	replay, ok := err.(*IdempotentReplay)
+	require.True(t, ok, "got %v", err)
+	assert.Equal(t, http.StatusCreated, replay.StatusCode)
+	first, _ := json.Marshal(refund)
+	assert.Equal(t, string(first)+"\n", string(replay.Body))
+	assert.Len(t, fake.written, 1, "the retry moved no money")
+	assert.Equal(t, money.New(600, money.USD), to.Balance)
+}
@@ -4,6 +4,7 @@ import (
 	"MockBankGo/auth"
 	"MockBankGo/internal/apperrors"
 	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
 	"MockBankGo/internal/repositories"
 	"database/sql"
 	"strings"
@@ -12,11 +13,12 @@ import (
 )
 
 type UserService struct {
-	userRepo repositories.IUserRepository
+	userRepo       repositories.IUserRepository
+	accountService *AccountService
 }
 
-func NewUserService(UserRepo repositories.IUserRepository) *UserService {
-	return &UserService{userRepo: UserRepo}
+func NewUserService(UserRepo repositories.IUserRepository, accountService *AccountService) *UserService {
+	return &UserService{userRepo: UserRepo, accountService: accountService}
 }
 
 func (s *UserService) CreateUser(user *models.User) error {
@@ -43,41 +45,43 @@ func (s *UserService) CreateUser(user *models.User) error {
 	}
 
 	user.Password = string(hashedPassword)
-	user.Balance = 0
 	user.Role = auth.User
 
-	if err := s.userRepo.CreateUser(user); err != nil {
+	// Every user starts with a checking account, numbered and with its IBAN
+	// like any account opened later.
+	account, err := s.accountService.newAccount(0, models.Checking, money.DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.CreateUser(user, account); err != nil {
 		return apperrors.ErrDatabaseError
 	}
 
 	return nil
 }
 
-func (s *UserService) LoginUser(email, password string) (string, error) {
+// LoginUser checks the user's credentials. Tokens are issued by
+// SessionService.StartSession.
+func (s *UserService) LoginUser(email, password string) (*models.User, error) {
 	if email == "" || password == "" {
-		return "", apperrors.ErrEmailPasswordRequired
+		return nil, apperrors.ErrEmailPasswordRequired
 	}
 
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return "", apperrors.ErrUserNotFound
+			return nil, apperrors.ErrUserNotFound
 		}
-		return "", apperrors.ErrDatabaseError
+		return nil, apperrors.ErrDatabaseError
 	}
 
 	password = strings.TrimSpace(password)
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", apperrors.ErrInvalidCredentials
+		return nil, apperrors.ErrInvalidCredentials
 	}
 
-	token, err := auth.GenerateJWT(user.ID, string(user.Role))
-	if err != nil {
-		return "", apperrors.ErrGenerateToken
-	}
-
-	return token, nil
+	return user, nil
 }
 
 func (s *UserService) GetAllUsers() ([]models.User, error) {
@@ -105,3 +109,23 @@ func (s *UserService) GetUserProfile(userID int64) (*models.User, error) {
 	user.Password = ""
 	return user, nil
 }
+
+// ChangeRole promotes or demotes a user. Admins cannot change their own role,
+// which keeps the last admin from locking everyone out. The new role applies
+// to tokens issued after the change.
+func (s *UserService) ChangeRole(actorID int64, userID int64, role auth.Role) error {
+	if !role.Valid() {
+		return apperrors.ErrInvalidRole
+	}
+	if actorID == userID {
+		return apperrors.ErrChangeOwnRole
+	}
+
+	if err := s.userRepo.UpdateUserRole(userID, role); err != nil {
+		if err == sql.ErrNoRows {
+			return apperrors.ErrUserNotFound
+		}
+		return apperrors.ErrDatabaseError
+	}
+	return nil
+}
@@ -10,7 +10,10 @@ import (
 
 	"MockBankGo/auth"
 	"MockBankGo/internal/apperrors"
+	"MockBankGo/internal/iban"
 	"MockBankGo/internal/models"
+	"MockBankGo/internal/money"
+	"MockBankGo/internal/repositories"
 )
 
 type MockUserRepository struct {
@@ -40,8 +43,8 @@ func (m *MockUserRepository) GetUsers() ([]models.User, error) {
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) CreateUser(user *models.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) CreateUser(user *models.User, account *models.Account) error {
+	args := m.Called(user, account)
 	return args.Error(0)
 }
 
@@ -54,6 +57,45 @@ func (m *MockUserRepository) GetUserByUsername(username string) (*models.User, e
 	return user, args.Error(1)
 }
 
+func (m *MockUserRepository) UpdateUserRole(id int64, role auth.Role) error {
+	args := m.Called(id, role)
+	return args.Error(0)
+}
+
+// fakeAccountNumbers hands out one account number.
+type fakeAccountNumbers struct {
+	repositories.IAccountRepository
+	number string
+}
+
+func (f *fakeAccountNumbers) NextAccountNumber() (string, error) {
+	return f.number, nil
+}
+
+func TestCreateUser_DefaultAccountHasIBAN(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetUserByEmail", "ada@example.com").Return(nil, sql.ErrNoRows)
+	mockRepo.On("GetUserByUsername", "ada").Return(nil, sql.ErrNoRows)
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(nil)
+
+	issuer, err := iban.NewIssuer("DE", "12345678")
+	assert.NoError(t, err)
+	accountService := NewAccountService(&fakeAccountNumbers{number: "001000000001"}, issuer, nil)
+	service := NewUserService(mockRepo, accountService)
+
+	err = service.CreateUser(&models.User{Username: "ada", Email: "ada@example.com", Password: "securepassword"})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	account := mockRepo.Calls[2].Arguments.Get(1).(*models.Account)
+	assert.Equal(t, "001000000001", account.Number)
+	assert.Equal(t, models.Checking, account.Type)
+	assert.Equal(t, money.DefaultCurrency, account.Currency)
+	if assert.NotNil(t, account.IBAN) {
+		assert.Equal(t, "DE69123456781000000001", *account.IBAN)
+	}
+}
+
 func TestLoginUser_Success(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 
@@ -71,14 +113,14 @@ func TestLoginUser_Success(t *testing.T) {
 	// Setup mock: when GetUserByEmail is called, return our user, no error.
 	mockRepo.On("GetUserByEmail", user.Email).Return(user, nil)
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	// Act
-	token, err := service.LoginUser(user.Email, password)
+	loggedIn, err := service.LoginUser(user.Email, password)
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.Equal(t, user.ID, loggedIn.ID)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -97,13 +139,13 @@ func TestLoginUser_WrongPassword(t *testing.T) {
 
 	mockRepo.On("GetUserByEmail", user.Email).Return(user, nil)
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
 	// Try wrong password
-	token, err := service.LoginUser(user.Email, "wrongpassword")
+	loggedIn, err := service.LoginUser(user.Email, "wrongpassword")
 
 	assert.Equal(t, apperrors.ErrInvalidCredentials, err)
-	assert.Empty(t, token)
+	assert.Nil(t, loggedIn)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -115,24 +157,68 @@ func TestLoginUser_UserNotFound(t *testing.T) {
 	// Return nil user and an sql.ErrNoRows-like behavior: just nil user and no error triggers user not found logic.
 	mockRepo.On("GetUserByEmail", email).Return(nil, sql.ErrNoRows)
 
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
-	token, err := service.LoginUser(email, "anyPassword")
+	loggedIn, err := service.LoginUser(email, "anyPassword")
 
 	assert.Equal(t, apperrors.ErrUserNotFound, err)
-	assert.Empty(t, token)
+	assert.Nil(t, loggedIn)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestLoginUser_EmptyInput(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	service := NewUserService(mockRepo)
+	service := NewUserService(mockRepo, nil)
 
-	token, err := service.LoginUser("", "")
+	loggedIn, err := service.LoginUser("", "")
 
 	assert.Equal(t, apperrors.ErrEmailPasswordRequired, err)
-	assert.Empty(t, token)
+	assert.Nil(t, loggedIn)
 
 	// No repo method should be called in this case
 	mockRepo.AssertExpectations(t)
 }
+
+func TestChangeRole_Success(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("UpdateUserRole", int64(2), auth.Admin).Return(nil)
+
+	service := NewUserService(mockRepo, nil)
+
+	err := service.ChangeRole(1, 2, auth.Admin)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangeRole_OwnRole(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil)
+
+	err := service.ChangeRole(1, 1, auth.User)
+
+	assert.Equal(t, apperrors.ErrChangeOwnRole, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangeRole_InvalidRole(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, nil)
+
+	err := service.ChangeRole(1, 2, auth.Role("superuser"))
+
+	assert.Equal(t, apperrors.ErrInvalidRole, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangeRole_UserNotFound(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("UpdateUserRole", int64(42), auth.User).Return(sql.ErrNoRows)
+
+	service := NewUserService(mockRepo, nil)
+
+	err := service.ChangeRole(1, 42, auth.User)
+
+	assert.Equal(t, apperrors.ErrUserNotFound, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"MockBankGo/auth"
 	"MockBankGo/db"
 	"MockBankGo/internal"
 	"MockBankGo/middleware"
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -21,24 +23,132 @@ func main() {
 		log.Fatalf("Migration error: %v", err)
 	}
 
+	if err := internal.AssignIBANs(database); err != nil {
+		log.Fatalf("IBAN assignment error: %v", err)
+	}
+
+	keySet, err := auth.LoadKeySetFromEnv()
+	if err != nil {
+		log.Fatalf("JWT key error: %v", err)
+	}
+	auth.SetKeySet(keySet)
+
 	router := mux.NewRouter()
-	router.Use(middleware.Logger)
+	router.Use(middleware.RequestID, middleware.Logger)
 
 	protected := router.NewRoute().Subrouter()
-	protected.Use(middleware.JWTAuth)
+	protected.Use(middleware.JWTAuth(internal.InitSessionService(database)))
 
 	userHandler := internal.InitUserHandler(database)
 	transactionHandler := internal.InitTransactionHandler(database)
+	accountHandler := internal.InitAccountHandler(database)
+	standingOrderHandler := internal.InitStandingOrderHandler(database)
+	auditHandler := internal.InitAuditHandler(database)
+	limitHandler := internal.InitLimitHandler(database)
+	fxHandler := internal.InitFXHandler(database)
+	feeHandler := internal.InitFeeHandler(database)
+	holdHandler := internal.InitHoldHandler(database)
+	overdraftHandler := internal.InitOverdraftHandler(database)
+	notificationHandler := internal.InitNotificationHandler(database)
+	interestHandler := internal.InitInterestHandler(database)
+	loanHandler := internal.InitLoanHandler(database)
+	termDepositHandler := internal.InitTermDepositHandler(database)
+	payeeHandler := internal.InitPayeeHandler(database)
+	statementHandler := internal.InitStatementHandler(database)
+	exportHandler := internal.InitExportHandler(database)
+
+	keyHandler := internal.InitKeyHandler(keySet)
 
-	router.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
+	router.HandleFunc("/.well-known/jwks.json", keyHandler.JWKS).Methods("GET")
 	router.HandleFunc("/signup", userHandler.Signup).Methods("POST")
 	router.HandleFunc("/login", userHandler.Login).Methods("POST")
+	router.HandleFunc("/token/refresh", userHandler.Refresh).Methods("POST")
 
-	protected.HandleFunc("/withdraw", transactionHandler.Withdraw).Methods("POST")
-	protected.HandleFunc("/deposit", transactionHandler.Deposit).Methods("POST")
-	protected.HandleFunc("/transfer", transactionHandler.Transfer).Methods("POST")
-	protected.HandleFunc("/transactions", transactionHandler.GetTransactions).Methods("GET")
+	// guard wraps a handler so it only runs for roles granting perm.
+	guard := func(perm auth.Permission, h http.HandlerFunc) http.Handler {
+		return middleware.RequirePermission(perm)(h)
+	}
+
+	protected.Handle("/withdraw", guard(auth.PermMoveOwnMoney, transactionHandler.Withdraw)).Methods("POST")
+	protected.Handle("/deposit", guard(auth.PermMoveOwnMoney, transactionHandler.Deposit)).Methods("POST")
+	protected.Handle("/transfer", guard(auth.PermMoveOwnMoney, transactionHandler.Transfer)).Methods("POST")
+	protected.Handle("/payees", guard(auth.PermMoveOwnMoney, payeeHandler.List)).Methods("GET")
+	protected.Handle("/payees", guard(auth.PermMoveOwnMoney, payeeHandler.Create)).Methods("POST")
+	protected.Handle("/payees/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, payeeHandler.Delete)).Methods("DELETE")
+	protected.Handle("/recipients/lookup", guard(auth.PermMoveOwnMoney, payeeHandler.Lookup)).Methods("GET")
+	protected.Handle("/fx/quote", guard(auth.PermMoveOwnMoney, fxHandler.Quote)).Methods("GET")
+	protected.HandleFunc("/fx/rates", fxHandler.ListRates).Methods("GET")
+	protected.Handle("/fees/preview", guard(auth.PermMoveOwnMoney, feeHandler.Preview)).Methods("GET")
+	protected.Handle("/holds", guard(auth.PermMoveOwnMoney, holdHandler.List)).Methods("GET")
+	protected.Handle("/holds", guard(auth.PermMoveOwnMoney, holdHandler.Create)).Methods("POST")
+	protected.Handle("/holds/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, holdHandler.Get)).Methods("GET")
+	protected.Handle("/holds/{id:[0-9]+}/capture", guard(auth.PermMoveOwnMoney, holdHandler.Capture)).Methods("POST")
+	protected.Handle("/holds/{id:[0-9]+}/release", guard(auth.PermMoveOwnMoney, holdHandler.Release)).Methods("POST")
+	protected.Handle("/loans", guard(auth.PermMoveOwnMoney, loanHandler.List)).Methods("GET")
+	protected.Handle("/loans", guard(auth.PermMoveOwnMoney, loanHandler.Apply)).Methods("POST")
+	protected.Handle("/loans/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, loanHandler.Get)).Methods("GET")
+	protected.Handle("/loans/{id:[0-9]+}/repay", guard(auth.PermMoveOwnMoney, loanHandler.Repay)).Methods("POST")
+	protected.Handle("/term-deposit-products", guard(auth.PermMoveOwnMoney, termDepositHandler.ListOpenProducts)).Methods("GET")
+	protected.Handle("/term-deposits", guard(auth.PermMoveOwnMoney, termDepositHandler.List)).Methods("GET")
+	protected.Handle("/term-deposits", guard(auth.PermMoveOwnMoney, termDepositHandler.Open)).Methods("POST")
+	protected.Handle("/term-deposits/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, termDepositHandler.Get)).Methods("GET")
+	protected.Handle("/term-deposits/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, termDepositHandler.SetRollover)).Methods("PATCH")
+	protected.Handle("/term-deposits/{id:[0-9]+}/break", guard(auth.PermMoveOwnMoney, termDepositHandler.Break)).Methods("POST")
+	protected.Handle("/transactions", guard(auth.PermReadOwnTransactions, transactionHandler.GetTransactions)).Methods("GET")
+	protected.Handle("/transactions/export", guard(auth.PermReadOwnTransactions, exportHandler.Export)).Methods("GET")
+	protected.Handle("/transactions/{id:[0-9]+}/refund", guard(auth.PermRefundReceived, transactionHandler.Refund)).Methods("POST")
+	protected.Handle("/transactions/{id:[0-9]+}/reverse", guard(auth.PermReverseTransactions, transactionHandler.Reverse)).Methods("POST")
+	protected.Handle("/statements", guard(auth.PermReadOwnTransactions, statementHandler.Statement)).Methods("GET")
+	protected.Handle("/statements/{id:[0-9]+}", guard(auth.PermReadOwnTransactions, statementHandler.Get)).Methods("GET")
+	protected.Handle("/statements/{period:[0-9]+-[0-9]+}.pdf", guard(auth.PermReadOwnTransactions, exportHandler.StatementPDF)).Methods("GET")
+	protected.Handle("/standing-orders", guard(auth.PermMoveOwnMoney, standingOrderHandler.List)).Methods("GET")
+	protected.Handle("/standing-orders", guard(auth.PermMoveOwnMoney, standingOrderHandler.Create)).Methods("POST")
+	protected.Handle("/standing-orders/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, standingOrderHandler.Get)).Methods("GET")
+	protected.Handle("/standing-orders/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, standingOrderHandler.Update)).Methods("PATCH")
+	protected.Handle("/standing-orders/{id:[0-9]+}", guard(auth.PermMoveOwnMoney, standingOrderHandler.Cancel)).Methods("DELETE")
 	protected.HandleFunc("/profile", userHandler.Profile).Methods("GET")
+	protected.HandleFunc("/notifications", notificationHandler.List).Methods("GET")
+	protected.HandleFunc("/notifications/{id:[0-9]+}/read", notificationHandler.MarkRead).Methods("POST")
+	protected.HandleFunc("/logout", userHandler.Logout).Methods("POST")
+	protected.HandleFunc("/logout/all", userHandler.LogoutAll).Methods("POST")
+	protected.Handle("/accounts", guard(auth.PermManageOwnAccounts, accountHandler.ListAccounts)).Methods("GET")
+	protected.Handle("/accounts", guard(auth.PermManageOwnAccounts, accountHandler.OpenAccount)).Methods("POST")
+	protected.Handle("/accounts/{id:[0-9]+}/close", guard(auth.PermManageOwnAccounts, accountHandler.CloseAccount)).Methods("POST")
+	protected.Handle("/accounts/{id:[0-9]+}/interest", guard(auth.PermManageOwnAccounts, interestHandler.AccountInterest)).Methods("GET")
+	protected.Handle("/accounts/{id:[0-9]+}/statements", guard(auth.PermReadOwnTransactions, statementHandler.List)).Methods("GET")
+
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireRole(auth.Admin))
+
+	admin.Handle("/users", guard(auth.PermReadUsers, userHandler.GetUsers)).Methods("GET")
+	admin.Handle("/users/{id:[0-9]+}/role", guard(auth.PermManageRoles, userHandler.ChangeRole)).Methods("PATCH")
+	admin.Handle("/users/{id:[0-9]+}/overdrafts", guard(auth.PermManageOverdrafts, overdraftHandler.List)).Methods("GET")
+	admin.Handle("/users/{id:[0-9]+}/overdraft", guard(auth.PermManageOverdrafts, overdraftHandler.Set)).Methods("PUT")
+	admin.Handle("/interest-products", guard(auth.PermManageInterest, interestHandler.ListProducts)).Methods("GET")
+	admin.Handle("/interest-products", guard(auth.PermManageInterest, interestHandler.CreateProduct)).Methods("POST")
+	admin.Handle("/interest-products/{id:[0-9]+}", guard(auth.PermManageInterest, interestHandler.UpdateProduct)).Methods("PUT")
+	admin.Handle("/interest-attachments", guard(auth.PermManageInterest, interestHandler.ListAttachments)).Methods("GET")
+	admin.Handle("/interest-attachments", guard(auth.PermManageInterest, interestHandler.Attach)).Methods("PUT")
+	admin.Handle("/interest-attachments/{id:[0-9]+}", guard(auth.PermManageInterest, interestHandler.Detach)).Methods("DELETE")
+	admin.Handle("/loans", guard(auth.PermManageLoans, loanHandler.ListAll)).Methods("GET")
+	admin.Handle("/loans/{id:[0-9]+}/approve", guard(auth.PermManageLoans, loanHandler.Approve)).Methods("POST")
+	admin.Handle("/loans/{id:[0-9]+}/reject", guard(auth.PermManageLoans, loanHandler.Reject)).Methods("POST")
+	admin.Handle("/term-deposit-products", guard(auth.PermManageTermDeposits, termDepositHandler.ListProducts)).Methods("GET")
+	admin.Handle("/term-deposit-products", guard(auth.PermManageTermDeposits, termDepositHandler.CreateProduct)).Methods("POST")
+	admin.Handle("/term-deposit-products/{id:[0-9]+}", guard(auth.PermManageTermDeposits, termDepositHandler.UpdateProduct)).Methods("PUT")
+	admin.Handle("/limits", guard(auth.PermManageLimits, limitHandler.ListLimits)).Methods("GET")
+	admin.Handle("/limits", guard(auth.PermManageLimits, limitHandler.SetLimit)).Methods("PUT")
+	admin.Handle("/limits/{id:[0-9]+}", guard(auth.PermManageLimits, limitHandler.DeleteLimit)).Methods("DELETE")
+	admin.Handle("/fees", guard(auth.PermManageFees, feeHandler.ListRules)).Methods("GET")
+	admin.Handle("/fees", guard(auth.PermManageFees, feeHandler.SetRule)).Methods("PUT")
+	admin.Handle("/fees/{id:[0-9]+}", guard(auth.PermManageFees, feeHandler.DeleteRule)).Methods("DELETE")
+	admin.Handle("/fx/rates", guard(auth.PermManageFXRates, fxHandler.SetRates)).Methods("PUT")
+	admin.Handle("/statement-pdfs/{period:[0-9]+-[0-9]+}", guard(auth.PermManageStatements, exportHandler.GenerateStatementPDFs)).Methods("POST")
+	admin.Handle("/audit", guard(auth.PermReadAuditLog, auditHandler.Search)).Methods("GET")
+	admin.Handle("/audit/verify", guard(auth.PermReadAuditLog, auditHandler.Verify)).Methods("GET")
+
+	scheduler := internal.InitScheduler(database)
+	scheduler.Start(context.Background())
 
 	log.Printf("Server running on %s", os.Getenv("LISTEN_ADDR"))
 	if err := http.ListenAndServe(os.Getenv("LISTEN_ADDR"), router); err != nil {
@@ -3,6 +3,7 @@ package middleware
 import (
 	"MockBankGo/auth"
 	"context"
+	"log"
 	"net/http"
 	"strings"
 )
@@ -13,28 +14,49 @@ type contextKey string
 // Define a non-exported variable for the user context key
 var userIDKey = contextKey("userID")
 var userRoleKey = contextKey("userRole")
+var tokenKey = contextKey("token")
 
-func JWTAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
+// RevocationChecker reports whether an access token has been revoked by
+// logout or refresh-token reuse.
+type RevocationChecker interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+func JWTAuth(revocations RevocationChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+
+			if !strings.HasPrefix(authHeader, "Bearer ") {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
 
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			claims, err := auth.VerifyJWT(tokenString)
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
 
-		userID, role, err := auth.VerifyJWT(tokenString)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			revoked, err := revocations.IsRevoked(claims.ID)
+			if err != nil {
+				log.Printf("revocation check for token %s: %v", claims.ID, err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if revoked {
+				http.Error(w, "Token revoked", http.StatusUnauthorized)
+				return
+			}
 
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
-		ctx = context.WithValue(ctx, userRoleKey, role)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, userRoleKey, claims.Role)
+			ctx = context.WithValue(ctx, tokenKey, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 func GetUserID(ctx context.Context) (int64, bool) {
@@ -46,3 +68,9 @@ func GetUserRole(ctx context.Context) (auth.Role, bool) {
 	role, ok := ctx.Value(userRoleKey).(auth.Role)
 	return auth.Role(role), ok
 }
+
+// GetToken returns the claims of the access token that authenticated the request.
+func GetToken(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(tokenKey).(*auth.Claims)
+	return claims, ok
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"MockBankGo/auth"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRevocations map[string]bool
+
+func (f fakeRevocations) IsRevoked(jti string) (bool, error) {
+	if jti == "broken" {
+		return false, errors.New("database down")
+	}
+	return f[jti], nil
+}
+
+func requestWithToken(t *testing.T, jti string) *http.Request {
+	token, err := auth.GenerateJWT(7, jti)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestJWTAuth_ValidToken(t *testing.T) {
+	rec := httptest.NewRecorder()
+	var gotID int64
+	var gotJTI string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, _ = GetUserID(r.Context())
+		claims, _ := GetToken(r.Context())
+		gotJTI = claims.ID
+	})
+	JWTAuth(fakeRevocations{})(handler).ServeHTTP(rec, requestWithToken(t, "live"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, int64(7), gotID)
+	assert.Equal(t, "live", gotJTI)
+}
+
+func TestJWTAuth_RevokedToken(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	JWTAuth(fakeRevocations{"gone": true})(okHandler()).ServeHTTP(rec, requestWithToken(t, "gone"))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestJWTAuth_RevocationCheckFails(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	JWTAuth(fakeRevocations{})(okHandler()).ServeHTTP(rec, requestWithToken(t, "broken"))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
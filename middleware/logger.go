@@ -23,7 +23,7 @@ func Logger(next http.Handler) http.Handler {
 
 		next.ServeHTTP(rw, r)
 
-		log.Printf("incoming request %s %s from %s - status %d",
-			r.Method, r.URL.Path, r.RemoteAddr, rw.statusCode)
+		log.Printf("incoming request %s %s from %s - status %d - request %s",
+			r.Method, r.URL.Path, r.RemoteAddr, rw.statusCode, GetRequestID(r.Context()))
 	})
 }
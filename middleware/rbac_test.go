@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"MockBankGo/auth"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func requestWithRole(role auth.Role) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	ctx := context.WithValue(req.Context(), userIDKey, int64(1))
+	ctx = context.WithValue(ctx, userRoleKey, role)
+	return req.WithContext(ctx)
+}
+
+func TestRequirePermission_UserGetsForbidden(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	RequirePermission(auth.PermReadUsers)(okHandler()).ServeHTTP(rec, requestWithRole(auth.User))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRequirePermission_UnknownRoleGetsForbidden(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	RequirePermission(auth.PermMoveOwnMoney)(okHandler()).ServeHTTP(rec, requestWithRole(auth.Role("guest")))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestRequirePermission_AdminAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	RequirePermission(auth.PermManageRoles)(okHandler()).ServeHTTP(rec, requestWithRole(auth.Admin))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequirePermission_UserAllowedOwnPermission(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	RequirePermission(auth.PermMoveOwnMoney)(okHandler()).ServeHTTP(rec, requestWithRole(auth.User))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequirePermission_NoRoleUnauthorized(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+
+	RequirePermission(auth.PermReadUsers)(okHandler()).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRequireRole_UserGetsForbidden(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	RequireRole(auth.Admin)(okHandler()).ServeHTTP(rec, requestWithRole(auth.User))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID_KeepsClientID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("X-Request-ID", "client-123")
+	var got string
+
+	RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = GetRequestID(r.Context())
+	})).ServeHTTP(rec, req)
+
+	assert.Equal(t, "client-123", got)
+	assert.Equal(t, "client-123", rec.Header().Get("X-Request-ID"))
+}
+
+func TestRequestID_ReplacesMalformedID(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.Header.Set("X-Request-ID", "bad id\nwith newline")
+
+	RequestID(okHandler()).ServeHTTP(rec, req)
+
+	assert.Len(t, rec.Header().Get("X-Request-ID"), 32)
+}
+
+func TestClientIP_IgnoresForwardedFor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+	req.RemoteAddr = "203.0.113.9:51234"
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+
+	assert.Equal(t, "203.0.113.9", ClientIP(req))
+}